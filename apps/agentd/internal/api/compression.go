@@ -0,0 +1,63 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const encodingGzip = "gzip"
+
+// negotiateContentEncoding picks the response encoding from Accept-Encoding.
+// It returns "" for identity.
+func negotiateContentEncoding(acceptEncoding string) string {
+	gzipQ, wildcardQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != encodingGzip && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok &&
+			strings.EqualFold(strings.TrimSpace(name), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if coding == "*" {
+			wildcardQ = q
+		} else if q > gzipQ {
+			gzipQ = q
+		}
+	}
+	// An explicit gzip entry takes precedence over the wildcard.
+	if gzipQ > 0 || (gzipQ < 0 && wildcardQ > 0) {
+		return encodingGzip
+	}
+	return ""
+}
+
+// encodedWriter compresses into w. Nothing reaches w until the first Write,
+// so callers can still fall back to a JSON error response when no data was produced.
+type encodedWriter struct {
+	io.Writer
+	close func() error
+}
+
+func newEncodedWriter(w io.Writer, encoding string) *encodedWriter {
+	switch encoding {
+	case encodingGzip:
+		gz := gzip.NewWriter(w)
+		return &encodedWriter{Writer: gz, close: gz.Close}
+	default:
+		return &encodedWriter{Writer: w, close: func() error { return nil }}
+	}
+}
+
+func (e *encodedWriter) Close() error {
+	return e.close()
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
+)
+
+const (
+	exportJobStateQueued    = "queued"
+	exportJobStateRunning   = "running"
+	exportJobStateSucceeded = "succeeded"
+	exportJobStateFailed    = "failed"
+	exportJobStateCanceled  = "canceled"
+
+	exportJobDefaultConcurrency = 2
+	exportJobDefaultTimeout     = 30 * time.Minute
+	exportJobDefaultTTL         = time.Hour
+	// exportJobMaxRetained bounds the job table. Finished jobs are evicted
+	// oldest first to make room, so only queued and running jobs can fill it.
+	exportJobMaxRetained = 64
+)
+
+var (
+	errExportJobNotFound = errors.New("export job not found")
+	errExportJobTooMany  = errors.New("too many queued or running export jobs; wait for some to finish or cancel them")
+)
+
+// ExportJobConfig controls the asynchronous audit log export jobs.
+type ExportJobConfig struct {
+	// Concurrency bounds how many jobs talk to Doris at the same time.
+	Concurrency int
+	// Timeout bounds a single job, including time spent queued.
+	Timeout time.Duration
+	// TTL is how long a finished job and its spooled file are kept.
+	TTL time.Duration
+	// Dir is where result files are spooled; empty uses the OS temp dir.
+	Dir string
+}
+
+func (c ExportJobConfig) normalized() ExportJobConfig {
+	if c.Concurrency <= 0 {
+		c.Concurrency = exportJobDefaultConcurrency
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = exportJobDefaultTimeout
+	}
+	if c.TTL <= 0 {
+		c.TTL = exportJobDefaultTTL
+	}
+	return c
+}
+
+type exportJobStatus struct {
+	ID           string                `json:"id"`
+	State        string                `json:"state"`
+	Format       string                `json:"format"`
+	RowsWritten  int64                 `json:"rowsWritten"`
+	BytesWritten int64                 `json:"bytesWritten"`
+	HasMore      bool                  `json:"hasMore"`
+	NextCursor   string                `json:"nextCursor,omitempty"`
+	Schema       *doris.AuditLogSchema `json:"schema,omitempty"`
+	Error        string                `json:"error,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	StartedAt    *time.Time            `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time            `json:"finishedAt,omitempty"`
+	ExpiresAt    *time.Time            `json:"expiresAt,omitempty"`
+}
+
+type exportJob struct {
+	status exportJobStatus
+	path   string
+	cancel context.CancelFunc
+	expiry *time.Timer
+
+	// Progress counters are updated by the export goroutine without holding the manager lock.
+	bytes atomic.Int64
+	lines atomic.Int64
+}
+
+type exportJobManager struct {
+	exporter AuditLogExporter
+	cfg      ExportJobConfig
+	sem      chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*exportJob
+}
+
+func newExportJobManager(exporter AuditLogExporter, cfg ExportJobConfig) *exportJobManager {
+	cfg = cfg.normalized()
+	return &exportJobManager{
+		exporter: exporter,
+		cfg:      cfg,
+		sem:      make(chan struct{}, cfg.Concurrency),
+		jobs:     make(map[string]*exportJob),
+	}
+}
+
+func (m *exportJobManager) submit(cfg doris.ConnConfig, opts doris.AuditLogExportOptions) (exportJobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.jobs) >= exportJobMaxRetained && !m.evictOldestFinishedLocked() {
+		return exportJobStatus{}, errExportJobTooMany
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	job := &exportJob{
+		status: exportJobStatus{
+			ID:        generateTraceID(),
+			State:     exportJobStateQueued,
+			Format:    opts.Format,
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[job.status.ID] = job
+	applyReadWriteTimeout(&cfg, m.cfg.Timeout+10*time.Second)
+	go m.run(ctx, job, cfg, opts)
+	return job.snapshot(), nil
+}
+
+// evictOldestFinishedLocked drops the finished job that finished first, with
+// its result. It reports false when every retained job is still queued or running.
+func (m *exportJobManager) evictOldestFinishedLocked() bool {
+	var oldest *exportJob
+	for _, job := range m.jobs {
+		if job.status.FinishedAt == nil {
+			continue
+		}
+		if oldest == nil || job.status.FinishedAt.Before(*oldest.status.FinishedAt) {
+			oldest = job
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(m.jobs, oldest.status.ID)
+	if oldest.expiry != nil {
+		oldest.expiry.Stop()
+	}
+	removeSpoolFile(oldest.path)
+	return true
+}
+
+// spoolDir is where export results are spooled; empty means the OS temp dir.
+func (m *exportJobManager) spoolDir() string {
+	return m.cfg.Dir
+}
+
+func (m *exportJobManager) run(ctx context.Context, job *exportJob, cfg doris.ConnConfig, opts doris.AuditLogExportOptions) {
+	defer job.cancel()
+
+	select {
+	case m.sem <- struct{}{}:
+		defer func() { <-m.sem }()
+	case <-ctx.Done():
+		m.finish(job, doris.AuditLogExportResult{}, ctx.Err())
+		return
+	}
+
+	f, err := os.CreateTemp(m.spoolDir(), "agentd-audit-export-*")
+	if err != nil {
+		m.finish(job, doris.AuditLogExportResult{}, fmt.Errorf("create spool file: %w", err))
+		return
+	}
+	m.mu.Lock()
+	job.path = f.Name()
+	started := time.Now().UTC()
+	job.status.StartedAt = &started
+	if job.status.State == exportJobStateQueued {
+		job.status.State = exportJobStateRunning
+	}
+	m.mu.Unlock()
+
+	opts.OnSchema = func(schema doris.AuditLogSchema) {
+		m.mu.Lock()
+		job.status.Schema = &schema
+		m.mu.Unlock()
+	}
+	result, err := m.exporter(ctx, cfg, opts, &exportJobProgressWriter{w: f, job: job})
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	m.finish(job, result, err)
+}
+
+func (m *exportJobManager) finish(job *exportJob, result doris.AuditLogExportResult, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finished := time.Now().UTC()
+	expires := finished.Add(m.cfg.TTL)
+	job.status.FinishedAt = &finished
+	job.status.ExpiresAt = &expires
+	switch {
+	case job.status.State == exportJobStateCanceled:
+	case err != nil:
+		job.status.State = exportJobStateFailed
+		job.status.Error = err.Error()
+	default:
+		job.status.State = exportJobStateSucceeded
+		job.status.RowsWritten = int64(result.Rows)
+		job.status.HasMore = result.HasMore
+		job.status.NextCursor = result.NextCursor
+	}
+	if job.status.State != exportJobStateSucceeded {
+		removeSpoolFile(job.path)
+		job.path = ""
+	}
+
+	id := job.status.ID
+	if _, ok := m.jobs[id]; !ok {
+		removeSpoolFile(job.path)
+		return
+	}
+	job.expiry = time.AfterFunc(m.cfg.TTL, func() { m.remove(id) })
+}
+
+func (m *exportJobManager) get(id string) (exportJobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return exportJobStatus{}, errExportJobNotFound
+	}
+	return job.snapshot(), nil
+}
+
+// cancel stops a queued or running job. A finished job is deleted together with its result.
+func (m *exportJobManager) cancel(id string) (exportJobStatus, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return exportJobStatus{}, errExportJobNotFound
+	}
+	if job.status.FinishedAt == nil {
+		job.status.State = exportJobStateCanceled
+		status := job.snapshot()
+		m.mu.Unlock()
+		job.cancel()
+		return status, nil
+	}
+	status := job.snapshot()
+	m.mu.Unlock()
+	m.remove(id)
+	return status, nil
+}
+
+// openResult returns the spooled result of a succeeded job. The caller closes the file.
+func (m *exportJobManager) openResult(id string) (*os.File, exportJobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, exportJobStatus{}, errExportJobNotFound
+	}
+	status := job.snapshot()
+	if status.State != exportJobStateSucceeded || job.path == "" {
+		return nil, status, fmt.Errorf("export job is %s; result is not available", status.State)
+	}
+	f, err := os.Open(job.path)
+	if err != nil {
+		return nil, status, err
+	}
+	return f, status, nil
+}
+
+func (m *exportJobManager) remove(id string) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.jobs, id)
+	expiry, path := job.expiry, job.path
+	m.mu.Unlock()
+
+	if expiry != nil {
+		expiry.Stop()
+	}
+	job.cancel()
+	removeSpoolFile(path)
+}
+
+func (j *exportJob) snapshot() exportJobStatus {
+	status := j.status
+	status.BytesWritten = j.bytes.Load()
+	if status.FinishedAt == nil {
+		switch {
+		case doris.IsColumnarAuditLogExportFormat(status.Format):
+			// Binary formats have no lines; the row count is known once finished.
+		case status.Format == doris.AuditLogExportFormatNDJSON:
+			status.RowsWritten = j.lines.Load()
+		default:
+			// TSV starts with a header line.
+			if lines := j.lines.Load(); lines > 0 {
+				status.RowsWritten = lines - 1
+			}
+		}
+	}
+	return status
+}
+
+func removeSpoolFile(path string) {
+	if path != "" {
+		_ = os.Remove(path)
+	}
+}
+
+// exportJobProgressWriter tracks bytes and line count. The text export formats
+// escape embedded newlines, so one line is one row (plus the TSV header).
+type exportJobProgressWriter struct {
+	w   io.Writer
+	job *exportJob
+}
+
+func (p *exportJobProgressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.job.bytes.Add(int64(n))
+	var lines int64
+	for _, c := range b[:n] {
+		if c == '\n' {
+			lines++
+		}
+	}
+	p.job.lines.Add(lines)
+	return n, err
+}
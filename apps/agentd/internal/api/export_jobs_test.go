@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
+)
+
+const exportJobCreatePath = "/api/v1/jobs/audit-export"
+
+type exportJobBody struct {
+	OK   bool            `json:"ok"`
+	Data exportJobStatus `json:"data"`
+}
+
+func newTestServerWithExportJobs(t *testing.T, exporter AuditLogExporter) http.Handler {
+	t.Helper()
+	return newServer(serverRunners{exporter: exporter}, 0, ServerConfig{ExportJobs: ExportJobConfig{
+		Concurrency: 1,
+		Timeout:     10 * time.Second,
+		TTL:         time.Minute,
+		Dir:         t.TempDir(),
+	}})
+}
+
+func decodeExportJob(t *testing.T, body io.Reader) exportJobStatus {
+	t.Helper()
+	var out exportJobBody
+	if err := json.NewDecoder(body).Decode(&out); err != nil {
+		t.Fatalf("decode job response failed: %v", err)
+	}
+	if !out.OK || out.Data.ID == "" {
+		t.Fatalf("unexpected job response: %+v", out)
+	}
+	return out.Data
+}
+
+// waitExportJobState polls until the job has finished in the wanted state.
+func waitExportJobState(t *testing.T, h http.Handler, id string, want string) exportJobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := serveLocalJSON(h, http.MethodGet, exportJobsPathPrefix+id, "")
+		assertStatus(t, w, http.StatusOK)
+		status := decodeExportJob(t, w.Body)
+		if status.FinishedAt != nil && status.State == want {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not reach %q, last state %q", id, want, status.State)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExportJobLifecycle(t *testing.T) {
+	t.Parallel()
+
+	h := newTestServerWithExportJobs(t, func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		_, _ = io.WriteString(w, "a\tb\n1\t2\n3\t4\n")
+		return doris.AuditLogExportResult{Rows: 2, HasMore: true, NextCursor: "next"}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, exportJobCreatePath, exportBody)
+	assertStatus(t, w, http.StatusAccepted)
+	created := decodeExportJob(t, w.Body)
+
+	status := waitExportJobState(t, h, created.ID, exportJobStateSucceeded)
+	if status.RowsWritten != 2 || status.BytesWritten != 12 || !status.HasMore || status.NextCursor != "next" {
+		t.Fatalf("unexpected job status: %+v", status)
+	}
+
+	w = serveLocalJSON(h, http.MethodGet, exportJobsPathPrefix+created.ID+"/result", "")
+	assertStatus(t, w, http.StatusOK)
+	if w.Body.String() != "a\tb\n1\t2\n3\t4\n" {
+		t.Fatalf("unexpected result body: %q", w.Body.String())
+	}
+	if got := w.Header().Get("X-Audit-Log-Next-Cursor"); got != "next" {
+		t.Fatalf("unexpected next cursor header: %q", got)
+	}
+
+	w = serveLocalJSON(h, http.MethodDelete, exportJobsPathPrefix+created.ID, "")
+	assertStatus(t, w, http.StatusOK)
+	w = serveLocalJSON(h, http.MethodGet, exportJobsPathPrefix+created.ID, "")
+	assertErrContains(t, w, http.StatusNotFound, "export job not found")
+}
+
+func TestExportJobCancelRemovesSpoolFile(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan string, 1)
+	h := newTestServerWithExportJobs(t, func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		_, _ = io.WriteString(w, "a\tb\n")
+		started <- w.(*exportJobProgressWriter).w.(*os.File).Name()
+		<-ctx.Done()
+		return doris.AuditLogExportResult{}, ctx.Err()
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, exportJobCreatePath, exportBody)
+	assertStatus(t, w, http.StatusAccepted)
+	created := decodeExportJob(t, w.Body)
+	spoolPath := <-started
+
+	w = serveLocalJSON(h, http.MethodGet, exportJobsPathPrefix+created.ID+"/result", "")
+	assertErrContains(t, w, http.StatusConflict, "result is not available")
+
+	w = serveLocalJSON(h, http.MethodDelete, exportJobsPathPrefix+created.ID, "")
+	assertStatus(t, w, http.StatusOK)
+	waitExportJobState(t, h, created.ID, exportJobStateCanceled)
+	if _, err := os.Stat(spoolPath); !os.IsNotExist(err) {
+		t.Fatalf("expected spool file to be removed, stat err=%v", err)
+	}
+}
+
+func TestExportJobCreateValidatesRequest(t *testing.T) {
+	t.Parallel()
+
+	h := newTestServerWithExportJobs(t, nil)
+	w := serveLocalJSON(h, http.MethodPost, exportJobCreatePath, `{"lookbackSeconds":1,"limit":1}`)
+	assertErrContains(t, w, http.StatusBadRequest, "connection")
+}
+
+func TestExportJobSubmitEvictsOldestFinishedJob(t *testing.T) {
+	t.Parallel()
+
+	m := newExportJobManager(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		return doris.AuditLogExportResult{}, nil
+	}, ExportJobConfig{Concurrency: 4, TTL: time.Minute, Dir: t.TempDir()})
+
+	var oldest string
+	for i := 0; i < exportJobMaxRetained; i++ {
+		status, err := m.submit(doris.ConnConfig{}, doris.AuditLogExportOptions{})
+		if err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+		if i == 0 {
+			oldest = status.ID
+		}
+		waitExportJobFinished(t, m, status.ID)
+	}
+
+	if _, err := m.submit(doris.ConnConfig{}, doris.AuditLogExportOptions{}); err != nil {
+		t.Fatalf("finished jobs must not block new submits: %v", err)
+	}
+	if _, err := m.get(oldest); !errors.Is(err, errExportJobNotFound) {
+		t.Fatalf("expected the oldest finished job to be evicted, got %v", err)
+	}
+}
+
+func TestExportJobSubmitRejectsWhenAllJobsActive(t *testing.T) {
+	t.Parallel()
+
+	m := newExportJobManager(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		<-ctx.Done()
+		return doris.AuditLogExportResult{}, ctx.Err()
+	}, ExportJobConfig{Concurrency: 1, TTL: time.Minute, Dir: t.TempDir()})
+
+	for i := 0; i < exportJobMaxRetained; i++ {
+		status, err := m.submit(doris.ConnConfig{}, doris.AuditLogExportOptions{})
+		if err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+		t.Cleanup(func() { _, _ = m.cancel(status.ID) })
+	}
+	if _, err := m.submit(doris.ConnConfig{}, doris.AuditLogExportOptions{}); !errors.Is(err, errExportJobTooMany) {
+		t.Fatalf("expected errExportJobTooMany, got %v", err)
+	}
+}
+
+func waitExportJobFinished(t *testing.T, m *exportJobManager, id string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := m.get(id)
+		if err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		if status.FinishedAt != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish", id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
+)
+
+const schemaAuditWaiverMaxRetained = 1000
+
+var (
+	errSchemaAuditWaiverNotFound = errors.New("schema audit waiver not found")
+	errSchemaAuditWaiverTooMany  = errors.New("too many schema audit waivers; delete expired ones first")
+)
+
+// schemaAuditWaiverStore keeps waivers in memory and, when path is set,
+// mirrors them to a JSON file so they survive restarts.
+type schemaAuditWaiverStore struct {
+	path string
+
+	mu      sync.Mutex
+	waivers map[string]doris.SchemaAuditWaiver
+}
+
+func newSchemaAuditWaiverStore(path string, initial []doris.SchemaAuditWaiver) *schemaAuditWaiverStore {
+	store := &schemaAuditWaiverStore{
+		path:    path,
+		waivers: make(map[string]doris.SchemaAuditWaiver, len(initial)),
+	}
+	for _, waiver := range initial {
+		store.waivers[waiver.ID] = waiver
+	}
+	return store
+}
+
+// LoadSchemaAuditWaivers reads waivers saved by agentd. A missing file
+// yields no waivers; an invalid waiver fails the whole file.
+func LoadSchemaAuditWaivers(path string) ([]doris.SchemaAuditWaiver, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var waivers []doris.SchemaAuditWaiver
+	if err := json.Unmarshal(data, &waivers); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(waivers))
+	for i := range waivers {
+		if waivers[i].ID == "" {
+			return nil, errors.New("schema audit waiver id is required")
+		}
+		if _, dup := seen[waivers[i].ID]; dup {
+			return nil, errors.New("duplicate schema audit waiver id: " + waivers[i].ID)
+		}
+		seen[waivers[i].ID] = struct{}{}
+		waivers[i] = waivers[i].Normalize()
+		if err := waivers[i].Validate(); err != nil {
+			return nil, errors.New("schema audit waiver " + waivers[i].ID + ": " + err.Error())
+		}
+	}
+	return waivers, nil
+}
+
+func (s *schemaAuditWaiverStore) list() []doris.SchemaAuditWaiver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listLocked()
+}
+
+func (s *schemaAuditWaiverStore) listLocked() []doris.SchemaAuditWaiver {
+	out := make([]doris.SchemaAuditWaiver, 0, len(s.waivers))
+	for _, waiver := range s.waivers {
+		out = append(out, waiver)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (s *schemaAuditWaiverStore) add(waiver doris.SchemaAuditWaiver) (doris.SchemaAuditWaiver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waivers) >= schemaAuditWaiverMaxRetained {
+		return doris.SchemaAuditWaiver{}, errSchemaAuditWaiverTooMany
+	}
+	waiver.ID = generateTraceID()
+	waiver.CreatedAt = time.Now().UTC()
+	s.waivers[waiver.ID] = waiver
+	if err := s.saveLocked(); err != nil {
+		delete(s.waivers, waiver.ID)
+		return doris.SchemaAuditWaiver{}, err
+	}
+	return waiver, nil
+}
+
+func (s *schemaAuditWaiverStore) remove(id string) (doris.SchemaAuditWaiver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	waiver, ok := s.waivers[id]
+	if !ok {
+		return doris.SchemaAuditWaiver{}, errSchemaAuditWaiverNotFound
+	}
+	delete(s.waivers, id)
+	if err := s.saveLocked(); err != nil {
+		s.waivers[id] = waiver
+		return doris.SchemaAuditWaiver{}, err
+	}
+	return waiver, nil
+}
+
+// saveLocked writes the store through a temp file so a crash never leaves a
+// truncated file behind.
+func (s *schemaAuditWaiverStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.listLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".schema-audit-waivers-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
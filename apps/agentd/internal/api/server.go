@@ -16,15 +16,48 @@ import (
 type AuditLogExporter func(
 	ctx context.Context,
 	cfg doris.ConnConfig,
-	lookbackSeconds int,
-	limit int,
+	opts doris.AuditLogExportOptions,
 	w io.Writer,
-) error
+) (doris.AuditLogExportResult, error)
 
-type ExplainRunner func(ctx context.Context, cfg doris.ConnConfig, sql string, mode string) (string, error)
+type AuditLogTopTemplatesRunner func(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	opts doris.AuditLogTopTemplatesOptions,
+) (doris.AuditLogTopTemplatesResult, error)
+
+type QueryProfileRunner func(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	opts doris.QueryProfileOptions,
+) (doris.QueryProfileResult, error)
+
+type ExplainRunner func(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	sql string,
+	mode string,
+	sessionVariables map[string]string,
+) (string, error)
+
+type ExplainBatchRunner func(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	items []doris.ExplainBatchItem,
+	opts doris.ExplainBatchOptions,
+) (doris.ExplainBatchResult, error)
 
 type ListDatabasesRunner func(ctx context.Context, cfg doris.ConnConfig) ([]string, error)
 
+type ListCatalogsRunner func(ctx context.Context, cfg doris.ConnConfig) ([]doris.Catalog, error)
+
+// TableStatsRunner returns one entry per ref, in ref order.
+type TableStatsRunner func(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	refs []doris.TableStatsRef,
+) (doris.TableStatsResult, error)
+
 type TestConnectionRunner func(ctx context.Context, cfg doris.ConnConfig) error
 
 type SchemaAuditScanRunner func(
@@ -36,8 +69,7 @@ type SchemaAuditScanRunner func(
 type SchemaAuditTableDetailRunner func(
 	ctx context.Context,
 	cfg doris.ConnConfig,
-	database string,
-	table string,
+	opts doris.SchemaAuditTableDetailOptions,
 ) (doris.SchemaAuditTableDetailResult, error)
 
 type countingWriter struct {
@@ -57,6 +89,7 @@ type dorisConnection struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	Database string `json:"database,omitempty"`
+	Catalog  string `json:"catalog,omitempty"`
 }
 
 func parseConnConfig(c *dorisConnection) (doris.ConnConfig, error) {
@@ -77,17 +110,13 @@ func parseConnConfig(c *dorisConnection) (doris.ConnConfig, error) {
 	if c.Password == "" {
 		return doris.ConnConfig{}, errors.New("connection.password is required")
 	}
-	database := strings.TrimSpace(c.Database)
-	if database != "" {
-		if strings.HasPrefix(database, "`") && strings.HasSuffix(database, "`") && len(database) >= 2 {
-			database = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(database, "`"), "`"))
-		}
-		if database == "" {
-			return doris.ConnConfig{}, errors.New("connection.database is invalid")
-		}
-		if strings.ContainsAny(database, "`;\r\n\t ") {
-			return doris.ConnConfig{}, errors.New("connection.database must be a database name (no quotes or semicolons)")
-		}
+	database, err := parseConnIdentifier(c.Database, "database")
+	if err != nil {
+		return doris.ConnConfig{}, err
+	}
+	catalog, err := parseConnIdentifier(c.Catalog, "catalog")
+	if err != nil {
+		return doris.ConnConfig{}, err
 	}
 	return doris.ConnConfig{
 		Host:     host,
@@ -95,9 +124,29 @@ func parseConnConfig(c *dorisConnection) (doris.ConnConfig, error) {
 		User:     user,
 		Password: c.Password,
 		Database: database,
+		Catalog:  catalog,
 	}, nil
 }
 
+// parseConnIdentifier trims an optional database or catalog name and strips
+// one level of backticks.
+func parseConnIdentifier(value string, field string) (string, error) {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(name, "`") && strings.HasSuffix(name, "`") && len(name) >= 2 {
+		name = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(name, "`"), "`"))
+	}
+	if name == "" {
+		return "", errors.New("connection." + field + " is invalid")
+	}
+	if strings.ContainsAny(name, "`;\r\n\t ") {
+		return "", errors.New("connection." + field + " must be a " + field + " name (no quotes or semicolons)")
+	}
+	return name, nil
+}
+
 func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
 	if r.Method != method {
 		writeErrorWithRequest(w, r, http.StatusMethodNotAllowed, "method not allowed")
@@ -133,25 +182,26 @@ func applyReadWriteTimeout(cfg *doris.ConnConfig, timeout time.Duration) {
 }
 
 func normalizeExplainMode(mode string) (string, error) {
-	normalized := strings.ToLower(strings.TrimSpace(mode))
-	switch normalized {
-	case "", "tree":
-		return "tree", nil
-	case "plan":
-		return "plan", nil
-	default:
-		return "", errors.New("unsupported explain mode: " + normalized)
-	}
+	return doris.NormalizeExplainMode(mode)
 }
 
 type Server struct {
 	exportAuditLog         AuditLogExporter
+	auditLogTopTemplates   AuditLogTopTemplatesRunner
+	queryProfile           QueryProfileRunner
 	testConnection         TestConnectionRunner
 	explain                ExplainRunner
+	explainBatch           ExplainBatchRunner
 	listDatabases          ListDatabasesRunner
+	listCatalogs           ListCatalogsRunner
+	tableStats             TableStatsRunner
 	schemaAuditScan        SchemaAuditScanRunner
 	schemaAuditTableDetail SchemaAuditTableDetailRunner
 	exportTimeout          time.Duration
+	exportJobs             *exportJobManager
+	auditLogDirs           []string
+	schemaAuditProfiles    map[string]doris.SchemaAuditRuleConfig
+	schemaAuditWaivers     *schemaAuditWaiverStore
 }
 
 func NewServer(
@@ -159,71 +209,150 @@ func NewServer(
 	exportTimeout time.Duration,
 	testConnection ...TestConnectionRunner,
 ) http.Handler {
-	tc := doris.TestConnection
-	if len(testConnection) > 0 && testConnection[0] != nil {
-		tc = testConnection[0]
+	runners := serverRunners{exporter: exporter}
+	if len(testConnection) > 0 {
+		runners.testConnection = testConnection[0]
 	}
-	return newServer(exporter, exportTimeout, tc, nil, nil, nil, nil)
+	return newServer(runners, exportTimeout, ServerConfig{})
 }
 
-func newServer(
+// ServerConfig holds agent-wide settings that do not come with a request.
+type ServerConfig struct {
+	ExportJobs ExportJobConfig
+	// AuditLogDirs lists directories whose fe.audit.log files may be read. Empty disables file mode.
+	AuditLogDirs []string
+	// SchemaAuditProfiles adds or replaces named schema audit rule profiles
+	// on top of the built-in ones.
+	SchemaAuditProfiles map[string]doris.SchemaAuditRuleConfig
+	// SchemaAuditWaiversFile persists schema audit waivers. Empty keeps them
+	// in memory only.
+	SchemaAuditWaiversFile string
+	// SchemaAuditWaivers seeds the waiver store, usually from
+	// LoadSchemaAuditWaivers(SchemaAuditWaiversFile).
+	SchemaAuditWaivers []doris.SchemaAuditWaiver
+}
+
+// NewServerWithConfig is NewServer with explicit agent-wide settings.
+func NewServerWithConfig(
 	exporter AuditLogExporter,
 	exportTimeout time.Duration,
-	testConnection TestConnectionRunner,
-	explain ExplainRunner,
-	listDatabases ListDatabasesRunner,
-	schemaAuditScan SchemaAuditScanRunner,
-	schemaAuditTableDetail SchemaAuditTableDetailRunner,
+	config ServerConfig,
 ) http.Handler {
-	if exporter == nil {
-		exporter = doris.StreamAuditLogOutfileTSVLookback
+	return newServer(serverRunners{exporter: exporter}, exportTimeout, config)
+}
+
+// serverRunners holds the functions a Server uses to talk to Doris. Nil
+// fields fall back to the doris package implementations.
+type serverRunners struct {
+	exporter               AuditLogExporter
+	auditLogTopTemplates   AuditLogTopTemplatesRunner
+	queryProfile           QueryProfileRunner
+	testConnection         TestConnectionRunner
+	explain                ExplainRunner
+	explainBatch           ExplainBatchRunner
+	listDatabases          ListDatabasesRunner
+	listCatalogs           ListCatalogsRunner
+	tableStats             TableStatsRunner
+	schemaAuditScan        SchemaAuditScanRunner
+	schemaAuditTableDetail SchemaAuditTableDetailRunner
+}
+
+func (r serverRunners) withDefaults() serverRunners {
+	if r.exporter == nil {
+		r.exporter = doris.StreamAuditLogExport
 	}
-	if exportTimeout <= 0 {
-		exportTimeout = 60 * time.Second
+	if r.auditLogTopTemplates == nil {
+		r.auditLogTopTemplates = doris.BuildAuditLogTopTemplates
+	}
+	if r.queryProfile == nil {
+		r.queryProfile = doris.FetchQueryProfile
 	}
-	if testConnection == nil {
-		testConnection = doris.TestConnection
+	if r.testConnection == nil {
+		r.testConnection = doris.TestConnection
 	}
-	if explain == nil {
-		explain = func(ctx context.Context, cfg doris.ConnConfig, sqlText string, mode string) (string, error) {
+	if r.explain == nil {
+		r.explain = func(
+			ctx context.Context,
+			cfg doris.ConnConfig,
+			sqlText string,
+			mode string,
+			sessionVariables map[string]string,
+		) (string, error) {
 			normalizedMode, err := normalizeExplainMode(mode)
 			if err != nil {
 				return "", err
 			}
-			if normalizedMode == "plan" {
-				return doris.ExplainPlan(ctx, cfg, sqlText)
-			}
-			return doris.ExplainTree(ctx, cfg, sqlText)
+			return doris.Explain(ctx, cfg, sqlText, normalizedMode, sessionVariables)
 		}
 	}
-	if listDatabases == nil {
-		listDatabases = doris.ListDatabases
+	if r.explainBatch == nil {
+		r.explainBatch = doris.ExplainBatch
+	}
+	if r.listDatabases == nil {
+		r.listDatabases = doris.ListDatabases
+	}
+	if r.listCatalogs == nil {
+		r.listCatalogs = doris.ListCatalogs
 	}
-	if schemaAuditScan == nil {
-		schemaAuditScan = doris.BuildSchemaAuditScan
+	if r.tableStats == nil {
+		r.tableStats = doris.FetchTableStats
 	}
-	if schemaAuditTableDetail == nil {
-		schemaAuditTableDetail = doris.BuildSchemaAuditTableDetail
+	if r.schemaAuditScan == nil {
+		r.schemaAuditScan = doris.BuildSchemaAuditScan
+	}
+	if r.schemaAuditTableDetail == nil {
+		r.schemaAuditTableDetail = doris.BuildSchemaAuditTableDetail
+	}
+	return r
+}
+
+func newServer(runners serverRunners, exportTimeout time.Duration, config ServerConfig) http.Handler {
+	runners = runners.withDefaults()
+	if exportTimeout <= 0 {
+		exportTimeout = 60 * time.Second
 	}
 
 	server := &Server{
-		exportAuditLog:         exporter,
-		testConnection:         testConnection,
-		explain:                explain,
-		listDatabases:          listDatabases,
-		schemaAuditScan:        schemaAuditScan,
-		schemaAuditTableDetail: schemaAuditTableDetail,
+		exportAuditLog:         runners.exporter,
+		auditLogTopTemplates:   runners.auditLogTopTemplates,
+		queryProfile:           runners.queryProfile,
+		testConnection:         runners.testConnection,
+		explain:                runners.explain,
+		explainBatch:           runners.explainBatch,
+		listDatabases:          runners.listDatabases,
+		listCatalogs:           runners.listCatalogs,
+		tableStats:             runners.tableStats,
+		schemaAuditScan:        runners.schemaAuditScan,
+		schemaAuditTableDetail: runners.schemaAuditTableDetail,
 		exportTimeout:          exportTimeout,
+		exportJobs:             newExportJobManager(runners.exporter, config.ExportJobs),
+		auditLogDirs:           cleanAuditLogDirs(config.AuditLogDirs),
+		schemaAuditProfiles:    mergeSchemaAuditProfiles(config.SchemaAuditProfiles),
+		schemaAuditWaivers:     newSchemaAuditWaiverStore(config.SchemaAuditWaiversFile, config.SchemaAuditWaivers),
 	}
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v1/health", server.handleHealth)
 	mux.HandleFunc("/api/v1/doris/connection/test", server.handleDorisConnectionTest)
 	mux.HandleFunc("/api/v1/doris/databases", server.handleDorisDatabases)
+	mux.HandleFunc("/api/v1/doris/catalogs", server.handleDorisCatalogs)
 	mux.HandleFunc("/api/v1/doris/audit-log/export", server.handleDorisAuditLogExport)
+	mux.HandleFunc("/api/v1/doris/audit-log/top-templates", server.handleDorisAuditLogTopTemplates)
+	mux.HandleFunc("/api/v1/audit-log/files/export", server.handleAuditLogFileExport)
 	mux.HandleFunc("/api/v1/doris/explain", server.handleDorisExplain)
 	mux.HandleFunc("/api/v1/doris/explain/tree", server.handleDorisExplain)
+	mux.HandleFunc("/api/v1/doris/explain/diff", server.handleDorisExplainDiff)
+	mux.HandleFunc("/api/v1/doris/explain/batch", server.handleDorisExplainBatch)
+	mux.HandleFunc("/api/v1/doris/query-profile", server.handleDorisQueryProfile)
+	mux.HandleFunc("/api/v1/doris/table-stats", server.handleDorisTableStats)
 	mux.HandleFunc("/api/v1/doris/schema-audit/scan", server.handleDorisSchemaAuditScan)
+	mux.HandleFunc("/api/v1/doris/schema-audit/scan/stream", server.handleDorisSchemaAuditScanStream)
 	mux.HandleFunc("/api/v1/doris/schema-audit/table-detail", server.handleDorisSchemaAuditTableDetail)
+	mux.HandleFunc("/api/v1/doris/schema-audit/profiles", server.handleDorisSchemaAuditProfiles)
+	mux.HandleFunc("/api/v1/doris/schema-audit/rules", server.handleDorisSchemaAuditRules)
+	mux.HandleFunc("/api/v1/doris/schema-audit/waivers", server.handleDorisSchemaAuditWaivers)
+	mux.HandleFunc(schemaAuditWaiversPathPrefix, server.handleDorisSchemaAuditWaiver)
+	mux.HandleFunc("/api/v1/jobs/audit-export", server.handleAuditExportJobCreate)
+	mux.HandleFunc(exportJobsPathPrefix, server.handleExportJob)
 	return withLocalOnly(withCORS(mux))
 }
 
@@ -267,10 +396,11 @@ func withCORS(next http.Handler) http.Handler {
 		if origin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(auditExportExposedHeaders, ", "))
 		}
 		if r.Method == http.MethodOptions {
 			if origin != "" {
-				w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
+				w.Header().Set("Access-Control-Allow-Methods", "GET,POST,DELETE,OPTIONS")
 				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			}
 			w.WriteHeader(http.StatusNoContent)
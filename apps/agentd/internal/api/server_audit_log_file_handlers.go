@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
+)
+
+var errAuditLogFileModeDisabled = errors.New("fe.audit.log file mode is disabled; start agentd with --audit-log-dir")
+
+type auditLogFileExportRequest struct {
+	Dir             string   `json:"dir,omitempty"`
+	LookbackSeconds int      `json:"lookbackSeconds"`
+	From            string   `json:"from,omitempty"`
+	To              string   `json:"to,omitempty"`
+	Limit           int      `json:"limit"`
+	Format          string   `json:"format,omitempty"`
+	Columns         []string `json:"columns,omitempty"`
+	Redact          string   `json:"redact,omitempty"`
+	RedactSalt      string   `json:"redactSalt,omitempty"`
+}
+
+func cleanAuditLogDirs(dirs []string) []string {
+	out := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if resolved, err := resolveLocalDir(dir); err == nil {
+			out = append(out, resolved)
+		}
+	}
+	return out
+}
+
+func resolveLocalDir(dir string) (string, error) {
+	abs, err := filepath.Abs(strings.TrimSpace(dir))
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", errors.New("not a directory")
+	}
+	return resolved, nil
+}
+
+// resolveAuditLogDir confines requests to the directories configured at startup,
+// so a local web page cannot read arbitrary files through the agent.
+func (s *Server) resolveAuditLogDir(requested string) (string, error) {
+	if len(s.auditLogDirs) == 0 {
+		return "", errAuditLogFileModeDisabled
+	}
+	if strings.TrimSpace(requested) == "" {
+		if len(s.auditLogDirs) == 1 {
+			return s.auditLogDirs[0], nil
+		}
+		return "", errors.New("dir is required when more than one --audit-log-dir is configured")
+	}
+	dir, err := resolveLocalDir(requested)
+	if err != nil {
+		return "", errors.New("dir is invalid: " + err.Error())
+	}
+	for _, root := range s.auditLogDirs {
+		rel, err := filepath.Rel(root, dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return dir, nil
+		}
+	}
+	return "", errors.New("dir is not under a configured --audit-log-dir")
+}
+
+func (s *Server) handleAuditLogFileExport(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req auditLogFileExportRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	dir, err := s.resolveAuditLogDir(req.Dir)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errAuditLogFileModeDisabled) {
+			status = http.StatusForbidden
+		}
+		writeErrorWithRequest(w, r, status, err.Error())
+		return
+	}
+	if req.Limit <= 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "limit must be positive")
+		return
+	}
+	format, err := doris.NormalizeAuditLogExportFormat(req.Format)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	redact, err := doris.NormalizeSQLRedactionMode(req.Redact)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts := doris.AuditLogFileOptions{
+		Dir:             dir,
+		LookbackSeconds: req.LookbackSeconds,
+		From:            strings.TrimSpace(req.From),
+		To:              strings.TrimSpace(req.To),
+		Limit:           req.Limit,
+		Format:          format,
+		Columns:         req.Columns,
+		Redact:          redact,
+		RedactSalt:      req.RedactSalt,
+	}
+	s.streamAuditExport(w, r, format, func(
+		ctx context.Context,
+		out io.Writer,
+		onSchema func(doris.AuditLogSchema),
+	) (doris.AuditLogExportResult, error) {
+		opts.OnSchema = onSchema
+		return doris.StreamAuditLogFiles(ctx, opts, out)
+	})
+}
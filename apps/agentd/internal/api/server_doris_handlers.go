@@ -2,9 +2,15 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
 )
 
 type connectionRequest struct {
@@ -12,15 +18,157 @@ type connectionRequest struct {
 }
 
 type auditExportRequest struct {
-	Connection      *dorisConnection `json:"connection"`
-	LookbackSeconds int              `json:"lookbackSeconds"`
-	Limit           int              `json:"limit"`
+	Connection      *dorisConnection    `json:"connection"`
+	LookbackSeconds int                 `json:"lookbackSeconds"`
+	Limit           int                 `json:"limit"`
+	From            string              `json:"from,omitempty"`
+	To              string              `json:"to,omitempty"`
+	Cursor          string              `json:"cursor,omitempty"`
+	Filters         *auditExportFilters `json:"filters,omitempty"`
+	Format          string              `json:"format,omitempty"`
+	Columns         []string            `json:"columns,omitempty"`
+	Normalize       bool                `json:"normalize,omitempty"`
+	Redact          string              `json:"redact,omitempty"`
+	RedactSalt      string              `json:"redactSalt,omitempty"`
+}
+
+type auditExportFilters struct {
+	User           string `json:"user,omitempty"`
+	ClientIP       string `json:"clientIp,omitempty"`
+	Database       string `json:"database,omitempty"`
+	Catalog        string `json:"catalog,omitempty"`
+	State          string `json:"state,omitempty"`
+	StmtType       string `json:"stmtType,omitempty"`
+	MinQueryTimeMs int64  `json:"minQueryTimeMs,omitempty"`
+	MinScanBytes   int64  `json:"minScanBytes,omitempty"`
+	MinScanRows    int64  `json:"minScanRows,omitempty"`
+	SQLContains    string `json:"sqlContains,omitempty"`
+	SQLRegex       string `json:"sqlRegex,omitempty"`
+}
+
+func (f *auditExportFilters) toDoris() doris.AuditLogExportFilters {
+	if f == nil {
+		return doris.AuditLogExportFilters{}
+	}
+	return doris.AuditLogExportFilters{
+		User:           strings.TrimSpace(f.User),
+		ClientIP:       strings.TrimSpace(f.ClientIP),
+		Database:       strings.TrimSpace(f.Database),
+		Catalog:        strings.TrimSpace(f.Catalog),
+		State:          strings.TrimSpace(f.State),
+		StmtType:       strings.TrimSpace(f.StmtType),
+		MinQueryTimeMs: f.MinQueryTimeMs,
+		MinScanBytes:   f.MinScanBytes,
+		MinScanRows:    f.MinScanRows,
+		SQLContains:    f.SQLContains,
+		SQLRegex:       f.SQLRegex,
+	}
+}
+
+const (
+	auditExportRowCountHeader   = "X-Audit-Log-Row-Count"
+	auditExportHasMoreHeader    = "X-Audit-Log-Has-More"
+	auditExportNextCursorHeader = "X-Audit-Log-Next-Cursor"
+	auditExportSchemaHeader     = "X-Audit-Log-Schema"
+)
+
+// auditExportExposedHeaders lists the export headers browsers may read across origins.
+var auditExportExposedHeaders = []string{
+	auditExportRowCountHeader,
+	auditExportHasMoreHeader,
+	auditExportNextCursorHeader,
+	auditExportSchemaHeader,
+}
+
+func (req auditExportRequest) rangeMode() bool {
+	return strings.TrimSpace(req.From) != "" ||
+		strings.TrimSpace(req.To) != "" ||
+		strings.TrimSpace(req.Cursor) != ""
+}
+
+type auditTopTemplatesRequest struct {
+	Connection      *dorisConnection    `json:"connection"`
+	LookbackSeconds int                 `json:"lookbackSeconds"`
+	From            string              `json:"from,omitempty"`
+	To              string              `json:"to,omitempty"`
+	Filters         *auditExportFilters `json:"filters,omitempty"`
+	TopN            int                 `json:"topN"`
+	OrderBy         string              `json:"orderBy,omitempty"`
+	MaxRows         int                 `json:"maxRows"`
 }
 
 type explainRequest struct {
+	Connection       *dorisConnection  `json:"connection"`
+	SQL              string            `json:"sql"`
+	Mode             string            `json:"mode"`
+	SessionVariables map[string]string `json:"sessionVariables,omitempty"`
+	// IncludeStats attaches table and column statistics for scanned tables.
+	IncludeStats bool `json:"includeStats,omitempty"`
+}
+
+// tableStatsRequest takes explicit tables, raw EXPLAIN output whose scan
+// nodes name the tables, or both.
+type tableStatsRequest struct {
+	Connection  *dorisConnection      `json:"connection"`
+	Tables      []doris.TableStatsRef `json:"tables,omitempty"`
+	ExplainText string                `json:"explainText,omitempty"`
+}
+
+type queryProfileRequest struct {
 	Connection *dorisConnection `json:"connection"`
-	SQL        string           `json:"sql"`
+	QueryID    string           `json:"queryId"`
+	HTTPPort   int              `json:"httpPort,omitempty"`
+}
+
+type explainBatchItem struct {
+	SQL      string `json:"sql"`
+	Database string `json:"database,omitempty"`
+}
+
+type explainBatchRequest struct {
+	Connection       *dorisConnection   `json:"connection"`
+	Mode             string             `json:"mode"`
+	SessionVariables map[string]string  `json:"sessionVariables,omitempty"`
+	Concurrency      int                `json:"concurrency,omitempty"`
+	Items            []explainBatchItem `json:"items"`
+}
+
+type explainDiffSide struct {
+	SQL              string            `json:"sql,omitempty"`
+	SessionVariables map[string]string `json:"sessionVariables,omitempty"`
+}
+
+// explainDiffRequest compares two SQL texts, or one shared sql explained
+// under two sets of session variables.
+type explainDiffRequest struct {
+	Connection *dorisConnection `json:"connection"`
+	SQL        string           `json:"sql,omitempty"`
 	Mode       string           `json:"mode"`
+	Left       explainDiffSide  `json:"left"`
+	Right      explainDiffSide  `json:"right"`
+}
+
+func auditExportContentType(format string) (contentType string, filename string) {
+	switch format {
+	case doris.AuditLogExportFormatNDJSON:
+		return "application/x-ndjson; charset=utf-8", "audit_log.ndjson"
+	case doris.AuditLogExportFormatArrow:
+		return "application/vnd.apache.arrow.stream", "audit_log.arrows"
+	case doris.AuditLogExportFormatParquet:
+		return "application/vnd.apache.parquet", "audit_log.parquet"
+	default:
+		return "text/tab-separated-values; charset=utf-8", "audit_log.tsv"
+	}
+}
+
+// setAuditExportSchemaHeader describes the exported columns so importers can
+// handle differences between Doris versions without sniffing the header row.
+func setAuditExportSchemaHeader(h http.Header, schema doris.AuditLogSchema) {
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return
+	}
+	h.Set(auditExportSchemaHeader, string(encoded))
 }
 
 func (s *Server) handleDorisConnectionTest(w http.ResponseWriter, r *http.Request) {
@@ -74,11 +222,11 @@ func (s *Server) handleDorisDatabases(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleDorisAuditLogExport(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleDorisCatalogs(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodPost) {
 		return
 	}
-	var req auditExportRequest
+	var req connectionRequest
 	if !readJSONOrWriteError(w, r, &req) {
 		return
 	}
@@ -86,33 +234,276 @@ func (s *Server) handleDorisAuditLogExport(w http.ResponseWriter, r *http.Reques
 	if !ok {
 		return
 	}
-	if req.LookbackSeconds <= 0 {
-		writeErrorWithRequest(w, r, http.StatusBadRequest, "lookbackSeconds must be positive")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, 20*time.Second)
+	catalogs, err := s.listCatalogs(ctx, cfg)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	writeData(w, r, http.StatusOK, map[string]any{
+		"catalogs": catalogs,
+	})
+}
+
+// parseAuditExportRequestOrWriteError decodes and validates an export request
+// shared by the streaming export and the export job endpoints.
+func parseAuditExportRequestOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+) (doris.ConnConfig, doris.AuditLogExportOptions, bool) {
+	var req auditExportRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
+	if req.rangeMode() {
+		if req.LookbackSeconds != 0 {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "lookbackSeconds cannot be combined with from/to/cursor")
+			return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+		}
+	} else if req.LookbackSeconds <= 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "lookbackSeconds must be positive")
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
 	if req.Limit <= 0 {
 		writeErrorWithRequest(w, r, http.StatusBadRequest, "limit must be positive")
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
+	format, err := doris.NormalizeAuditLogExportFormat(req.Format)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
+	redact, err := doris.NormalizeSQLRedactionMode(req.Redact)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return doris.ConnConfig{}, doris.AuditLogExportOptions{}, false
+	}
+	return cfg, doris.AuditLogExportOptions{
+		LookbackSeconds: req.LookbackSeconds,
+		Limit:           req.Limit,
+		From:            strings.TrimSpace(req.From),
+		To:              strings.TrimSpace(req.To),
+		Cursor:          strings.TrimSpace(req.Cursor),
+		Filters:         req.Filters.toDoris(),
+		Format:          format,
+		Columns:         req.Columns,
+		Normalize:       req.Normalize,
+		Redact:          redact,
+		RedactSalt:      req.RedactSalt,
+	}, true
+}
+
+func (s *Server) handleDorisAuditLogExport(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	cfg, opts, ok := parseAuditExportRequestOrWriteError(w, r)
+	if !ok {
 		return
 	}
 
+	applyReadWriteTimeout(&cfg, s.exportTimeout+10*time.Second)
+	export := func(
+		ctx context.Context,
+		out io.Writer,
+		onSchema func(doris.AuditLogSchema),
+	) (doris.AuditLogExportResult, error) {
+		opts.OnSchema = onSchema
+		return s.exportAuditLog(ctx, cfg, opts, out)
+	}
+	// Range mode pages through the audit log; the page is spooled so the
+	// continuation cursor can go out as a header rather than a trailer.
+	if opts.LookbackSeconds == 0 {
+		s.spoolAuditExport(w, r, opts.Format, export)
+		return
+	}
+	s.streamAuditExport(w, r, opts.Format, export)
+}
+
+type auditExportFunc func(
+	ctx context.Context,
+	out io.Writer,
+	onSchema func(doris.AuditLogSchema),
+) (doris.AuditLogExportResult, error)
+
+// auditExportPage describes a finished export whose body is sent from a spool file.
+type auditExportPage struct {
+	Format     string
+	Rows       int64
+	Bytes      int64
+	HasMore    bool
+	NextCursor string
+	Schema     *doris.AuditLogSchema
+}
+
+// spoolAuditExport runs export into a temporary file and then sends it with the
+// row count, has-more flag and continuation cursor as response headers.
+func (s *Server) spoolAuditExport(w http.ResponseWriter, r *http.Request, format string, export auditExportFunc) {
 	ctx, cancel := context.WithTimeout(r.Context(), s.exportTimeout)
 	defer cancel()
 
-	w.Header().Set("Content-Type", "text/tab-separated-values; charset=utf-8")
-	w.Header().Set("Content-Disposition", `attachment; filename="audit_log.tsv"`)
+	f, err := os.CreateTemp(s.exportJobs.spoolDir(), "agentd-audit-export-*")
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusInternalServerError, "create spool file: "+err.Error())
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var schema *doris.AuditLogSchema
+	cw := &countingWriter{w: f}
+	result, err := export(ctx, cw, func(detected doris.AuditLogSchema) {
+		schema = &detected
+	})
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		writeErrorWithRequest(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeAuditExportPage(w, r, f, auditExportPage{
+		Format:     format,
+		Rows:       int64(result.Rows),
+		Bytes:      cw.n,
+		HasMore:    result.HasMore,
+		NextCursor: result.NextCursor,
+		Schema:     schema,
+	})
+}
+
+// writeAuditExportPage sends a finished export as an attachment. Errors while
+// copying abort the response so a truncated file is never mistaken for a page.
+func writeAuditExportPage(w http.ResponseWriter, r *http.Request, body io.Reader, page auditExportPage) {
+	contentType, filename := auditExportContentType(page.Format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
-	cw := &countingWriter{w: w}
-	applyReadWriteTimeout(&cfg, s.exportTimeout+10*time.Second)
-	if err := s.exportAuditLog(ctx, cfg, req.LookbackSeconds, req.Limit, cw); err != nil {
+	w.Header().Set(auditExportRowCountHeader, strconv.FormatInt(page.Rows, 10))
+	w.Header().Set(auditExportHasMoreHeader, strconv.FormatBool(page.HasMore))
+	w.Header().Set(auditExportNextCursorHeader, page.NextCursor)
+	if page.Schema != nil {
+		setAuditExportSchemaHeader(w.Header(), *page.Schema)
+	}
+	encoding := negotiateContentEncoding(r.Header.Get("Accept-Encoding"))
+	w.Header().Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	} else {
+		w.Header().Set("Content-Length", strconv.FormatInt(page.Bytes, 10))
+	}
+	ew := newEncodedWriter(w, encoding)
+	if _, err := io.Copy(ew, body); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if err := ew.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// streamAuditExport writes an export produced by export as an attachment in the given format.
+// Errors before the first byte become JSON errors; later errors abort the response.
+func (s *Server) streamAuditExport(w http.ResponseWriter, r *http.Request, format string, export auditExportFunc) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.exportTimeout)
+	defer cancel()
+
+	contentType, filename := auditExportContentType(format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Lookback exports are not paged; the row count is only known once the
+	// last row is written.
+	w.Header().Set("Trailer", strings.Join([]string{
+		auditExportRowCountHeader,
+		auditExportHasMoreHeader,
+	}, ", "))
+	encoding := negotiateContentEncoding(r.Header.Get("Accept-Encoding"))
+	w.Header().Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	ew := newEncodedWriter(w, encoding)
+	cw := &countingWriter{w: ew}
+	result, err := export(ctx, cw, func(schema doris.AuditLogSchema) {
+		setAuditExportSchemaHeader(w.Header(), schema)
+	})
+	if err != nil {
 		if cw.n == 0 {
 			w.Header().Del("Content-Disposition")
+			w.Header().Del("Trailer")
+			w.Header().Del("Content-Encoding")
+			w.Header().Del(auditExportSchemaHeader)
 			writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 		// Avoid silently importing a truncated TSV.
 		panic(http.ErrAbortHandler)
 	}
+	if err := ew.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set(auditExportRowCountHeader, strconv.Itoa(result.Rows))
+	w.Header().Set(auditExportHasMoreHeader, strconv.FormatBool(result.HasMore))
+}
+
+func (s *Server) handleDorisAuditLogTopTemplates(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req auditTopTemplatesRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return
+	}
+	rangeMode := strings.TrimSpace(req.From) != "" || strings.TrimSpace(req.To) != ""
+	if rangeMode && req.LookbackSeconds != 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "lookbackSeconds cannot be combined with from/to")
+		return
+	}
+	if !rangeMode && req.LookbackSeconds <= 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "lookbackSeconds must be positive")
+		return
+	}
+	if req.TopN < 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "topN must not be negative")
+		return
+	}
+	if req.MaxRows < 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "maxRows must not be negative")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.exportTimeout)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, s.exportTimeout+10*time.Second)
+
+	result, err := s.auditLogTopTemplates(ctx, cfg, doris.AuditLogTopTemplatesOptions{
+		LookbackSeconds: req.LookbackSeconds,
+		From:            strings.TrimSpace(req.From),
+		To:              strings.TrimSpace(req.To),
+		Filters:         req.Filters.toDoris(),
+		TopN:            req.TopN,
+		OrderBy:         req.OrderBy,
+		MaxRows:         req.MaxRows,
+	})
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusOK, result)
 }
 
 func (s *Server) handleDorisExplain(w http.ResponseWriter, r *http.Request) {
@@ -141,13 +532,297 @@ func (s *Server) handleDorisExplain(w http.ResponseWriter, r *http.Request) {
 		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	sessionVariables, err := doris.NormalizeExplainSessionVariables(req.SessionVariables)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "sessionVariables: "+err.Error())
+		return
+	}
 
-	rawText, err := s.explain(ctx, cfg, sqlText, mode)
+	rawText, err := s.explain(ctx, cfg, sqlText, mode, sessionVariables)
 	if err != nil {
 		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	writeData(w, r, http.StatusOK, map[string]any{
+	resp := map[string]any{
 		"rawText": rawText,
+	}
+	// Output the parser does not understand is still useful as raw text.
+	analysis := doris.AnalyzeExplainOutput(mode, rawText, sqlText)
+	if analysis.PlanError != "" {
+		resp["planError"] = analysis.PlanError
+	}
+	if analysis.Plan != nil {
+		resp["plan"] = analysis.Plan
+		resp["findings"] = analysis.Findings
+	}
+	if analysis.Shape != nil {
+		resp["shape"] = analysis.Shape
+	}
+	if analysis.Memo != nil {
+		resp["memo"] = analysis.Memo
+	}
+	if req.IncludeStats && analysis.Plan != nil {
+		refs, nodeKeys := doris.ExplainScanTableRefs(analysis.Plan)
+		if len(refs) > 0 {
+			statsCtx, statsCancel := context.WithTimeout(r.Context(), 15*time.Second)
+			defer statsCancel()
+			// Stats are an enrichment; the plan is still returned without them.
+			if stats, err := s.fetchLinkedTableStats(statsCtx, cfg, refs, nodeKeys); err != nil {
+				resp["tableStatsError"] = err.Error()
+			} else {
+				resp["tableStats"] = stats
+			}
+		}
+	}
+	writeData(w, r, http.StatusOK, resp)
+}
+
+func (s *Server) handleDorisExplainDiff(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req explainDiffRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return
+	}
+	mode, err := normalizeExplainMode(req.Mode)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !doris.ExplainModeHasPlanGraph(mode) {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "explain diff does not support mode: "+mode)
+		return
+	}
+	sides := []struct {
+		name string
+		side explainDiffSide
+	}{{"left", req.Left}, {"right", req.Right}}
+	for i := range sides {
+		side := &sides[i].side
+		side.SQL = strings.TrimSpace(side.SQL)
+		if side.SQL == "" {
+			side.SQL = strings.TrimSpace(req.SQL)
+		}
+		if side.SQL == "" {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, sides[i].name+".sql is required")
+			return
+		}
+		vars, err := doris.NormalizeExplainSessionVariables(side.SessionVariables)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, sides[i].name+".sessionVariables: "+err.Error())
+			return
+		}
+		side.SessionVariables = vars
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, 20*time.Second)
+
+	results := make(map[string]any, 3)
+	var plans [2]*doris.ExplainPlanGraph
+	for i, side := range sides {
+		rawText, err := s.explain(ctx, cfg, side.side.SQL, mode, side.side.SessionVariables)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, side.name+": "+err.Error())
+			return
+		}
+		plan, err := doris.ParseExplain(rawText)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, side.name+" plan: "+err.Error())
+			return
+		}
+		plans[i] = plan
+		results[side.name] = map[string]any{"rawText": rawText, "plan": plan}
+	}
+	results["diff"] = doris.DiffExplainPlans(plans[0], plans[1])
+	writeData(w, r, http.StatusOK, results)
+}
+
+func (s *Server) handleDorisQueryProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req queryProfileRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return
+	}
+	queryID := strings.TrimSpace(req.QueryID)
+	if queryID == "" {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "queryId is required")
+		return
+	}
+	if req.HTTPPort < 0 || req.HTTPPort > 65535 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "httpPort must be in 0..65535 (0 = default)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, 20*time.Second)
+	result, err := s.queryProfile(ctx, cfg, doris.QueryProfileOptions{QueryID: queryID, HTTPPort: req.HTTPPort})
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusOK, result)
+}
+
+func (s *Server) handleDorisExplainBatch(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req explainBatchRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return
+	}
+	mode, err := normalizeExplainMode(req.Mode)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	sessionVariables, err := doris.NormalizeExplainSessionVariables(req.SessionVariables)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "sessionVariables: "+err.Error())
+		return
+	}
+	if len(req.Items) == 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "items is required")
+		return
+	}
+	if len(req.Items) > doris.ExplainBatchMaxItems {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "items must be at most "+strconv.Itoa(doris.ExplainBatchMaxItems))
+		return
+	}
+	if req.Concurrency < 0 || req.Concurrency > doris.ExplainBatchMaxConcurrency {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "concurrency must be in 0.."+strconv.Itoa(doris.ExplainBatchMaxConcurrency))
+		return
+	}
+	items := make([]doris.ExplainBatchItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		sqlText := strings.TrimSpace(item.SQL)
+		if sqlText == "" {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "items["+strconv.Itoa(i)+"].sql is required")
+			return
+		}
+		database := strings.TrimSpace(item.Database)
+		if strings.ContainsAny(database, "`;\r\n\t ") {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "items["+strconv.Itoa(i)+"].database must be a database name")
+			return
+		}
+		items = append(items, doris.ExplainBatchItem{SQL: sqlText, Database: database})
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, 20*time.Second)
+	result, err := s.explainBatch(ctx, cfg, items, doris.ExplainBatchOptions{
+		Format:           mode,
+		SessionVariables: sessionVariables,
+		Concurrency:      req.Concurrency,
 	})
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusOK, result)
+}
+
+func (s *Server) handleDorisTableStats(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req tableStatsRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return
+	}
+
+	refs := make([]doris.TableStatsRef, 0, len(req.Tables))
+	for i, ref := range req.Tables {
+		ref.Table = strings.TrimSpace(ref.Table)
+		if ref.Table == "" {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "tables["+strconv.Itoa(i)+"].table is required")
+			return
+		}
+		refs = append(refs, ref)
+	}
+	nodeKeys := map[doris.TableStatsRef][]string{}
+	if explainText := strings.TrimSpace(req.ExplainText); explainText != "" {
+		plan, err := doris.ParseExplain(explainText)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "explainText: "+err.Error())
+			return
+		}
+		var planRefs []doris.TableStatsRef
+		planRefs, nodeKeys = doris.ExplainScanTableRefs(plan)
+		for _, ref := range planRefs {
+			if !containsTableStatsRef(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "tables or explainText with scan nodes is required")
+		return
+	}
+	if len(refs) > doris.TableStatsMaxTables {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "tables must be at most "+strconv.Itoa(doris.TableStatsMaxTables))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, 20*time.Second)
+	result, err := s.fetchLinkedTableStats(ctx, cfg, refs, nodeKeys)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusOK, result)
+}
+
+// fetchLinkedTableStats runs the stats runner and copies each ref's explain
+// scan node keys onto its entry.
+func (s *Server) fetchLinkedTableStats(
+	ctx context.Context,
+	cfg doris.ConnConfig,
+	refs []doris.TableStatsRef,
+	nodeKeys map[doris.TableStatsRef][]string,
+) (doris.TableStatsResult, error) {
+	result, err := s.tableStats(ctx, cfg, refs)
+	if err != nil {
+		return doris.TableStatsResult{}, err
+	}
+	for i := range result.Tables {
+		if i < len(refs) {
+			result.Tables[i].NodeKeys = nodeKeys[refs[i]]
+		}
+	}
+	return result, nil
+}
+
+func containsTableStatsRef(refs []doris.TableStatsRef, ref doris.TableStatsRef) bool {
+	for _, existing := range refs {
+		if existing == ref {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const exportJobsPathPrefix = "/api/v1/jobs/"
+
+func (s *Server) handleAuditExportJobCreate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	cfg, opts, ok := parseAuditExportRequestOrWriteError(w, r)
+	if !ok {
+		return
+	}
+	status, err := s.exportJobs.submit(cfg, opts)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusAccepted, status)
+}
+
+// handleExportJob serves GET/DELETE /api/v1/jobs/{id} and GET /api/v1/jobs/{id}/result.
+func (s *Server) handleExportJob(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, exportJobsPathPrefix)
+	id, sub, _ := strings.Cut(rest, "/")
+	if id == "" || (sub != "" && sub != "result") {
+		writeErrorWithRequest(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	if sub == "result" {
+		if !requireMethod(w, r, http.MethodGet) {
+			return
+		}
+		s.writeExportJobResult(w, r, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status, err := s.exportJobs.get(id)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		writeData(w, r, http.StatusOK, status)
+	case http.MethodDelete:
+		status, err := s.exportJobs.cancel(id)
+		if err != nil {
+			writeErrorWithRequest(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		writeData(w, r, http.StatusOK, status)
+	default:
+		writeErrorWithRequest(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) writeExportJobResult(w http.ResponseWriter, r *http.Request, id string) {
+	f, status, err := s.exportJobs.openResult(id)
+	if err != nil {
+		code := http.StatusConflict
+		if errors.Is(err, errExportJobNotFound) {
+			code = http.StatusNotFound
+		}
+		writeErrorWithRequest(w, r, code, err.Error())
+		return
+	}
+	defer f.Close()
+
+	writeAuditExportPage(w, r, f, auditExportPage{
+		Format:     status.Format,
+		Rows:       status.RowsWritten,
+		Bytes:      status.BytesWritten,
+		HasMore:    status.HasMore,
+		NextCursor: status.NextCursor,
+		Schema:     status.Schema,
+	})
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,48 +16,207 @@ import (
 )
 
 type schemaAuditScanRequest struct {
-	Connection *dorisConnection `json:"connection"`
-	Database   string           `json:"database"`
-	TableLike  string           `json:"tableLike"`
-	Page       int              `json:"page"`
-	PageSize   int              `json:"pageSize"`
+	Connection    *dorisConnection `json:"connection"`
+	Database      string           `json:"database"`
+	TableLike     string           `json:"tableLike"`
+	Page          int              `json:"page"`
+	PageSize      int              `json:"pageSize"`
+	Profile       string           `json:"profile"`
+	RuleConfig    json.RawMessage  `json:"ruleConfig"`
+	DisabledRules []string         `json:"disabledRules"`
+	// Deep, Concurrency and TableTimeoutSeconds control the deep scan mode.
+	Deep                bool `json:"deep"`
+	Concurrency         int  `json:"concurrency,omitempty"`
+	TableTimeoutSeconds int  `json:"tableTimeoutSeconds,omitempty"`
 }
 
 type schemaAuditTableDetailRequest struct {
-	Connection *dorisConnection `json:"connection"`
-	Database   string           `json:"database"`
-	Table      string           `json:"table"`
+	Connection    *dorisConnection `json:"connection"`
+	Database      string           `json:"database"`
+	Table         string           `json:"table"`
+	Profile       string           `json:"profile"`
+	RuleConfig    json.RawMessage  `json:"ruleConfig"`
+	DisabledRules []string         `json:"disabledRules"`
+}
+
+const schemaAuditWaiversPathPrefix = "/api/v1/doris/schema-audit/waivers/"
+
+const (
+	schemaAuditScanTimeout       = 60 * time.Second
+	schemaAuditScanStreamTimeout = 10 * time.Minute
+)
+
+// schemaAuditScanEvent is one NDJSON line of a streamed scan. Type is
+// "inventory", "item", "warning", "summary" or "error"; the stream ends with
+// exactly one "summary" or "error" event.
+type schemaAuditScanEvent struct {
+	Type      string                       `json:"type"`
+	Inventory *doris.SchemaAuditInventory  `json:"inventory,omitempty"`
+	Item      *doris.SchemaAuditScanItem   `json:"item,omitempty"`
+	Evaluated int                          `json:"evaluated,omitempty"`
+	Total     int                          `json:"total,omitempty"`
+	Warning   string                       `json:"warning,omitempty"`
+	Result    *doris.SchemaAuditScanResult `json:"result,omitempty"`
+	Error     string                       `json:"error,omitempty"`
+	TraceID   string                       `json:"traceId,omitempty"`
+}
+
+type schemaAuditWaiverRequest struct {
+	RuleID    string    `json:"ruleId"`
+	Database  string    `json:"database"`
+	Table     string    `json:"table"`
+	Reason    string    `json:"reason"`
+	Author    string    `json:"author"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type schemaAuditWaiverStatus struct {
+	doris.SchemaAuditWaiver
+	Active bool `json:"active"`
+}
+
+type schemaAuditProfile struct {
+	Name  string                      `json:"name"`
+	Rules doris.SchemaAuditRuleConfig `json:"rules"`
 }
 
 func (s *Server) handleDorisSchemaAuditScan(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodPost) {
 		return
 	}
+	cfg, opts, ok := s.parseSchemaAuditScanRequestOrWriteError(w, r)
+	if !ok {
+		return
+	}
 
-	var req schemaAuditScanRequest
-	if !readJSONOrWriteError(w, r, &req) {
+	// A deep scan that runs out of time still answers: tables it could not
+	// reach keep their summary findings and report a deepError.
+	ctx, cancel := context.WithTimeout(r.Context(), schemaAuditScanTimeout)
+	defer cancel()
+	applyReadWriteTimeout(&cfg, schemaAuditScanTimeout+10*time.Second)
+
+	result, err := s.schemaAuditScan(ctx, cfg, opts)
+	if err != nil {
+		writeErrorWithRequest(w, r, schemaAuditStatusCode(err), err.Error())
 		return
 	}
-	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	writeData(w, r, http.StatusOK, result)
+}
+
+// handleDorisSchemaAuditScanStream runs the same scan as
+// handleDorisSchemaAuditScan but answers with NDJSON events as the scan
+// progresses, so large clusters are not bound by the one-shot timeout.
+// Errors before the first event become JSON errors; later errors end the
+// stream with an error event. A client that disconnects cancels the scan.
+func (s *Server) handleDorisSchemaAuditScanStream(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	cfg, opts, ok := s.parseSchemaAuditScanRequestOrWriteError(w, r)
 	if !ok {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), schemaAuditScanStreamTimeout)
 	defer cancel()
-	applyReadWriteTimeout(&cfg, 70*time.Second)
+	applyReadWriteTimeout(&cfg, schemaAuditScanStreamTimeout+10*time.Second)
+	rc := http.NewResponseController(w)
+	// The server-wide WriteTimeout is sized for one-shot responses.
+	_ = rc.SetWriteDeadline(time.Now().Add(schemaAuditScanStreamTimeout + 10*time.Second))
 
-	result, err := s.schemaAuditScan(ctx, cfg, doris.SchemaAuditScanOptions{
-		Database:  strings.TrimSpace(req.Database),
-		TableLike: strings.TrimSpace(req.TableLike),
-		Page:      req.Page,
-		PageSize:  req.PageSize,
-	})
+	traceID := resolveTraceID(r)
+	enc := json.NewEncoder(w)
+	started := false
+	broken := false
+	emit := func(event schemaAuditScanEvent) {
+		if broken {
+			return
+		}
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Trace-Id", traceID)
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(event); err != nil {
+			// The client is gone; stop fetching tables nobody will see.
+			broken = true
+			cancel()
+			return
+		}
+		_ = rc.Flush()
+	}
+
+	total := 0
+	evaluated := 0
+	opts.OnInventory = func(inventory doris.SchemaAuditInventory) {
+		total = inventory.TableCount
+		emit(schemaAuditScanEvent{Type: "inventory", Inventory: &inventory, Total: total})
+	}
+	opts.OnItem = func(item doris.SchemaAuditScanItem) {
+		evaluated++
+		emit(schemaAuditScanEvent{Type: "item", Item: &item, Evaluated: evaluated, Total: total})
+	}
+	opts.OnWarning = func(warning string) {
+		emit(schemaAuditScanEvent{Type: "warning", Warning: warning})
+	}
+
+	result, err := s.schemaAuditScan(ctx, cfg, opts)
 	if err != nil {
-		writeErrorWithRequest(w, r, schemaAuditStatusCode(err), err.Error())
+		if !started {
+			writeErrorWithRequest(w, r, schemaAuditStatusCode(err), err.Error())
+			return
+		}
+		emit(schemaAuditScanEvent{Type: "error", Error: err.Error(), TraceID: traceID})
 		return
 	}
-	writeData(w, r, http.StatusOK, result)
+	emit(schemaAuditScanEvent{Type: "summary", Result: &result, TraceID: traceID})
+}
+
+func (s *Server) parseSchemaAuditScanRequestOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+) (doris.ConnConfig, doris.SchemaAuditScanOptions, bool) {
+	var req schemaAuditScanRequest
+	if !readJSONOrWriteError(w, r, &req) {
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+	cfg, ok := parseConnConfigOrWriteError(w, r, req.Connection)
+	if !ok {
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+	rules, ok := s.resolveSchemaAuditRulesOrWriteError(w, r, req.Profile, req.RuleConfig)
+	if !ok {
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+	if err := doris.DefaultSchemaAuditRuleRegistry().ValidateRuleIDs(req.DisabledRules); err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+	if req.Concurrency < 0 || req.Concurrency > doris.SchemaAuditDeepScanMaxConcurrency {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "concurrency must be in 0.."+strconv.Itoa(doris.SchemaAuditDeepScanMaxConcurrency))
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+	maxTableTimeoutSeconds := int(doris.SchemaAuditDeepScanMaxTableTimeout / time.Second)
+	if req.TableTimeoutSeconds < 0 || req.TableTimeoutSeconds > maxTableTimeoutSeconds {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, "tableTimeoutSeconds must be in 0.."+strconv.Itoa(maxTableTimeoutSeconds))
+		return doris.ConnConfig{}, doris.SchemaAuditScanOptions{}, false
+	}
+
+	return cfg, doris.SchemaAuditScanOptions{
+		Database:      strings.TrimSpace(req.Database),
+		TableLike:     strings.TrimSpace(req.TableLike),
+		Page:          req.Page,
+		PageSize:      req.PageSize,
+		Rules:         rules,
+		DisabledRules: req.DisabledRules,
+		Waivers:       s.schemaAuditWaivers.list(),
+		Deep:          req.Deep,
+		Concurrency:   req.Concurrency,
+		TableTimeout:  time.Duration(req.TableTimeoutSeconds) * time.Second,
+	}, true
 }
 
 func (s *Server) handleDorisSchemaAuditTableDetail(w http.ResponseWriter, r *http.Request) {
@@ -80,11 +243,25 @@ func (s *Server) handleDorisSchemaAuditTableDetail(w http.ResponseWriter, r *htt
 		writeErrorWithRequest(w, r, http.StatusBadRequest, "table is required")
 		return
 	}
+	rules, ok := s.resolveSchemaAuditRulesOrWriteError(w, r, req.Profile, req.RuleConfig)
+	if !ok {
+		return
+	}
+	if err := doris.DefaultSchemaAuditRuleRegistry().ValidateRuleIDs(req.DisabledRules); err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
 	defer cancel()
 	applyReadWriteTimeout(&cfg, 25*time.Second)
-	result, err := s.schemaAuditTableDetail(ctx, cfg, database, table)
+	result, err := s.schemaAuditTableDetail(ctx, cfg, doris.SchemaAuditTableDetailOptions{
+		Database:      database,
+		Table:         table,
+		Rules:         rules,
+		DisabledRules: req.DisabledRules,
+		Waivers:       s.schemaAuditWaivers.list(),
+	})
 	if err != nil {
 		writeErrorWithRequest(w, r, schemaAuditStatusCode(err), err.Error())
 		return
@@ -92,6 +269,159 @@ func (s *Server) handleDorisSchemaAuditTableDetail(w http.ResponseWriter, r *htt
 	writeData(w, r, http.StatusOK, result)
 }
 
+func (s *Server) handleDorisSchemaAuditRules(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeData(w, r, http.StatusOK, map[string]any{"rules": doris.DefaultSchemaAuditRuleRegistry().Rules()})
+}
+
+// handleDorisSchemaAuditWaivers serves GET (list) and POST (create) on
+// /api/v1/doris/schema-audit/waivers.
+func (s *Server) handleDorisSchemaAuditWaivers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		now := time.Now()
+		waivers := s.schemaAuditWaivers.list()
+		out := make([]schemaAuditWaiverStatus, 0, len(waivers))
+		for _, waiver := range waivers {
+			out = append(out, schemaAuditWaiverStatus{SchemaAuditWaiver: waiver, Active: waiver.Active(now)})
+		}
+		writeData(w, r, http.StatusOK, map[string]any{"waivers": out})
+	case http.MethodPost:
+		var req schemaAuditWaiverRequest
+		if !readJSONOrWriteError(w, r, &req) {
+			return
+		}
+		waiver := doris.SchemaAuditWaiver{
+			RuleID:    req.RuleID,
+			Database:  req.Database,
+			Table:     req.Table,
+			Reason:    req.Reason,
+			Author:    req.Author,
+			ExpiresAt: req.ExpiresAt.UTC(),
+		}.Normalize()
+		if err := waiver.Validate(); err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !waiver.Active(time.Now()) {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "expiresAt must be in the future")
+			return
+		}
+		created, err := s.schemaAuditWaivers.add(waiver)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, errSchemaAuditWaiverTooMany) {
+				code = http.StatusTooManyRequests
+			}
+			writeErrorWithRequest(w, r, code, err.Error())
+			return
+		}
+		writeData(w, r, http.StatusCreated, schemaAuditWaiverStatus{SchemaAuditWaiver: created, Active: true})
+	default:
+		writeErrorWithRequest(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleDorisSchemaAuditWaiver serves DELETE /api/v1/doris/schema-audit/waivers/{id}.
+func (s *Server) handleDorisSchemaAuditWaiver(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, schemaAuditWaiversPathPrefix)
+	if id == "" || strings.Contains(id, "/") {
+		writeErrorWithRequest(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	if !requireMethod(w, r, http.MethodDelete) {
+		return
+	}
+	removed, err := s.schemaAuditWaivers.remove(id)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errSchemaAuditWaiverNotFound) {
+			code = http.StatusNotFound
+		}
+		writeErrorWithRequest(w, r, code, err.Error())
+		return
+	}
+	writeData(w, r, http.StatusOK, schemaAuditWaiverStatus{SchemaAuditWaiver: removed, Active: removed.Active(time.Now())})
+}
+
+func (s *Server) handleDorisSchemaAuditProfiles(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	names := doris.SchemaAuditRuleProfileNames(s.schemaAuditProfiles)
+	profiles := make([]schemaAuditProfile, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, schemaAuditProfile{Name: name, Rules: s.schemaAuditProfiles[name]})
+	}
+	writeData(w, r, http.StatusOK, map[string]any{"profiles": profiles})
+}
+
+// resolveSchemaAuditRulesOrWriteError starts from the named profile and
+// applies the fields present in ruleConfig on top of it.
+func (s *Server) resolveSchemaAuditRulesOrWriteError(
+	w http.ResponseWriter,
+	r *http.Request,
+	profile string,
+	ruleConfig json.RawMessage,
+) (doris.SchemaAuditRuleConfig, bool) {
+	rules, err := doris.LookupSchemaAuditRuleProfile(s.schemaAuditProfiles, profile)
+	if err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return doris.SchemaAuditRuleConfig{}, false
+	}
+	if len(ruleConfig) > 0 && !bytes.Equal(bytes.TrimSpace(ruleConfig), []byte("null")) {
+		dec := json.NewDecoder(bytes.NewReader(ruleConfig))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rules); err != nil {
+			writeErrorWithRequest(w, r, http.StatusBadRequest, "ruleConfig: "+err.Error())
+			return doris.SchemaAuditRuleConfig{}, false
+		}
+	}
+	if err := rules.Validate(); err != nil {
+		writeErrorWithRequest(w, r, http.StatusBadRequest, err.Error())
+		return doris.SchemaAuditRuleConfig{}, false
+	}
+	return rules, true
+}
+
+func mergeSchemaAuditProfiles(
+	extra map[string]doris.SchemaAuditRuleConfig,
+) map[string]doris.SchemaAuditRuleConfig {
+	profiles := doris.SchemaAuditRuleProfiles()
+	for name, rules := range extra {
+		if name = strings.TrimSpace(name); name != "" {
+			profiles[name] = rules.WithDefaults()
+		}
+	}
+	return profiles
+}
+
+// LoadSchemaAuditProfiles reads a JSON object mapping profile names to rule
+// configs. Omitted thresholds fall back to the default profile.
+func LoadSchemaAuditProfiles(path string) (map[string]doris.SchemaAuditRuleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profiles map[string]doris.SchemaAuditRuleConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&profiles); err != nil {
+		return nil, err
+	}
+	for name, rules := range profiles {
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("schema audit profile name is required")
+		}
+		if err := rules.WithDefaults().Validate(); err != nil {
+			return nil, errors.New("schema audit profile " + name + ": " + err.Error())
+		}
+	}
+	return profiles, nil
+}
+
 func schemaAuditStatusCode(err error) int {
 	if isSchemaAuditRequestError(err) {
 		return http.StatusBadRequest
@@ -102,6 +432,9 @@ func schemaAuditStatusCode(err error) int {
 }
 
 func isSchemaAuditRequestError(err error) bool {
+	if errors.Is(err, doris.ErrInvalidSchemaAuditRuleConfig) {
+		return true
+	}
 	var mysqlErr *mysql.MySQLError
 	if errors.As(err, &mysqlErr) {
 		if isSchemaAuditRequestMySQLError(mysqlErr.Number, mysqlErr.Message) {
@@ -1,14 +1,19 @@
 package api
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/QuakeWang/doris-dashboard/apps/agentd/internal/doris"
 	"github.com/go-sql-driver/mysql"
@@ -23,21 +28,46 @@ type errBody struct {
 }
 
 const (
-	localRemoteAddr            = "127.0.0.1:12345"
-	exportPath                 = "/api/v1/doris/audit-log/export"
-	connTestPath               = "/api/v1/doris/connection/test"
-	databasesPath              = "/api/v1/doris/databases"
-	explainPath                = "/api/v1/doris/explain"
-	schemaAuditScanPath        = "/api/v1/doris/schema-audit/scan"
-	schemaAuditTableDetailPath = "/api/v1/doris/schema-audit/table-detail"
-	connTestBody               = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"}}`
-	connWithDBBody             = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","database":"tpch"}}`
-	exportBody                 = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10}`
-	explainTreeBody            = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1"}`
-	schemaAuditScanBody        = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"db1","tableLike":"fact","page":2,"pageSize":10}`
-	schemaAuditTableDetailBody = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"db1","table":"tbl1"}`
-	schemaAuditTableDetailNoDB = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"table":"tbl1"}`
-	schemaAuditTableDetailConn = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","database":"tpch"},"table":"tbl1"}`
+	localRemoteAddr             = "127.0.0.1:12345"
+	exportPath                  = "/api/v1/doris/audit-log/export"
+	connTestPath                = "/api/v1/doris/connection/test"
+	databasesPath               = "/api/v1/doris/databases"
+	catalogsPath                = "/api/v1/doris/catalogs"
+	explainPath                 = "/api/v1/doris/explain"
+	schemaAuditScanPath         = "/api/v1/doris/schema-audit/scan"
+	schemaAuditTableDetailPath  = "/api/v1/doris/schema-audit/table-detail"
+	connTestBody                = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"}}`
+	connWithDBBody              = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","database":"tpch"}}`
+	connWithCatalogBody         = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","catalog":" ` + "`hive`" + ` "}}`
+	connBadCatalogBody          = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","catalog":"hive;drop"}}`
+	exportBody                  = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10}`
+	exportRangeBody             = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"from":"2024-01-01 00:00:00","to":"2024-01-02 00:00:00","cursor":"prev-token","limit":10}`
+	exportFilteredBody          = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10,"filters":{"user":" alice ","state":"ERR","minQueryTimeMs":500,"sqlContains":"lineitem"},"columns":["time","query_time"]}`
+	exportNDJSONBody            = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10,"format":"ndjson","normalize":true,"redact":"hash","redactSalt":"vendor-a"}`
+	exportCSVBody               = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10,"format":"csv"}`
+	exportBadRedactBody         = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"limit":10,"redact":"mask"}`
+	topTemplatesPath            = "/api/v1/doris/audit-log/top-templates"
+	auditLogFileExportPath      = "/api/v1/audit-log/files/export"
+	topTemplatesBody            = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":600,"topN":5,"orderBy":"p99QueryTime","filters":{"database":"tpch"}}`
+	topTemplatesNoWindowBody    = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"topN":5}`
+	exportRangeWithLookbackBody = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"lookbackSeconds":60,"cursor":"prev-token","limit":10}`
+	explainTreeBody             = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1"}`
+	explainBadSessionVarBody    = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1","sessionVariables":{"exec_mem_limit":"1"}}`
+	queryProfilePath            = "/api/v1/doris/query-profile"
+	tableStatsPath              = "/api/v1/doris/table-stats"
+	tableStatsBody              = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","database":"tpch"},"tables":[{"table":" orders "}],"explainText":"PLAN FRAGMENT 0\n  0:VOlapScanNode\n     TABLE: tpch.lineitem(l)"}`
+	tableStatsEmptyBody         = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"explainText":"PLAN FRAGMENT 0\n  0:VUNION"}`
+	queryProfileBody            = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"queryId":" q-1 ","httpPort":18030}`
+	explainBatchPath            = "/api/v1/doris/explain/batch"
+	explainBatchBody            = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"mode":"plan","concurrency":2,"items":[{"sql":" SELECT 1 ","database":"tpch"},{"sql":"SELECT 2"}]}`
+	explainBatchEmptySQLBody    = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"items":[{"sql":"SELECT 1"},{"sql":" "}]}`
+	explainDiffPath             = "/api/v1/doris/explain/diff"
+	explainDiffVarsBody         = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1","mode":"plan","left":{"sessionVariables":{"disable_join_reorder":"false"}},"right":{"sessionVariables":{"DISABLE_JOIN_REORDER":"1"}}}`
+	explainDiffBadVarBody       = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"left":{"sql":"SELECT 1"},"right":{"sql":"SELECT 2","sessionVariables":{"sql_mode":"x"}}}`
+	schemaAuditScanBody         = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"db1","tableLike":"fact","page":2,"pageSize":10}`
+	schemaAuditTableDetailBody  = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"db1","table":"tbl1"}`
+	schemaAuditTableDetailNoDB  = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"table":"tbl1"}`
+	schemaAuditTableDetailConn  = `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password","database":"tpch"},"table":"tbl1"}`
 )
 
 func newLocalRequest(method, target string, body io.Reader) *http.Request {
@@ -112,15 +142,14 @@ func newTestServer(
 	schemaAuditScan SchemaAuditScanRunner,
 	schemaAuditTableDetail SchemaAuditTableDetailRunner,
 ) http.Handler {
-	return newServer(
-		exporter,
-		0,
-		testConnection,
-		explain,
-		listDatabases,
-		schemaAuditScan,
-		schemaAuditTableDetail,
-	)
+	return newServer(serverRunners{
+		exporter:               exporter,
+		testConnection:         testConnection,
+		explain:                explain,
+		listDatabases:          listDatabases,
+		schemaAuditScan:        schemaAuditScan,
+		schemaAuditTableDetail: schemaAuditTableDetail,
+	}, 0, ServerConfig{})
 }
 
 func newTestServerWithConnectionRunner(runner TestConnectionRunner) http.Handler {
@@ -139,6 +168,26 @@ func newTestServerWithSchemaAuditScanRunner(runner SchemaAuditScanRunner) http.H
 	return newTestServer(nil, nil, nil, nil, runner, nil)
 }
 
+func newTestServerWithTopTemplatesRunner(runner AuditLogTopTemplatesRunner) http.Handler {
+	return newServer(serverRunners{auditLogTopTemplates: runner}, 0, ServerConfig{})
+}
+
+func newTestServerWithQueryProfileRunner(runner QueryProfileRunner) http.Handler {
+	return newServer(serverRunners{queryProfile: runner}, 0, ServerConfig{})
+}
+
+func newTestServerWithExplainBatchRunner(runner ExplainBatchRunner) http.Handler {
+	return newServer(serverRunners{explainBatch: runner}, 0, ServerConfig{})
+}
+
+func newTestServerWithCatalogsRunner(runner ListCatalogsRunner) http.Handler {
+	return newServer(serverRunners{listCatalogs: runner}, 0, ServerConfig{})
+}
+
+func newTestServerWithTableStatsRunner(explain ExplainRunner, runner TableStatsRunner) http.Handler {
+	return newServer(serverRunners{explain: explain, tableStats: runner}, 0, ServerConfig{})
+}
+
 func newTestServerWithSchemaAuditTableDetailRunner(
 	runner SchemaAuditTableDetailRunner,
 ) http.Handler {
@@ -148,7 +197,14 @@ func newTestServerWithSchemaAuditTableDetailRunner(
 func TestServerErrorResponses(t *testing.T) {
 	t.Parallel()
 
-	noOpExporter := func(context.Context, doris.ConnConfig, int, int, io.Writer) error { return nil }
+	noOpExporter := func(
+		context.Context,
+		doris.ConnConfig,
+		doris.AuditLogExportOptions,
+		io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		return doris.AuditLogExportResult{}, nil
+	}
 	defaultHandler := NewServer(noOpExporter, 0)
 	postJSON := func(path, body string) *http.Request {
 		return newLocalJSONRequest(http.MethodPost, path, body)
@@ -169,13 +225,39 @@ func TestServerErrorResponses(t *testing.T) {
 		},
 		{
 			name: "exporter error writes JSON",
-			handler: NewServer(func(context.Context, doris.ConnConfig, int, int, io.Writer) error {
-				return errors.New("boom")
+			handler: NewServer(func(
+				context.Context,
+				doris.ConnConfig,
+				doris.AuditLogExportOptions,
+				io.Writer,
+			) (doris.AuditLogExportResult, error) {
+				return doris.AuditLogExportResult{}, errors.New("boom")
 			}, 0),
 			req:             postJSON(exportPath, exportBody),
 			wantStatus:      http.StatusBadRequest,
 			wantErrContains: "boom",
 		},
+		{
+			name:            "export rejects lookback with cursor",
+			handler:         defaultHandler,
+			req:             postJSON(exportPath, exportRangeWithLookbackBody),
+			wantStatus:      http.StatusBadRequest,
+			wantErrContains: "cannot be combined",
+		},
+		{
+			name:            "export rejects unsupported format",
+			handler:         defaultHandler,
+			req:             postJSON(exportPath, exportCSVBody),
+			wantStatus:      http.StatusBadRequest,
+			wantErrContains: "unsupported export format",
+		},
+		{
+			name:            "export rejects unsupported redaction mode",
+			handler:         defaultHandler,
+			req:             postJSON(exportPath, exportBadRedactBody),
+			wantStatus:      http.StatusBadRequest,
+			wantErrContains: "unsupported redaction mode",
+		},
 		{
 			name:    "reject non-loopback remote addr",
 			handler: defaultHandler,
@@ -214,7 +296,9 @@ func TestServerErrorResponses(t *testing.T) {
 			handler: newTestServer(
 				noOpExporter,
 				nil,
-				func(context.Context, doris.ConnConfig, string, string) (string, error) { return "", nil },
+				func(context.Context, doris.ConnConfig, string, string, map[string]string) (string, error) {
+					return "", nil
+				},
 				nil,
 				nil,
 				nil,
@@ -223,6 +307,13 @@ func TestServerErrorResponses(t *testing.T) {
 			wantStatus:      http.StatusBadRequest,
 			wantErrContains: "sql is required",
 		},
+		{
+			name:            "explain rejects session variables outside the allowlist",
+			handler:         defaultHandler,
+			req:             postJSON(explainPath, explainBadSessionVarBody),
+			wantStatus:      http.StatusBadRequest,
+			wantErrContains: "sessionVariables: unsupported session variable: exec_mem_limit",
+		},
 		{
 			name:            "schema audit table detail missing database",
 			handler:         defaultHandler,
@@ -252,9 +343,14 @@ func TestServerErrorResponses(t *testing.T) {
 func TestExportAuditLogExporterErrorAfterWriteAborts(t *testing.T) {
 	t.Parallel()
 
-	h := NewServer(func(ctx context.Context, cfg doris.ConnConfig, lookbackSeconds int, limit int, w io.Writer) error {
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
 		_, _ = io.WriteString(w, "a\tb\n")
-		return errors.New("boom")
+		return doris.AuditLogExportResult{}, errors.New("boom")
 	}, 0)
 
 	r := newLocalJSONRequest(http.MethodPost, exportPath, exportBody)
@@ -274,14 +370,17 @@ func TestExportAuditLogCallsExporter(t *testing.T) {
 	t.Parallel()
 
 	var gotCfg doris.ConnConfig
-	var gotLookback int
-	var gotLimit int
-	h := NewServer(func(ctx context.Context, cfg doris.ConnConfig, lookbackSeconds int, limit int, w io.Writer) error {
+	var gotOptions doris.AuditLogExportOptions
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
 		gotCfg = cfg
-		gotLookback = lookbackSeconds
-		gotLimit = limit
+		gotOptions = opts
 		_, _ = io.WriteString(w, "a\tb\n")
-		return nil
+		return doris.AuditLogExportResult{Rows: 1}, nil
 	}, 0)
 
 	w := serveLocalJSON(h, http.MethodPost, exportPath, exportBody)
@@ -290,8 +389,314 @@ func TestExportAuditLogCallsExporter(t *testing.T) {
 		t.Fatalf("unexpected content-type: %q", ct)
 	}
 	assertDefaultConn(t, gotCfg)
-	if gotLookback != 60 || gotLimit != 10 {
-		t.Fatalf("unexpected args: lookback=%d limit=%d", gotLookback, gotLimit)
+	if gotOptions.LookbackSeconds != 60 || gotOptions.Limit != 10 {
+		t.Fatalf("unexpected opts: %+v", gotOptions)
+	}
+}
+
+func TestExportAuditLogPassesFiltersAndColumns(t *testing.T) {
+	t.Parallel()
+
+	var gotFilters doris.AuditLogExportFilters
+	var gotColumns []string
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		gotFilters = opts.Filters
+		gotColumns = opts.Columns
+		_, _ = io.WriteString(w, "a\tb\n")
+		return doris.AuditLogExportResult{Rows: 1}, nil
+	}, 0)
+
+	w := serveLocalJSON(h, http.MethodPost, exportPath, exportFilteredBody)
+	assertStatus(t, w, http.StatusOK)
+	want := doris.AuditLogExportFilters{
+		User:           "alice",
+		State:          "ERR",
+		MinQueryTimeMs: 500,
+		SQLContains:    "lineitem",
+	}
+	if gotFilters != want {
+		t.Fatalf("unexpected filters: %+v", gotFilters)
+	}
+	if strings.Join(gotColumns, ",") != "time,query_time" {
+		t.Fatalf("unexpected columns: %v", gotColumns)
+	}
+}
+
+func TestExportAuditLogNDJSONFormatWithSchemaHeader(t *testing.T) {
+	t.Parallel()
+
+	var gotFormat, gotRedact string
+	var gotNormalize bool
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		gotFormat = opts.Format
+		gotNormalize = opts.Normalize
+		gotRedact = opts.Redact + ":" + opts.RedactSalt
+		opts.OnSchema(doris.AuditLogSchema{Version: doris.AuditLogSchemaVersion21, Normalized: true})
+		_, _ = io.WriteString(w, "{\"a\":1}\n")
+		return doris.AuditLogExportResult{Rows: 1}, nil
+	}, 0)
+
+	w := serveLocalJSON(h, http.MethodPost, exportPath, exportNDJSONBody)
+	assertStatus(t, w, http.StatusOK)
+	if gotFormat != doris.AuditLogExportFormatNDJSON || !gotNormalize || gotRedact != "hash:vendor-a" {
+		t.Fatalf("unexpected opts: format=%q normalize=%v redact=%q", gotFormat, gotNormalize, gotRedact)
+	}
+	if got := w.Header().Get("X-Audit-Log-Schema"); !strings.Contains(got, `"version":"2.1"`) ||
+		!strings.Contains(got, `"normalized":true`) {
+		t.Fatalf("unexpected schema header: %q", got)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/x-ndjson") {
+		t.Fatalf("unexpected content-type: %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "audit_log.ndjson") {
+		t.Fatalf("unexpected content-disposition: %q", cd)
+	}
+}
+
+func TestAuditExportContentTypeColumnarFormats(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		format       string
+		wantType     string
+		wantFilename string
+	}{
+		{format: doris.AuditLogExportFormatArrow, wantType: "application/vnd.apache.arrow.stream", wantFilename: "audit_log.arrows"},
+		{format: doris.AuditLogExportFormatParquet, wantType: "application/vnd.apache.parquet", wantFilename: "audit_log.parquet"},
+	}
+	for _, tc := range cases {
+		contentType, filename := auditExportContentType(tc.format)
+		if contentType != tc.wantType || filename != tc.wantFilename {
+			t.Fatalf("%s: unexpected content type %q, filename %q", tc.format, contentType, filename)
+		}
+	}
+}
+
+func TestExportAuditLogGzipNegotiation(t *testing.T) {
+	t.Parallel()
+
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		_, _ = io.WriteString(w, "a\tb\n")
+		return doris.AuditLogExportResult{Rows: 1}, nil
+	}, 0)
+
+	r := newLocalJSONRequest(http.MethodPost, exportPath, exportBody)
+	r.Header.Set("Accept-Encoding", "br;q=1.0, gzip;q=0.8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assertStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("unexpected content-encoding: %q", got)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("open gzip body failed: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body failed: %v", err)
+	}
+	if string(body) != "a\tb\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func TestExportAuditLogGzipErrorBeforeWriteReturnsJSON(t *testing.T) {
+	t.Parallel()
+
+	h := NewServer(func(
+		context.Context,
+		doris.ConnConfig,
+		doris.AuditLogExportOptions,
+		io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		return doris.AuditLogExportResult{}, errors.New("boom")
+	}, 0)
+
+	r := newLocalJSONRequest(http.MethodPost, exportPath, exportBody)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("unexpected content-encoding: %q", got)
+	}
+	assertErrContains(t, w, http.StatusBadRequest, "boom")
+}
+
+func TestNegotiateContentEncoding(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "identity", want: ""},
+		{in: "gzip", want: "gzip"},
+		{in: "deflate, GZIP", want: "gzip"},
+		{in: "gzip;q=0", want: ""},
+		{in: "*;q=0.5", want: "gzip"},
+		{in: "gzip;q=0, *", want: ""},
+	}
+	for _, tc := range cases {
+		if got := negotiateContentEncoding(tc.in); got != tc.want {
+			t.Fatalf("negotiateContentEncoding(%q): want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestExportAuditLogRangeModeReturnsCursorHeaders(t *testing.T) {
+	t.Parallel()
+
+	var gotOptions doris.AuditLogExportOptions
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		gotOptions = opts
+		_, _ = io.WriteString(w, "a\tb\n")
+		return doris.AuditLogExportResult{Rows: 10, HasMore: true, NextCursor: "next-token"}, nil
+	}, 0)
+
+	r := newLocalJSONRequest(http.MethodPost, exportPath, exportRangeBody)
+	r.Header.Set("Origin", "http://localhost:5173")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assertStatus(t, w, http.StatusOK)
+	if gotOptions.From != "2024-01-01 00:00:00" || gotOptions.To != "2024-01-02 00:00:00" {
+		t.Fatalf("unexpected range opts: %+v", gotOptions)
+	}
+	if gotOptions.Cursor != "prev-token" || gotOptions.LookbackSeconds != 0 {
+		t.Fatalf("unexpected cursor opts: %+v", gotOptions)
+	}
+	if got := w.Body.String(); got != "a\tb\n" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+	if got := w.Header().Get("Content-Length"); got != "4" {
+		t.Fatalf("unexpected content length: %q", got)
+	}
+	if got := w.Header().Get("X-Audit-Log-Next-Cursor"); got != "next-token" {
+		t.Fatalf("unexpected next cursor header: %q", got)
+	}
+	if got := w.Header().Get("X-Audit-Log-Has-More"); got != "true" {
+		t.Fatalf("unexpected has-more header: %q", got)
+	}
+	if got := w.Header().Get("X-Audit-Log-Row-Count"); got != "10" {
+		t.Fatalf("unexpected row-count header: %q", got)
+	}
+	exposed := w.Header().Get("Access-Control-Expose-Headers")
+	for _, name := range []string{"X-Audit-Log-Next-Cursor", "X-Audit-Log-Has-More", "X-Audit-Log-Schema"} {
+		if !strings.Contains(exposed, name) {
+			t.Fatalf("expected %s to be exposed, got %q", name, exposed)
+		}
+	}
+}
+
+func TestExportAuditLogRangeModeErrorIsJSON(t *testing.T) {
+	t.Parallel()
+
+	h := NewServer(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogExportOptions,
+		w io.Writer,
+	) (doris.AuditLogExportResult, error) {
+		_, _ = io.WriteString(w, "a\tb\n")
+		return doris.AuditLogExportResult{}, errors.New("boom")
+	}, 0)
+
+	w := serveLocalJSON(h, http.MethodPost, exportPath, exportRangeBody)
+	assertErrContains(t, w, http.StatusBadRequest, "boom")
+	if got := w.Header().Get("Content-Disposition"); got != "" {
+		t.Fatalf("unexpected content disposition on error: %q", got)
+	}
+}
+
+func TestAuditLogTopTemplatesCallsRunner(t *testing.T) {
+	t.Parallel()
+
+	var gotOptions doris.AuditLogTopTemplatesOptions
+	h := newTestServerWithTopTemplatesRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.AuditLogTopTemplatesOptions,
+	) (doris.AuditLogTopTemplatesResult, error) {
+		gotOptions = opts
+		return doris.AuditLogTopTemplatesResult{
+			SampledRows:   3,
+			TemplateCount: 1,
+			Templates:     []doris.AuditLogTemplateStats{{Fingerprint: "abc", Template: "SELECT ?", Count: 3}},
+		}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, topTemplatesPath, topTemplatesBody)
+	assertStatus(t, w, http.StatusOK)
+	if gotOptions.LookbackSeconds != 600 || gotOptions.TopN != 5 || gotOptions.OrderBy != "p99QueryTime" {
+		t.Fatalf("unexpected opts: %+v", gotOptions)
+	}
+	if gotOptions.Filters.Database != "tpch" {
+		t.Fatalf("unexpected filters: %+v", gotOptions.Filters)
+	}
+	assertBodyContains(t, w, `"fingerprint":"abc"`)
+	assertBodyContains(t, w, `"sampledRows":3`)
+
+	w = serveLocalJSON(h, http.MethodPost, topTemplatesPath, topTemplatesNoWindowBody)
+	assertErrContains(t, w, http.StatusBadRequest, "lookbackSeconds must be positive")
+}
+
+func TestAuditLogFileExportRequiresConfiguredDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logDir := filepath.Join(root, "fe", "log")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	record := "2024-01-01 10:00:01,000 [query] |Timestamp=2024-01-01 10:00:01.000|User=root|QueryId=q-1|Stmt=select 1\n"
+	if err := os.WriteFile(filepath.Join(logDir, "fe.audit.log"), []byte(record), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	body := `{"from":"2024-01-01 10:00:00","to":"2024-01-01 11:00:00","limit":10,"format":"ndjson","columns":["query_id","user"]}`
+
+	w := serveLocalJSON(NewServer(nil, 0), http.MethodPost, auditLogFileExportPath, body)
+	assertErrContains(t, w, http.StatusForbidden, "file mode is disabled")
+
+	h := newServer(serverRunners{}, 0, ServerConfig{AuditLogDirs: []string{filepath.Join(root, "fe")}})
+	w = serveLocalJSON(h, http.MethodPost, auditLogFileExportPath, body)
+	assertStatus(t, w, http.StatusOK)
+	if w.Body.Len() != 0 {
+		t.Fatalf("expected no rows from the configured root: %q", w.Body.String())
+	}
+
+	outside := `{"dir":"` + filepath.ToSlash(t.TempDir()) + `","limit":10}`
+	w = serveLocalJSON(h, http.MethodPost, auditLogFileExportPath, outside)
+	assertErrContains(t, w, http.StatusBadRequest, "not under a configured")
+
+	inside := strings.Replace(body, "{", `{"dir":"`+filepath.ToSlash(logDir)+`",`, 1)
+	w = serveLocalJSON(h, http.MethodPost, auditLogFileExportPath, inside)
+	assertStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != `{"query_id":"q-1","user":"root"}`+"\n" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+	if got := w.Header().Get(auditExportSchemaHeader); !strings.Contains(got, `"version":"fe.audit.log"`) {
+		t.Fatalf("unexpected schema header: %q", got)
 	}
 }
 
@@ -328,6 +733,7 @@ func TestExplainCallsRunner(t *testing.T) {
 		name     string
 		body     string
 		wantMode string
+		wantVars map[string]string
 		rawText  string
 	}{
 		{
@@ -342,6 +748,19 @@ func TestExplainCallsRunner(t *testing.T) {
 			wantMode: "plan",
 			rawText:  "PLAN FRAGMENT 0",
 		},
+		{
+			name:     "session variables",
+			body:     `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1","sessionVariables":{"enable_nereids_planner":"true","Parallel_Pipeline_Task_Num":"4"}}`,
+			wantMode: "tree",
+			wantVars: map[string]string{"enable_nereids_planner": "true", "parallel_pipeline_task_num": "4"},
+			rawText:  "[00]:[0: ResultSink]||[Fragment: 0]||",
+		},
+		{
+			name:     "shape mode",
+			body:     `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1","mode":" Shape "}`,
+			wantMode: "shape",
+			rawText:  "PhysicalOlapScan[t]",
+		},
 	}
 
 	for _, tc := range tests {
@@ -352,15 +771,18 @@ func TestExplainCallsRunner(t *testing.T) {
 			var gotCfg doris.ConnConfig
 			var gotSQL string
 			var gotMode string
+			var gotVars map[string]string
 			h := newTestServerWithExplainRunner(func(
 				ctx context.Context,
 				cfg doris.ConnConfig,
 				sql string,
 				mode string,
+				sessionVariables map[string]string,
 			) (string, error) {
 				gotCfg = cfg
 				gotSQL = sql
 				gotMode = mode
+				gotVars = sessionVariables
 				return tc.rawText, nil
 			})
 
@@ -373,11 +795,122 @@ func TestExplainCallsRunner(t *testing.T) {
 			if gotMode != tc.wantMode {
 				t.Fatalf("unexpected mode: %q", gotMode)
 			}
+			if !reflect.DeepEqual(gotVars, tc.wantVars) {
+				t.Fatalf("unexpected session variables: %v", gotVars)
+			}
 			assertBodyContains(t, w, `"rawText":"`+tc.rawText+`"`)
+			if !doris.ExplainModeHasPlanGraph(tc.wantMode) {
+				assertBodyContains(t, w, `"shape":{"nodes":[`)
+				return
+			}
+			assertBodyContains(t, w, `"plan":{"format":"`+tc.wantMode+`"`)
+			assertBodyContains(t, w, `"findings":[]`)
 		})
 	}
 }
 
+func TestExplainDiffRunsBothSides(t *testing.T) {
+	t.Parallel()
+
+	var gotVars []map[string]string
+	h := newTestServerWithExplainRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		sql string,
+		mode string,
+		sessionVariables map[string]string,
+	) (string, error) {
+		gotVars = append(gotVars, sessionVariables)
+		if sql != "SELECT 1" || mode != "plan" {
+			t.Errorf("unexpected call: %q %q", sql, mode)
+		}
+		if sessionVariables["disable_join_reorder"] == "true" {
+			return "PLAN FRAGMENT 0\n  0:VOlapScanNode\n     TABLE: db.t(t)\n     partitions=3/3", nil
+		}
+		return "PLAN FRAGMENT 0\n  0:VOlapScanNode\n     TABLE: db.t(t)\n     partitions=1/3", nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, explainDiffPath, explainDiffVarsBody)
+	assertStatus(t, w, http.StatusOK)
+	if len(gotVars) != 2 || gotVars[0]["disable_join_reorder"] != "false" || gotVars[1]["disable_join_reorder"] != "true" {
+		t.Fatalf("unexpected session variables: %v", gotVars)
+	}
+	assertBodyContains(t, w, `"identical":false`)
+	assertBodyContains(t, w, `"scanChanges":[{"table":"db.t(t)","leftPartitions":{"selected":1,"total":3},"rightPartitions":{"selected":3,"total":3}}]`)
+
+	w = serveLocalJSON(h, http.MethodPost, explainDiffPath, explainDiffBadVarBody)
+	assertErrContains(t, w, http.StatusBadRequest, "right.sessionVariables: unsupported session variable: sql_mode")
+}
+
+func TestQueryProfileCallsRunner(t *testing.T) {
+	t.Parallel()
+
+	var gotCfg doris.ConnConfig
+	var gotOptions doris.QueryProfileOptions
+	h := newTestServerWithQueryProfileRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		opts doris.QueryProfileOptions,
+	) (doris.QueryProfileResult, error) {
+		gotCfg = cfg
+		gotOptions = opts
+		return doris.ParseQueryProfile("Summary:\n  - Total: 1sec\n"), nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, queryProfilePath, queryProfileBody)
+	assertStatus(t, w, http.StatusOK)
+	assertDefaultConn(t, gotCfg)
+	if gotOptions.QueryID != "q-1" || gotOptions.HTTPPort != 18030 {
+		t.Fatalf("unexpected options: %+v", gotOptions)
+	}
+	assertBodyContains(t, w, `"totalTimeNs":1000000000`)
+
+	w = serveLocalJSON(h, http.MethodPost, queryProfilePath, connTestBody)
+	assertErrContains(t, w, http.StatusBadRequest, "queryId is required")
+
+	w = serveLocalJSON(h, http.MethodPost, queryProfilePath, strings.Replace(queryProfileBody, "18030", "70000", 1))
+	assertErrContains(t, w, http.StatusBadRequest, "httpPort must be in 0..65535 (0 = default)")
+}
+
+func TestExplainBatchCallsRunner(t *testing.T) {
+	t.Parallel()
+
+	var gotItems []doris.ExplainBatchItem
+	var gotOptions doris.ExplainBatchOptions
+	h := newTestServerWithExplainBatchRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		items []doris.ExplainBatchItem,
+		opts doris.ExplainBatchOptions,
+	) (doris.ExplainBatchResult, error) {
+		gotItems = items
+		gotOptions = opts
+		return doris.ExplainBatchResult{
+			Items: []doris.ExplainBatchItemResult{
+				{Index: 0, Database: "tpch", RawText: "PLAN FRAGMENT 0"},
+				{Index: 1, Error: "table not found"},
+			},
+			Succeeded: 1,
+			Failed:    1,
+		}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, explainBatchPath, explainBatchBody)
+	assertStatus(t, w, http.StatusOK)
+	want := []doris.ExplainBatchItem{{SQL: "SELECT 1", Database: "tpch"}, {SQL: "SELECT 2"}}
+	if !reflect.DeepEqual(gotItems, want) {
+		t.Fatalf("unexpected items: %+v", gotItems)
+	}
+	if gotOptions.Format != "plan" || gotOptions.Concurrency != 2 {
+		t.Fatalf("unexpected options: %+v", gotOptions)
+	}
+	assertBodyContains(t, w, `"error":"table not found"`)
+	assertBodyContains(t, w, `"succeeded":1,"failed":1`)
+
+	w = serveLocalJSON(h, http.MethodPost, explainBatchPath, explainBatchEmptySQLBody)
+	assertErrContains(t, w, http.StatusBadRequest, "items[1].sql is required")
+}
+
 func TestListDatabasesCallsRunner(t *testing.T) {
 	t.Parallel()
 
@@ -399,6 +932,30 @@ func TestListDatabasesCallsRunner(t *testing.T) {
 	assertBodyContains(t, w, `"databases":["db1","db2"]`)
 }
 
+func TestListCatalogsCallsRunner(t *testing.T) {
+	t.Parallel()
+
+	var gotCfg doris.ConnConfig
+	h := newTestServerWithCatalogsRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+	) ([]doris.Catalog, error) {
+		gotCfg = cfg
+		return []doris.Catalog{{Name: "internal", Type: "internal"}, {Name: "hive", Type: "hms"}}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, catalogsPath, connWithCatalogBody)
+	assertStatus(t, w, http.StatusOK)
+	assertDefaultConn(t, gotCfg)
+	if gotCfg.Catalog != "hive" {
+		t.Fatalf("unexpected catalog: %q", gotCfg.Catalog)
+	}
+	assertBodyContains(t, w, `"catalogs":[{"name":"internal","type":"internal"},{"name":"hive","type":"hms"}]`)
+
+	w = serveLocalJSON(h, http.MethodPost, catalogsPath, connBadCatalogBody)
+	assertErrContains(t, w, http.StatusBadRequest, "connection.catalog must be a catalog name")
+}
+
 func TestSchemaAuditScanCallsRunner(t *testing.T) {
 	t.Parallel()
 
@@ -437,24 +994,155 @@ func TestSchemaAuditScanCallsRunner(t *testing.T) {
 	assertBodyContains(t, w, `"tableCount":1`)
 }
 
+func TestSchemaAuditScanDeepOptions(t *testing.T) {
+	t.Parallel()
+
+	const conn = `"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"}`
+	var gotOptions doris.SchemaAuditScanOptions
+	h := newTestServerWithSchemaAuditScanRunner(func(
+		_ context.Context,
+		_ doris.ConnConfig,
+		opts doris.SchemaAuditScanOptions,
+	) (doris.SchemaAuditScanResult, error) {
+		gotOptions = opts
+		return doris.SchemaAuditScanResult{
+			Items:    []doris.SchemaAuditScanItem{{Database: "db1", Table: "tbl1", DeepError: "timeout"}},
+			DeepScan: &doris.SchemaAuditDeepScanSummary{Concurrency: 8, Failed: 1},
+		}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, schemaAuditScanPath,
+		`{`+conn+`,"deep":true,"concurrency":8,"tableTimeoutSeconds":5}`)
+	assertStatus(t, w, http.StatusOK)
+	if !gotOptions.Deep || gotOptions.Concurrency != 8 || gotOptions.TableTimeout != 5*time.Second {
+		t.Fatalf("unexpected deep opts: %+v", gotOptions)
+	}
+	assertBodyContains(t, w, `"deepError":"timeout"`)
+	assertBodyContains(t, w, `"deepScan":{"concurrency":8`)
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: `{` + conn + `,"deep":true,"concurrency":-1}`, want: "concurrency must be in 0..16"},
+		{body: `{` + conn + `,"deep":true,"concurrency":17}`, want: "concurrency must be in 0..16"},
+		{body: `{` + conn + `,"deep":true,"tableTimeoutSeconds":61}`, want: "tableTimeoutSeconds must be in 0..60"},
+	}
+	for _, tc := range tests {
+		w := serveLocalJSON(h, http.MethodPost, schemaAuditScanPath, tc.body)
+		assertErrContains(t, w, http.StatusBadRequest, tc.want)
+	}
+}
+
+func TestSchemaAuditScanStream(t *testing.T) {
+	t.Parallel()
+
+	const streamPath = "/api/v1/doris/schema-audit/scan/stream"
+	h := newTestServerWithSchemaAuditScanRunner(func(
+		_ context.Context,
+		_ doris.ConnConfig,
+		opts doris.SchemaAuditScanOptions,
+	) (doris.SchemaAuditScanResult, error) {
+		if opts.Database == "broken" {
+			return doris.SchemaAuditScanResult{}, errors.New("connection refused")
+		}
+		opts.OnInventory(doris.SchemaAuditInventory{TableCount: 2})
+		opts.OnWarning("truncated")
+		opts.OnItem(doris.SchemaAuditScanItem{Database: "db1", Table: "t1"})
+		opts.OnItem(doris.SchemaAuditScanItem{Database: "db1", Table: "t2"})
+		if opts.Database == "late" {
+			return doris.SchemaAuditScanResult{}, errors.New("scan interrupted")
+		}
+		return doris.SchemaAuditScanResult{TotalItems: 2}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, streamPath, schemaAuditScanBody)
+	assertStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("unexpected content type: %q", ct)
+	}
+	type streamEvent struct {
+		Type      string `json:"type"`
+		Evaluated int    `json:"evaluated"`
+		Total     int    `json:"total"`
+	}
+	var events []streamEvent
+	dec := json.NewDecoder(w.Body)
+	for dec.More() {
+		var event streamEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		events = append(events, event)
+	}
+	wantTypes := []string{"inventory", "warning", "item", "item", "summary"}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for i := range wantTypes {
+		if events[i].Type != wantTypes[i] {
+			t.Fatalf("event %d: got type %q want %q", i, events[i].Type, wantTypes[i])
+		}
+	}
+	if events[3].Evaluated != 2 || events[3].Total != 2 {
+		t.Fatalf("unexpected item progress: %+v", events[3])
+	}
+
+	w = serveLocalJSON(h, http.MethodPost, streamPath,
+		`{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"broken"}`)
+	assertErrContains(t, w, http.StatusBadGateway, "connection refused")
+
+	w = serveLocalJSON(h, http.MethodPost, streamPath,
+		`{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"database":"late"}`)
+	assertStatus(t, w, http.StatusOK)
+	assertBodyContains(t, w, `{"type":"error","error":"scan interrupted"`)
+}
+
+func TestSchemaAuditScanStreamCancelsWithClient(t *testing.T) {
+	t.Parallel()
+
+	h := newTestServerWithSchemaAuditScanRunner(func(
+		ctx context.Context,
+		_ doris.ConnConfig,
+		opts doris.SchemaAuditScanOptions,
+	) (doris.SchemaAuditScanResult, error) {
+		opts.OnInventory(doris.SchemaAuditInventory{TableCount: 1})
+		<-ctx.Done()
+		return doris.SchemaAuditScanResult{}, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newLocalJSONRequest(http.MethodPost, "/api/v1/doris/schema-audit/scan/stream", schemaAuditScanBody).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(w, r)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream did not stop after the client went away")
+	}
+	assertBodyContains(t, w, `"type":"error"`)
+}
+
 func TestSchemaAuditTableDetailCallsRunner(t *testing.T) {
 	t.Parallel()
 
 	var gotCfg doris.ConnConfig
-	var gotDatabase string
-	var gotTable string
+	var gotOptions doris.SchemaAuditTableDetailOptions
 	h := newTestServerWithSchemaAuditTableDetailRunner(func(
 		ctx context.Context,
 		cfg doris.ConnConfig,
-		database string,
-		table string,
+		opts doris.SchemaAuditTableDetailOptions,
 	) (doris.SchemaAuditTableDetailResult, error) {
 		gotCfg = cfg
-		gotDatabase = database
-		gotTable = table
+		gotOptions = opts
 		return doris.SchemaAuditTableDetailResult{
-			Database:       database,
-			Table:          table,
+			Database:       opts.Database,
+			Table:          opts.Table,
 			CreateTableSQL: "CREATE TABLE ...",
 		}, nil
 	})
@@ -462,8 +1150,11 @@ func TestSchemaAuditTableDetailCallsRunner(t *testing.T) {
 	w := serveLocalJSON(h, http.MethodPost, schemaAuditTableDetailPath, schemaAuditTableDetailBody)
 	assertStatus(t, w, http.StatusOK)
 	assertDefaultConn(t, gotCfg)
-	if gotDatabase != "db1" || gotTable != "tbl1" {
-		t.Fatalf("unexpected target: %s.%s", gotDatabase, gotTable)
+	if gotOptions.Database != "db1" || gotOptions.Table != "tbl1" {
+		t.Fatalf("unexpected target: %s.%s", gotOptions.Database, gotOptions.Table)
+	}
+	if gotOptions.Rules != doris.DefaultSchemaAuditRuleConfig() {
+		t.Fatalf("expected default rules, got %+v", gotOptions.Rules)
 	}
 	assertBodyContains(t, w, `"createTableSql":"CREATE TABLE ..."`)
 }
@@ -563,8 +1254,7 @@ func TestSchemaAuditTableDetailRunnerErrorStatus(t *testing.T) {
 			h := newTestServerWithSchemaAuditTableDetailRunner(func(
 				context.Context,
 				doris.ConnConfig,
-				string,
-				string,
+				doris.SchemaAuditTableDetailOptions,
 			) (doris.SchemaAuditTableDetailResult, error) {
 				return doris.SchemaAuditTableDetailResult{}, tc.runnerErr
 			})
@@ -575,3 +1265,333 @@ func TestSchemaAuditTableDetailRunnerErrorStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaAuditRuleProfiles(t *testing.T) {
+	t.Parallel()
+
+	const conn = `"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"}`
+	strict := doris.SchemaAuditRuleProfiles()["strict"]
+	overridden := doris.DefaultSchemaAuditRuleConfig()
+	overridden.EmptyTailThreshold = 14
+	strictOverridden := strict
+	strictOverridden.EmptyRatioWarn = 0.25
+
+	tests := []struct {
+		name            string
+		body            string
+		wantRules       doris.SchemaAuditRuleConfig
+		wantErrContains string
+	}{
+		{
+			name:      "named profile",
+			body:      `{` + conn + `,"profile":"strict"}`,
+			wantRules: strict,
+		},
+		{
+			name:      "rule config overrides default profile",
+			body:      `{` + conn + `,"ruleConfig":{"emptyTailThreshold":14}}`,
+			wantRules: overridden,
+		},
+		{
+			name:      "rule config overrides named profile",
+			body:      `{` + conn + `,"profile":"strict","ruleConfig":{"emptyRatioWarn":0.25}}`,
+			wantRules: strictOverridden,
+		},
+		{
+			name:            "unknown profile",
+			body:            `{` + conn + `,"profile":"lenient"}`,
+			wantErrContains: "unknown schema audit profile: lenient",
+		},
+		{
+			name:            "unknown rule config field",
+			body:            `{` + conn + `,"ruleConfig":{"emptyRatio":0.5}}`,
+			wantErrContains: "unknown field",
+		},
+		{
+			name:            "inconsistent thresholds",
+			body:            `{` + conn + `,"ruleConfig":{"emptyRatioWarn":0.9,"emptyRatioCritical":0.5}}`,
+			wantErrContains: "emptyRatioCritical must be >= emptyRatioWarn",
+		},
+		{
+			name:            "explicit zero threshold",
+			body:            `{` + conn + `,"ruleConfig":{"emptyTailThreshold":0}}`,
+			wantErrContains: "emptyTailThreshold must be >= 1",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotRules doris.SchemaAuditRuleConfig
+			called := false
+			h := newTestServerWithSchemaAuditScanRunner(func(
+				_ context.Context,
+				_ doris.ConnConfig,
+				opts doris.SchemaAuditScanOptions,
+			) (doris.SchemaAuditScanResult, error) {
+				called = true
+				gotRules = opts.Rules
+				return doris.SchemaAuditScanResult{}, nil
+			})
+
+			w := serveLocalJSON(h, http.MethodPost, schemaAuditScanPath, tc.body)
+			if tc.wantErrContains != "" {
+				assertErrContains(t, w, http.StatusBadRequest, tc.wantErrContains)
+				if called {
+					t.Fatalf("runner should not be called on invalid rules")
+				}
+				return
+			}
+			assertStatus(t, w, http.StatusOK)
+			if gotRules != tc.wantRules {
+				t.Fatalf("unexpected rules: got %+v want %+v", gotRules, tc.wantRules)
+			}
+		})
+	}
+}
+
+func TestSchemaAuditRulesEndpoint(t *testing.T) {
+	t.Parallel()
+
+	h := newTestServer(nil, nil, nil, nil, nil, nil)
+	w := serveLocalJSON(h, http.MethodGet, "/api/v1/doris/schema-audit/rules", "")
+	assertStatus(t, w, http.StatusOK)
+	assertBodyContains(t, w, `"rules":[{"id":"SA-E001"`)
+	assertBodyContains(t, w, `"inputs":["table_detail"]`)
+}
+
+func TestSchemaAuditDisabledRules(t *testing.T) {
+	t.Parallel()
+
+	const conn = `"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"}`
+	var gotDisabled []string
+	h := newTestServerWithSchemaAuditTableDetailRunner(func(
+		_ context.Context,
+		_ doris.ConnConfig,
+		opts doris.SchemaAuditTableDetailOptions,
+	) (doris.SchemaAuditTableDetailResult, error) {
+		gotDisabled = opts.DisabledRules
+		return doris.SchemaAuditTableDetailResult{}, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, schemaAuditTableDetailPath,
+		`{`+conn+`,"database":"db1","table":"tbl1","disabledRules":["SA-B009"]}`)
+	assertStatus(t, w, http.StatusOK)
+	if len(gotDisabled) != 1 || gotDisabled[0] != "SA-B009" {
+		t.Fatalf("unexpected disabled rules: %v", gotDisabled)
+	}
+
+	w = serveLocalJSON(h, http.MethodPost, schemaAuditTableDetailPath,
+		`{`+conn+`,"database":"db1","table":"tbl1","disabledRules":["SA-Z001"]}`)
+	assertErrContains(t, w, http.StatusBadRequest, "unknown rule: SA-Z001")
+}
+
+func TestSchemaAuditWaivers(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "waivers.json")
+	var gotWaivers []doris.SchemaAuditWaiver
+	h := newServer(serverRunners{schemaAuditScan: func(
+		_ context.Context,
+		_ doris.ConnConfig,
+		opts doris.SchemaAuditScanOptions,
+	) (doris.SchemaAuditScanResult, error) {
+		gotWaivers = opts.Waivers
+		return doris.SchemaAuditScanResult{}, nil
+	}}, 0, ServerConfig{SchemaAuditWaiversFile: path})
+	const waiversPath = "/api/v1/doris/schema-audit/waivers"
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	w := serveLocalJSON(h, http.MethodPost, waiversPath,
+		`{"ruleId":"SA-E001","database":"mig_*","reason":"backfill","author":"dba","expiresAt":"`+expiresAt+`"}`)
+	assertStatus(t, w, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID     string `json:"id"`
+			Table  string `json:"table"`
+			Active bool   `json:"active"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("decode response failed: %v", err)
+	}
+	if created.Data.ID == "" || created.Data.Table != "*" || !created.Data.Active {
+		t.Fatalf("unexpected created waiver: %+v", created.Data)
+	}
+
+	saved, err := LoadSchemaAuditWaivers(path)
+	if err != nil || len(saved) != 1 || saved[0].ID != created.Data.ID {
+		t.Fatalf("expected waiver to be persisted, got %+v err=%v", saved, err)
+	}
+
+	w = serveLocalJSON(h, http.MethodPost, schemaAuditScanPath, schemaAuditScanBody)
+	assertStatus(t, w, http.StatusOK)
+	if len(gotWaivers) != 1 || gotWaivers[0].RuleID != "SA-E001" {
+		t.Fatalf("expected scan to receive waivers, got %+v", gotWaivers)
+	}
+
+	w = serveLocalJSON(h, http.MethodGet, waiversPath, "")
+	assertStatus(t, w, http.StatusOK)
+	assertBodyContains(t, w, `"active":true`)
+
+	w = serveLocalJSON(h, http.MethodDelete, waiversPath+"/"+created.Data.ID, "")
+	assertStatus(t, w, http.StatusOK)
+	w = serveLocalJSON(h, http.MethodDelete, waiversPath+"/"+created.Data.ID, "")
+	assertErrContains(t, w, http.StatusNotFound, "schema audit waiver not found")
+	if saved, _ := LoadSchemaAuditWaivers(path); len(saved) != 0 {
+		t.Fatalf("expected deleted waiver to be removed from file, got %+v", saved)
+	}
+}
+
+func TestSchemaAuditWaiverValidation(t *testing.T) {
+	t.Parallel()
+
+	h := newTestServer(nil, nil, nil, nil, nil, nil)
+	const waiversPath = "/api/v1/doris/schema-audit/waivers"
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name            string
+		body            string
+		wantErrContains string
+	}{
+		{
+			name:            "unknown rule",
+			body:            `{"ruleId":"SA-Z001","reason":"r","author":"a","expiresAt":"` + future + `"}`,
+			wantErrContains: "unknown schema audit rule: SA-Z001",
+		},
+		{
+			name:            "missing author",
+			body:            `{"ruleId":"SA-E001","reason":"r","expiresAt":"` + future + `"}`,
+			wantErrContains: "author is required",
+		},
+		{
+			name:            "expiry in the past",
+			body:            `{"ruleId":"SA-E001","reason":"r","author":"a","expiresAt":"` + past + `"}`,
+			wantErrContains: "expiresAt must be in the future",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := serveLocalJSON(h, http.MethodPost, waiversPath, tc.body)
+			assertErrContains(t, w, http.StatusBadRequest, tc.wantErrContains)
+		})
+	}
+}
+
+func TestLoadSchemaAuditWaiversValidatesEachWaiver(t *testing.T) {
+	t.Parallel()
+
+	const valid = `{"id":"w1","ruleId":"sa-e001","database":"db","reason":"backfill","author":"dba","expiresAt":"2030-01-01T00:00:00Z"}`
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: `[` + valid + `]`},
+		{name: "missing id", content: `[{"ruleId":"SA-E001"}]`, wantErr: "schema audit waiver id is required"},
+		{name: "duplicate id", content: `[` + valid + `,` + valid + `]`, wantErr: "duplicate schema audit waiver id: w1"},
+		{
+			name:    "unknown rule",
+			content: `[{"id":"w2","ruleId":"SA-X999","reason":"r","author":"a","expiresAt":"2030-01-01T00:00:00Z"}]`,
+			wantErr: "schema audit waiver w2: unknown schema audit rule: SA-X999",
+		},
+		{
+			name:    "missing reason",
+			content: `[{"id":"w3","ruleId":"SA-E001","author":"a","expiresAt":"2030-01-01T00:00:00Z"}]`,
+			wantErr: "schema audit waiver w3: reason is required",
+		},
+		{
+			name:    "bad pattern",
+			content: `[{"id":"w4","ruleId":"SA-E001","database":"[","reason":"r","author":"a","expiresAt":"2030-01-01T00:00:00Z"}]`,
+			wantErr: "schema audit waiver w4: database pattern is invalid",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "waivers.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatalf("write waivers: %v", err)
+			}
+			waivers, err := LoadSchemaAuditWaivers(path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil || len(waivers) != 1 || waivers[0].RuleID != "SA-E001" {
+				t.Fatalf("unexpected waivers: %+v err=%v", waivers, err)
+			}
+		})
+	}
+}
+
+func TestSchemaAuditProfilesEndpoint(t *testing.T) {
+	t.Parallel()
+
+	h := newServer(serverRunners{}, 0, ServerConfig{
+		SchemaAuditProfiles: map[string]doris.SchemaAuditRuleConfig{
+			"team-a": {EmptyTailThreshold: 21},
+		},
+	})
+
+	w := serveLocalJSON(h, http.MethodGet, "/api/v1/doris/schema-audit/profiles", "")
+	assertStatus(t, w, http.StatusOK)
+	assertBodyContains(t, w, `"profiles":[{"name":"analytics-cold-data"`)
+	assertBodyContains(t, w, `"name":"strict"`)
+	assertBodyContains(t, w, `"name":"team-a","rules":{"emptyRatioWarn":0.3`)
+	assertBodyContains(t, w, `"emptyTailThreshold":21`)
+}
+
+func TestTableStatsLinksScanNodes(t *testing.T) {
+	t.Parallel()
+
+	var gotRefs []doris.TableStatsRef
+	h := newTestServerWithTableStatsRunner(func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		sql string,
+		mode string,
+		sessionVariables map[string]string,
+	) (string, error) {
+		return "PLAN FRAGMENT 0\n  0:VOlapScanNode\n     TABLE: tpch.lineitem(l)", nil
+	}, func(
+		ctx context.Context,
+		cfg doris.ConnConfig,
+		refs []doris.TableStatsRef,
+	) (doris.TableStatsResult, error) {
+		gotRefs = refs
+		out := doris.TableStatsResult{}
+		for _, ref := range refs {
+			out.Tables = append(out.Tables, doris.TableStatsEntry{TableStatsRef: ref})
+		}
+		return out, nil
+	})
+
+	w := serveLocalJSON(h, http.MethodPost, tableStatsPath, tableStatsBody)
+	assertStatus(t, w, http.StatusOK)
+	wantRefs := []doris.TableStatsRef{{Table: "orders"}, {Database: "tpch", Table: "lineitem"}}
+	if !reflect.DeepEqual(gotRefs, wantRefs) {
+		t.Fatalf("unexpected refs: %+v", gotRefs)
+	}
+	assertBodyContains(t, w, `"database":"tpch","table":"lineitem"`)
+	assertBodyContains(t, w, `"nodeKeys":["n1"]`)
+
+	w = serveLocalJSON(h, http.MethodPost, tableStatsPath, tableStatsEmptyBody)
+	assertErrContains(t, w, http.StatusBadRequest, "tables or explainText with scan nodes is required")
+
+	w = serveLocalJSON(h, http.MethodPost, explainPath, `{"connection":{"host":"127.0.0.1","port":19030,"user":"test_user","password":"test_password"},"sql":"SELECT 1","mode":"plan","includeStats":true}`)
+	assertStatus(t, w, http.StatusOK)
+	assertBodyContains(t, w, `"tableStats":{"tables":[{"database":"tpch","table":"lineitem"`)
+}
@@ -0,0 +1,123 @@
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const auditLogMaxProjectedColumns = 128
+
+const auditLogColumnsQuery = "" +
+	"SELECT column_name FROM information_schema.columns " +
+	"WHERE table_schema = '__internal_schema' AND table_name = 'audit_log' " +
+	"ORDER BY ordinal_position"
+
+func listAuditLogColumns(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, auditLogColumnsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]string, 0, 32)
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name.Valid && strings.TrimSpace(name.String) != "" {
+			columns = append(columns, strings.TrimSpace(name.String))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("unexpected audit_log schema: no columns in information_schema.columns")
+	}
+	return columns, nil
+}
+
+type auditLogProjectionColumn struct {
+	// Source is the live column name; empty selects NULL.
+	Source string
+	Alias  string
+}
+
+// resolveAuditLogProjection validates requested columns against the live schema.
+// Names match case-insensitively. With normalize, names refer to the canonical
+// column set and the output uses canonical names. Range mode always needs time and
+// query_id for the continuation cursor, so they are appended when missing.
+// A nil result selects every live column.
+func resolveAuditLogProjection(
+	requested []string,
+	schema AuditLogSchema,
+	normalize bool,
+	rangeMode bool,
+) ([]auditLogProjectionColumn, error) {
+	if len(requested) > auditLogMaxProjectedColumns {
+		return nil, fmt.Errorf("columns is invalid: too many columns (max=%d)", auditLogMaxProjectedColumns)
+	}
+	if len(requested) == 0 {
+		if normalize {
+			return schema.normalizedProjection(), nil
+		}
+		return nil, nil
+	}
+
+	byName := make(map[string]string, len(schema.Columns))
+	for _, col := range schema.Columns {
+		byName[strings.ToLower(col)] = col
+	}
+	lookup := func(name string) (auditLogProjectionColumn, bool) {
+		if normalize {
+			canonical, ok := canonicalAuditLogColumnName(name)
+			if !ok {
+				return auditLogProjectionColumn{}, false
+			}
+			return auditLogProjectionColumn{Source: schema.canonicalToSource[canonical], Alias: canonical}, true
+		}
+		col, ok := byName[strings.ToLower(name)]
+		return auditLogProjectionColumn{Source: col, Alias: col}, ok
+	}
+
+	projection := make([]auditLogProjectionColumn, 0, len(requested)+2)
+	seen := make(map[string]struct{}, len(requested)+2)
+	add := func(col auditLogProjectionColumn) {
+		key := strings.ToLower(col.Alias)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		projection = append(projection, col)
+	}
+	unknown := make([]string, 0)
+	for _, raw := range requested {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			return nil, errors.New("columns is invalid: empty column name")
+		}
+		col, ok := lookup(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		add(col)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("columns is invalid: unknown audit_log columns: %s", strings.Join(unknown, ", "))
+	}
+	if rangeMode {
+		for _, required := range []string{auditLogTimeColumn, auditLogQueryIDColumn} {
+			col, ok := lookup(required)
+			if !ok || col.Source == "" {
+				return nil, fmt.Errorf("unexpected audit_log schema: missing %s", required)
+			}
+			add(col)
+		}
+	}
+	return projection, nil
+}
@@ -0,0 +1,100 @@
+package doris
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveAuditLogProjection(t *testing.T) {
+	t.Parallel()
+
+	schema := describeAuditLogSchema([]string{
+		"query_id", "time", "client_ip", "user", "query_time", "scan_bytes", "cloud_cluster_name", "stmt",
+	})
+	cases := []struct {
+		name      string
+		requested []string
+		normalize bool
+		rangeMode bool
+		want      []auditLogProjectionColumn
+		wantErr   string
+	}{
+		{
+			name:      "case insensitive and deduplicated",
+			requested: []string{"Query_Time", "user", "USER"},
+			want: []auditLogProjectionColumn{
+				{Source: "query_time", Alias: "query_time"},
+				{Source: "user", Alias: "user"},
+			},
+		},
+		{
+			name:      "range mode appends cursor columns",
+			requested: []string{"scan_bytes"},
+			rangeMode: true,
+			want: []auditLogProjectionColumn{
+				{Source: "scan_bytes", Alias: "scan_bytes"},
+				{Source: "time", Alias: "time"},
+				{Source: "query_id", Alias: "query_id"},
+			},
+		},
+		{
+			name:      "normalized names map aliases and missing columns",
+			requested: []string{"compute_group", "peak_memory_bytes"},
+			normalize: true,
+			want: []auditLogProjectionColumn{
+				{Source: "cloud_cluster_name", Alias: "compute_group"},
+				{Source: "", Alias: "peak_memory_bytes"},
+			},
+		},
+		{
+			name:      "unknown column rejected",
+			requested: []string{"query_time", "peak_memory"},
+			wantErr:   "unknown audit_log columns: peak_memory",
+		},
+		{
+			name:      "empty name rejected",
+			requested: []string{" "},
+			wantErr:   "empty column name",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveAuditLogProjection(tc.requested, schema, tc.normalize, tc.rangeMode)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("unexpected projection:\nwant: %v\ngot:  %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAuditLogExportQueryProjection(t *testing.T) {
+	t.Parallel()
+
+	q, err := buildAuditLogExportQuery(AuditLogExportOptions{LookbackSeconds: 60, Limit: 10}, AuditLogSchema{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := q.sql([]auditLogProjectionColumn{
+		{Source: "time", Alias: "time"},
+		{Source: "cloud_cluster_name", Alias: "compute_group"},
+		{Alias: "workload_group"},
+	})
+	want := "SELECT `time`, `cloud_cluster_name` AS `compute_group`, NULL AS `workload_group` " +
+		"FROM `__internal_schema`.`audit_log` "
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("unexpected query: %s", got)
+	}
+}
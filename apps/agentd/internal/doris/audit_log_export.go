@@ -3,6 +3,8 @@ package doris
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,59 +18,337 @@ const (
 
 	auditLogMaxLookbackSeconds     = 30 * 24 * 3600
 	auditLogDefaultLookbackSeconds = 3600
+
+	auditLogTimeColumn    = "time"
+	auditLogQueryIDColumn = "query_id"
+	auditLogStmtColumn    = "stmt"
+	auditLogTimeLayout    = "2006-01-02 15:04:05.000000"
 )
 
-func StreamAuditLogOutfileTSVLookback(
-	ctx context.Context,
-	cfg ConnConfig,
-	lookbackSeconds int,
-	limit int,
-	w io.Writer,
-) error {
-	if lookbackSeconds <= 0 {
-		lookbackSeconds = auditLogDefaultLookbackSeconds
+var auditLogTimeInputLayouts = []string{
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02T15:04:05.999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// AuditLogExportOptions selects the audit_log rows to export.
+//
+// LookbackSeconds exports the newest rows relative to NOW(). From, To and
+// Cursor switch to range mode: rows are ordered by (time, query_id) ascending
+// and the result carries a cursor for resuming after the last exported row.
+type AuditLogExportOptions struct {
+	LookbackSeconds int
+	Limit           int
+	From            string
+	To              string
+	Cursor          string
+	Filters         AuditLogExportFilters
+	Format          string
+	// Columns projects the export; empty selects every audit_log column.
+	Columns []string
+	// Normalize maps the live audit_log columns onto the canonical column set
+	// shared by all supported Doris versions.
+	Normalize bool
+	// Redact replaces literals in the stmt column: "placeholder" or "hash". Empty keeps stmt as is.
+	Redact string
+	// RedactSalt keys the "hash" mode; empty uses a random salt, so hashes only correlate within one export.
+	RedactSalt string
+	// OnSchema, when set, receives the detected audit_log schema before any output is written.
+	OnSchema func(AuditLogSchema)
+}
+
+// AuditLogCursor is the position of the last exported row in range mode.
+// QueryID is empty for rows whose query_id is NULL or empty; range mode
+// orders those rows first within their timestamp.
+type AuditLogCursor struct {
+	Time    string `json:"t"`
+	QueryID string `json:"q"`
+}
+
+type AuditLogExportResult struct {
+	Rows       int
+	HasMore    bool
+	NextCursor string
+}
+
+func (o AuditLogExportOptions) rangeMode() bool {
+	return strings.TrimSpace(o.From) != "" || strings.TrimSpace(o.To) != "" || strings.TrimSpace(o.Cursor) != ""
+}
+
+func EncodeAuditLogCursor(c AuditLogCursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func DecodeAuditLogCursor(token string) (AuditLogCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return AuditLogCursor{}, errors.New("cursor is invalid")
 	}
-	if lookbackSeconds > auditLogMaxLookbackSeconds {
-		return fmt.Errorf(
-			"lookbackSeconds too large: %d (max=%d)",
-			lookbackSeconds,
-			auditLogMaxLookbackSeconds,
-		)
+	var c AuditLogCursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return AuditLogCursor{}, errors.New("cursor is invalid")
 	}
+	normalizedTime, err := normalizeAuditLogTime(c.Time)
+	if err != nil {
+		return AuditLogCursor{}, errors.New("cursor is invalid")
+	}
+	c.Time = normalizedTime
+	return c, nil
+}
+
+func normalizeAuditLogTime(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return "", errors.New("time is required")
+	}
+	for _, layout := range auditLogTimeInputLayouts {
+		if t, err := time.Parse(layout, trimmed); err == nil {
+			return t.Format(auditLogTimeLayout), nil
+		}
+	}
+	return "", fmt.Errorf("invalid time %q (want YYYY-MM-DD HH:MM:SS[.ffffff])", trimmed)
+}
+
+type auditLogExportQuery struct {
+	// From holds everything after the select list.
+	From      string
+	Args      []any
+	RangeMode bool
+	Limit     int
+}
+
+func (q auditLogExportQuery) sql(columns []auditLogProjectionColumn) string {
+	if len(columns) == 0 {
+		return "SELECT * " + q.From
+	}
+	items := make([]string, len(columns))
+	for i, col := range columns {
+		switch {
+		case col.Source == "":
+			items[i] = "NULL AS " + quoteSchemaAuditIdentifier(col.Alias)
+		case col.Source != col.Alias:
+			items[i] = quoteSchemaAuditIdentifier(col.Source) + " AS " + quoteSchemaAuditIdentifier(col.Alias)
+		default:
+			items[i] = quoteSchemaAuditIdentifier(col.Source)
+		}
+	}
+	return "SELECT " + strings.Join(items, ", ") + " " + q.From
+}
+
+// buildAuditLogExportQuery builds the row selection against the columns of
+// schema. A zero schema assumes canonical column names.
+func buildAuditLogExportQuery(opts AuditLogExportOptions, schema AuditLogSchema) (auditLogExportQuery, error) {
+	limit := opts.Limit
 	if limit <= 0 {
 		limit = auditLogDefaultLimit
 	}
 	if limit > auditLogMaxLimit {
-		return fmt.Errorf("limit too large: %d (max=%d)", limit, auditLogMaxLimit)
+		return auditLogExportQuery{}, fmt.Errorf("limit too large: %d (max=%d)", limit, auditLogMaxLimit)
+	}
+	filterPredicates, filterArgs, err := opts.Filters.predicates(schema)
+	if err != nil {
+		return auditLogExportQuery{}, err
+	}
+	column := func(canonical string) (string, error) {
+		source, ok := schema.sourceColumn(canonical)
+		if !ok {
+			return "", fmt.Errorf("unexpected audit_log schema: missing %s", canonical)
+		}
+		return quoteSchemaAuditIdentifier(source), nil
+	}
+	timeCol, err := column(auditLogTimeColumn)
+	if err != nil {
+		return auditLogExportQuery{}, err
+	}
+
+	if !opts.rangeMode() {
+		lookbackSeconds := opts.LookbackSeconds
+		if lookbackSeconds <= 0 {
+			lookbackSeconds = auditLogDefaultLookbackSeconds
+		}
+		if lookbackSeconds > auditLogMaxLookbackSeconds {
+			return auditLogExportQuery{}, fmt.Errorf(
+				"lookbackSeconds too large: %d (max=%d)",
+				lookbackSeconds,
+				auditLogMaxLookbackSeconds,
+			)
+		}
+		predicates := append([]string{
+			fmt.Sprintf("%s >= DATE_SUB(NOW(), INTERVAL %d SECOND)", timeCol, lookbackSeconds),
+			timeCol + " <= NOW()",
+		}, filterPredicates...)
+		return auditLogExportQuery{
+			From: fmt.Sprintf(
+				"FROM `__internal_schema`.`audit_log` "+
+					"WHERE %s "+
+					"ORDER BY %s DESC LIMIT %d",
+				strings.Join(predicates, " AND "),
+				timeCol,
+				limit,
+			),
+			Args:  filterArgs,
+			Limit: limit,
+		}, nil
+	}
+
+	if opts.LookbackSeconds > 0 {
+		return auditLogExportQuery{}, errors.New("lookbackSeconds cannot be combined with from/to/cursor")
+	}
+	queryIDCol, err := column(auditLogQueryIDColumn)
+	if err != nil {
+		return auditLogExportQuery{}, err
+	}
+	// Older FEs leave query_id NULL for some statements; page on '' instead so
+	// the cursor can always step past such rows.
+	queryIDKey := "COALESCE(" + queryIDCol + ", '')"
+	predicates := make([]string, 0, 3+len(filterPredicates))
+	args := make([]any, 0, 4+len(filterArgs))
+	var from, to string
+	if strings.TrimSpace(opts.From) != "" {
+		v, err := normalizeAuditLogTime(opts.From)
+		if err != nil {
+			return auditLogExportQuery{}, fmt.Errorf("from: %w", err)
+		}
+		from = v
+		predicates = append(predicates, timeCol+" >= ?")
+		args = append(args, from)
+	}
+	if strings.TrimSpace(opts.To) != "" {
+		v, err := normalizeAuditLogTime(opts.To)
+		if err != nil {
+			return auditLogExportQuery{}, fmt.Errorf("to: %w", err)
+		}
+		to = v
+		predicates = append(predicates, timeCol+" < ?")
+		args = append(args, to)
+	} else {
+		predicates = append(predicates, timeCol+" <= NOW()")
+	}
+	if from != "" && to != "" && from >= to {
+		return auditLogExportQuery{}, errors.New("from must be earlier than to")
+	}
+	if strings.TrimSpace(opts.Cursor) != "" {
+		cursor, err := DecodeAuditLogCursor(opts.Cursor)
+		if err != nil {
+			return auditLogExportQuery{}, err
+		}
+		predicates = append(predicates, fmt.Sprintf(
+			"(%[1]s > ? OR (%[1]s = ? AND %[2]s > ?))",
+			timeCol,
+			queryIDKey,
+		))
+		args = append(args, cursor.Time, cursor.Time, cursor.QueryID)
+	}
+	predicates = append(predicates, filterPredicates...)
+	args = append(args, filterArgs...)
+
+	// Fetch one extra row to tell whether another page exists.
+	return auditLogExportQuery{
+		From: fmt.Sprintf(
+			"FROM `__internal_schema`.`audit_log` "+
+				"WHERE %s "+
+				"ORDER BY %s ASC, %s ASC LIMIT %d",
+			strings.Join(predicates, " AND "),
+			timeCol,
+			queryIDKey,
+			limit+1,
+		),
+		Args:      args,
+		RangeMode: true,
+		Limit:     limit,
+	}, nil
+}
+
+func StreamAuditLogExport(
+	ctx context.Context,
+	cfg ConnConfig,
+	opts AuditLogExportOptions,
+	w io.Writer,
+) (AuditLogExportResult, error) {
+	format, err := NormalizeAuditLogExportFormat(opts.Format)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	// Validate the options before connecting; the query is rebuilt once the
+	// live schema is known.
+	q, err := buildAuditLogExportQuery(opts, AuditLogSchema{})
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	redactor, err := newSQLRedactor(opts.Redact, opts.RedactSalt)
+	if err != nil {
+		return AuditLogExportResult{}, err
 	}
 
-	db, err := openAndPing(ctx, cfg)
+	db, err := openAuditLogDB(ctx, cfg)
 	if err != nil {
-		return err
+		return AuditLogExportResult{}, err
 	}
 	defer db.Close()
 
-	q := fmt.Sprintf(
-		"SELECT * FROM `__internal_schema`.`audit_log` "+
-			"WHERE `time` >= DATE_SUB(NOW(), INTERVAL %d SECOND) AND `time` <= NOW() "+
-			"ORDER BY `time` DESC LIMIT %d",
-		lookbackSeconds,
-		limit,
-	)
-	rows, err := db.QueryContext(ctx, q)
+	// Without projection or normalization the schema descriptor is best effort:
+	// plain SELECT * exports keep working when information_schema.columns is unavailable.
+	var projection []auditLogProjectionColumn
+	available, err := listAuditLogColumns(ctx, db)
+	switch {
+	case err == nil:
+		schema := describeAuditLogSchema(available)
+		schema.Normalized = opts.Normalize
+		q, err = buildAuditLogExportQuery(opts, schema)
+		if err != nil {
+			return AuditLogExportResult{}, err
+		}
+		projection, err = resolveAuditLogProjection(opts.Columns, schema, opts.Normalize, q.RangeMode)
+		if err != nil {
+			return AuditLogExportResult{}, err
+		}
+		if opts.OnSchema != nil {
+			opts.OnSchema(schema)
+		}
+	case len(opts.Columns) > 0 || opts.Normalize:
+		return AuditLogExportResult{}, err
+	}
+
+	rows, err := db.QueryContext(ctx, q.sql(projection), q.Args...)
 	if err != nil {
-		return err
+		return AuditLogExportResult{}, err
 	}
 	defer rows.Close()
 
 	cols, err := rows.Columns()
 	if err != nil {
-		return err
+		return AuditLogExportResult{}, err
 	}
 	if len(cols) == 0 {
-		return errors.New("unexpected audit_log columns: empty")
+		return AuditLogExportResult{}, errors.New("unexpected audit_log columns: empty")
 	}
 	outCols := cols
+	timeIdx, queryIDIdx, stmtIdx := -1, -1, -1
+	for i, col := range cols {
+		canonical, _ := canonicalAuditLogColumnName(col)
+		switch canonical {
+		case auditLogTimeColumn:
+			timeIdx = i
+		case auditLogQueryIDColumn:
+			queryIDIdx = i
+		case auditLogStmtColumn:
+			stmtIdx = i
+		}
+	}
+	if q.RangeMode && (timeIdx < 0 || queryIDIdx < 0) {
+		return AuditLogExportResult{}, errors.New("unexpected audit_log columns: missing time or query_id")
+	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	encoder, err := newAuditLogRowEncoder(format, outCols, colTypes)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
 
 	raw := make([]any, len(cols))
 	ptrs := make([]any, len(cols))
@@ -76,40 +356,90 @@ func StreamAuditLogOutfileTSVLookback(
 		ptrs[i] = &raw[i]
 	}
 
-	if !rows.Next() {
+	hasRow := rows.Next()
+	if !hasRow {
 		if err := rows.Err(); err != nil {
-			return err
+			return AuditLogExportResult{}, err
+		}
+		if !q.RangeMode {
+			return AuditLogExportResult{}, errors.New("no audit_log rows found in the selected lookback window")
 		}
-		return errors.New("no audit_log rows found in the selected lookback window")
 	}
 
 	bw := bufio.NewWriterSize(w, 256*1024)
-	if _, err := bw.WriteString(strings.Join(outCols, "\t") + "\n"); err != nil {
-		return err
+	if err := encoder.writeHeader(bw); err != nil {
+		return AuditLogExportResult{}, err
 	}
 
-	row := make([]string, len(outCols))
-	for {
+	result := AuditLogExportResult{NextCursor: strings.TrimSpace(opts.Cursor)}
+	for hasRow {
 		if err := ctx.Err(); err != nil {
-			return err
+			return result, err
+		}
+		if result.Rows >= q.Limit {
+			result.HasMore = true
+			break
 		}
 		if err := rows.Scan(ptrs...); err != nil {
-			return err
+			return result, err
 		}
-		for i := 0; i < len(outCols); i++ {
-			row[i] = formatOutfileField(raw[i])
+		if redactor != nil && stmtIdx >= 0 {
+			raw[stmtIdx] = redactAuditLogStmt(redactor, raw[stmtIdx])
 		}
-		if _, err := bw.WriteString(strings.Join(row, "\t") + "\n"); err != nil {
-			return err
+		if err := encoder.writeRow(bw, raw); err != nil {
+			return result, err
 		}
-		if !rows.Next() {
-			break
+		result.Rows++
+		if q.RangeMode {
+			result.NextCursor = auditLogRowCursor(raw[timeIdx], raw[queryIDIdx])
 		}
+		hasRow = rows.Next()
 	}
 	if err := rows.Err(); err != nil {
-		return err
+		return result, err
+	}
+	if err := encoder.finish(bw); err != nil {
+		return result, err
+	}
+	return result, bw.Flush()
+}
+
+// auditLogRowCursor encodes the position of a scanned row. A NULL query_id
+// maps to "", matching the COALESCE in the range-mode ORDER BY.
+func auditLogRowCursor(rawTime any, rawQueryID any) string {
+	return EncodeAuditLogCursor(AuditLogCursor{
+		Time:    formatAuditLogCursorTime(rawTime),
+		QueryID: formatAuditLogCursorValue(rawQueryID),
+	})
+}
+
+func formatAuditLogCursorTime(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(auditLogTimeLayout)
+	}
+	return formatAuditLogCursorValue(v)
+}
+
+func formatAuditLogCursorValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func redactAuditLogStmt(r *sqlRedactor, v any) any {
+	switch x := v.(type) {
+	case []byte:
+		return []byte(r.redact(string(x)))
+	case string:
+		return r.redact(x)
+	default:
+		return v
 	}
-	return bw.Flush()
 }
 
 func formatOutfileField(v any) string {
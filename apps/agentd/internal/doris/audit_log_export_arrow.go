@@ -0,0 +1,307 @@
+package doris
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+)
+
+// Arrow IPC constants from Message.fbs and Schema.fbs.
+const (
+	arrowMetadataV5         = 4
+	arrowHeaderSchema       = 1
+	arrowHeaderRecordBatch  = 3
+	arrowTypeInt            = 2
+	arrowTypeFloatingPoint  = 3
+	arrowTypeUtf8           = 5
+	arrowTypeBool           = 6
+	arrowPrecisionDouble    = 2
+	arrowContinuationMarker = 0xFFFFFFFF
+)
+
+// auditLogArrowEncoder writes an Arrow IPC stream: the schema message, one
+// record batch per auditLogColumnarBatch and the end-of-stream marker. Every
+// column is nullable; its type comes from the column kind.
+type auditLogArrowEncoder struct {
+	cols  []string
+	batch *auditLogColumnarBatch
+	body  []byte
+}
+
+func newAuditLogArrowEncoder(cols []string, kinds []auditLogValueKind) *auditLogArrowEncoder {
+	return &auditLogArrowEncoder{cols: cols, batch: newAuditLogColumnarBatch(kinds)}
+}
+
+func (e *auditLogArrowEncoder) writeHeader(bw *bufio.Writer) error {
+	fields := make(flatVector, len(e.cols))
+	for i, name := range e.cols {
+		typeID, typ := arrowFieldType(e.batch.cols[i].typ)
+		fields[i] = flatTable{
+			flatRef(flatString(name)),
+			flatUint8(1), // nullable
+			flatUint8(typeID),
+			flatRef(typ),
+			{},
+			// Arrow C++ rejects a field without a children vector.
+			flatRef(flatVector{}),
+		}
+	}
+	schema := flatTable{flatInt16(0), flatRef(fields)} // little-endian
+	return writeArrowMessage(bw, arrowHeaderSchema, schema, nil)
+}
+
+func arrowFieldType(typ auditLogColumnarType) (uint8, flatTable) {
+	switch typ {
+	case auditLogColumnarInt64:
+		return arrowTypeInt, flatTable{flatInt32(64), flatUint8(1)} // signed
+	case auditLogColumnarFloat64:
+		return arrowTypeFloatingPoint, flatTable{flatInt16(arrowPrecisionDouble)}
+	case auditLogColumnarBool:
+		return arrowTypeBool, flatTable{}
+	default:
+		return arrowTypeUtf8, flatTable{}
+	}
+}
+
+func (e *auditLogArrowEncoder) writeRow(bw *bufio.Writer, raw []any) error {
+	e.batch.append(raw)
+	if e.batch.full() {
+		return e.flush(bw)
+	}
+	return nil
+}
+
+func (e *auditLogArrowEncoder) finish(bw *bufio.Writer) error {
+	if e.batch.rows > 0 {
+		if err := e.flush(bw); err != nil {
+			return err
+		}
+	}
+	var eos [8]byte
+	binary.LittleEndian.PutUint32(eos[:], arrowContinuationMarker)
+	_, err := bw.Write(eos[:])
+	return err
+}
+
+// flush writes the batch as one record batch message. Buffers are laid out in
+// field order (validity, then offsets and data or values), each padded to 8 bytes.
+func (e *auditLogArrowEncoder) flush(bw *bufio.Writer) error {
+	body := e.body[:0]
+	var nodes, buffers []byte
+	endBuffer := func(start int) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(start))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)-start))
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for i := range e.batch.cols {
+		c := &e.batch.cols[i]
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(e.batch.rows))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(c.nulls))
+
+		start := len(body)
+		if c.nulls > 0 {
+			body = appendAuditLogBitmap(body, c.valid)
+		}
+		endBuffer(start)
+
+		start = len(body)
+		switch c.typ {
+		case auditLogColumnarInt64:
+			for _, n := range c.ints {
+				body = binary.LittleEndian.AppendUint64(body, uint64(n))
+			}
+		case auditLogColumnarFloat64:
+			for _, f := range c.floats {
+				body = binary.LittleEndian.AppendUint64(body, math.Float64bits(f))
+			}
+		case auditLogColumnarBool:
+			body = appendAuditLogBitmap(body, c.bools)
+		default:
+			for _, off := range c.offsets {
+				body = binary.LittleEndian.AppendUint32(body, uint32(off))
+			}
+			endBuffer(start)
+			start = len(body)
+			body = append(body, c.data...)
+		}
+		endBuffer(start)
+	}
+	e.body = body
+
+	batch := flatTable{
+		flatInt64(int64(e.batch.rows)),
+		flatRef(flatStructs{count: len(e.batch.cols), data: nodes}),
+		flatRef(flatStructs{count: len(buffers) / 16, data: buffers}),
+	}
+	if err := writeArrowMessage(bw, arrowHeaderRecordBatch, batch, body); err != nil {
+		return err
+	}
+	e.batch.reset()
+	return nil
+}
+
+// writeArrowMessage writes one encapsulated message: the continuation marker,
+// the padded metadata length, the Message flatbuffer and the body.
+func writeArrowMessage(bw *bufio.Writer, headerType uint8, header flatTable, body []byte) error {
+	meta := buildFlatbuffer(flatTable{
+		flatInt16(arrowMetadataV5),
+		flatUint8(headerType),
+		flatRef(header),
+		flatInt64(int64(len(body))),
+	})
+	for len(meta)%8 != 0 {
+		meta = append(meta, 0)
+	}
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[:4], arrowContinuationMarker)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	if _, err := bw.Write(prefix[:]); err != nil {
+		return err
+	}
+	if _, err := bw.Write(meta); err != nil {
+		return err
+	}
+	_, err := bw.Write(body)
+	return err
+}
+
+// flatObject is a flatbuffer value referenced through an offset. The builder
+// writes front to back: a referenced object always follows its referrer, so
+// every unsigned offset points forward as the format requires.
+type flatObject interface {
+	writeFlat(b *flatBuilder) int
+}
+
+type flatBuilder struct {
+	buf []byte
+}
+
+func buildFlatbuffer(root flatObject) []byte {
+	b := &flatBuilder{buf: make([]byte, 4, 256)}
+	b.patchOffset(0, root.writeFlat(b))
+	return b.buf
+}
+
+func (b *flatBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *flatBuilder) patchOffset(at int, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+// flatField is one table field: a scalar of size bytes, or a reference. The
+// zero value leaves the field unset.
+type flatField struct {
+	size int
+	bits uint64
+	ref  flatObject
+}
+
+func flatUint8(v uint8) flatField    { return flatField{size: 1, bits: uint64(v)} }
+func flatInt16(v int16) flatField    { return flatField{size: 2, bits: uint64(uint16(v))} }
+func flatInt32(v int32) flatField    { return flatField{size: 4, bits: uint64(uint32(v))} }
+func flatInt64(v int64) flatField    { return flatField{size: 8, bits: uint64(v)} }
+func flatRef(o flatObject) flatField { return flatField{size: 4, ref: o} }
+
+// flatTable is a table whose field ids are the slice indexes.
+type flatTable []flatField
+
+func (t flatTable) writeFlat(b *flatBuilder) int {
+	// Inline fields follow the 4-byte vtable offset, widest first; the table
+	// starts 8-aligned so each scalar is naturally aligned in the buffer.
+	pos := make([]int, len(t))
+	size := 4
+	for _, width := range []int{8, 4, 2, 1} {
+		for i, f := range t {
+			if f.size != width {
+				continue
+			}
+			size = (size + width - 1) / width * width
+			pos[i] = size
+			size += width
+		}
+	}
+
+	b.align(2)
+	vtable := len(b.buf)
+	vtableSize := 4 + 2*len(t)
+	b.buf = append(b.buf, make([]byte, vtableSize)...)
+	b.align(8)
+	start := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+
+	binary.LittleEndian.PutUint16(b.buf[vtable:], uint16(vtableSize))
+	binary.LittleEndian.PutUint16(b.buf[vtable+2:], uint16(size))
+	binary.LittleEndian.PutUint32(b.buf[start:], uint32(int32(start-vtable)))
+	for i, f := range t {
+		if f.size == 0 {
+			continue
+		}
+		binary.LittleEndian.PutUint16(b.buf[vtable+4+2*i:], uint16(pos[i]))
+		at := b.buf[start+pos[i]:]
+		switch f.size {
+		case 1:
+			at[0] = byte(f.bits)
+		case 2:
+			binary.LittleEndian.PutUint16(at, uint16(f.bits))
+		case 4:
+			binary.LittleEndian.PutUint32(at, uint32(f.bits))
+		case 8:
+			binary.LittleEndian.PutUint64(at, f.bits)
+		}
+	}
+	for i, f := range t {
+		if f.ref != nil {
+			b.patchOffset(start+pos[i], f.ref.writeFlat(b))
+		}
+	}
+	return start
+}
+
+// flatVector is a vector of tables.
+type flatVector []flatObject
+
+func (v flatVector) writeFlat(b *flatBuilder) int {
+	b.align(4)
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, o := range v {
+		b.patchOffset(start+4+4*i, o.writeFlat(b))
+	}
+	return start
+}
+
+// flatStructs is a vector of count structs already encoded in data. The
+// structs used here hold int64 pairs, so the elements start 8-aligned.
+type flatStructs struct {
+	count int
+	data  []byte
+}
+
+func (v flatStructs) writeFlat(b *flatBuilder) int {
+	b.align(4)
+	if len(b.buf)%8 == 0 {
+		b.buf = append(b.buf, 0, 0, 0, 0)
+	}
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v.count))
+	b.buf = append(b.buf, v.data...)
+	return start
+}
+
+type flatString string
+
+func (s flatString) writeFlat(b *flatBuilder) int {
+	b.align(4)
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return start
+}
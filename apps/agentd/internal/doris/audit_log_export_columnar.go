@@ -0,0 +1,215 @@
+package doris
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Columnar exports buffer rows into batches: one Arrow record batch or one
+// Parquet row group each. The byte bound keeps long statements from growing a
+// batch past what int32 Arrow string offsets can address.
+const (
+	auditLogColumnarBatchRows  = 8192
+	auditLogColumnarBatchBytes = 64 << 20
+)
+
+type auditLogColumnarType int
+
+const (
+	auditLogColumnarString auditLogColumnarType = iota
+	auditLogColumnarInt64
+	auditLogColumnarFloat64
+	auditLogColumnarBool
+)
+
+// auditLogColumnarTypeOf maps a value kind to its column type. Times and
+// numbers that may not fit int64 or float64 are kept as text, as in NDJSON.
+func auditLogColumnarTypeOf(kind auditLogValueKind) auditLogColumnarType {
+	switch kind {
+	case auditLogValueInteger:
+		return auditLogColumnarInt64
+	case auditLogValueFloat:
+		return auditLogColumnarFloat64
+	case auditLogValueBool:
+		return auditLogColumnarBool
+	default:
+		return auditLogColumnarString
+	}
+}
+
+// auditLogColumn is one column of a batch. Null slots hold a zero value so the
+// Arrow buffers can be written as they are; Parquet skips them.
+type auditLogColumn struct {
+	typ     auditLogColumnarType
+	valid   []bool
+	nulls   int
+	ints    []int64
+	floats  []float64
+	bools   []bool
+	offsets []int32
+	data    []byte
+}
+
+type auditLogColumnarBatch struct {
+	cols []auditLogColumn
+	rows int
+}
+
+func newAuditLogColumnarBatch(kinds []auditLogValueKind) *auditLogColumnarBatch {
+	b := &auditLogColumnarBatch{cols: make([]auditLogColumn, len(kinds))}
+	for i, kind := range kinds {
+		b.cols[i].typ = auditLogColumnarTypeOf(kind)
+	}
+	b.reset()
+	return b
+}
+
+func (b *auditLogColumnarBatch) reset() {
+	b.rows = 0
+	for i := range b.cols {
+		c := &b.cols[i]
+		c.valid = c.valid[:0]
+		c.nulls = 0
+		c.ints = c.ints[:0]
+		c.floats = c.floats[:0]
+		c.bools = c.bools[:0]
+		c.offsets = append(c.offsets[:0], 0)
+		c.data = c.data[:0]
+	}
+}
+
+// full reports whether the batch should be written before the next row.
+func (b *auditLogColumnarBatch) full() bool {
+	if b.rows >= auditLogColumnarBatchRows {
+		return true
+	}
+	for i := range b.cols {
+		if len(b.cols[i].data) >= auditLogColumnarBatchBytes {
+			return true
+		}
+	}
+	return false
+}
+
+// append adds one scanned row. Values that do not parse as the column type
+// are written as null.
+func (b *auditLogColumnarBatch) append(raw []any) {
+	for i := range b.cols {
+		b.cols[i].append(raw[i])
+	}
+	b.rows++
+}
+
+func (c *auditLogColumn) append(v any) {
+	var ok bool
+	switch c.typ {
+	case auditLogColumnarInt64:
+		var n int64
+		n, ok = auditLogColumnarInt(v)
+		c.ints = append(c.ints, n)
+	case auditLogColumnarFloat64:
+		var f float64
+		f, ok = auditLogColumnarFloat(v)
+		c.floats = append(c.floats, f)
+	case auditLogColumnarBool:
+		var t bool
+		t, ok = auditLogColumnarBoolValue(v)
+		c.bools = append(c.bools, t)
+	default:
+		var s string
+		s, ok = auditLogColumnarText(v)
+		c.data = append(c.data, s...)
+		c.offsets = append(c.offsets, int32(len(c.data)))
+	}
+	c.valid = append(c.valid, ok)
+	if !ok {
+		c.nulls++
+	}
+}
+
+func auditLogColumnarText(v any) (string, bool) {
+	switch x := v.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(x), true
+	case string:
+		return x, true
+	case time.Time:
+		return x.Format(auditLogTimeLayout), true
+	default:
+		return fmt.Sprint(x), true
+	}
+}
+
+func auditLogColumnarInt(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	s, ok := auditLogColumnarText(v)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n, err == nil
+}
+
+func auditLogColumnarFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	s, ok := auditLogColumnarText(v)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
+func auditLogColumnarBoolValue(v any) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case int64:
+		return x != 0, true
+	}
+	s, ok := auditLogColumnarText(v)
+	if !ok {
+		return false, false
+	}
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true":
+		return true, true
+	case "0", "false":
+		return false, true
+	}
+	return false, false
+}
+
+// appendAuditLogBitmap appends bits LSB first, the bit order of both Arrow
+// validity bitmaps and Parquet PLAIN booleans.
+func appendAuditLogBitmap(dst []byte, bits []bool) []byte {
+	start := len(dst)
+	for i := 0; i < (len(bits)+7)/8; i++ {
+		dst = append(dst, 0)
+	}
+	for i, bit := range bits {
+		if bit {
+			dst[start+i/8] |= 1 << (i % 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,381 @@
+package doris
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+var columnarTestCols = []string{"query_id", "query_time", "cpu_share", "is_query"}
+
+var columnarTestKinds = []auditLogValueKind{
+	auditLogValueString,
+	auditLogValueInteger,
+	auditLogValueFloat,
+	auditLogValueBool,
+}
+
+func encodeColumnarTestRows(t *testing.T, encoder auditLogRowEncoder, rows [][]any) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	if err := encoder.writeHeader(bw); err != nil {
+		t.Fatalf("writeHeader: %v", err)
+	}
+	for _, row := range rows {
+		if err := encoder.writeRow(bw, row); err != nil {
+			t.Fatalf("writeRow: %v", err)
+		}
+	}
+	if err := encoder.finish(bw); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAuditLogArrowEncoderWritesIPCStream(t *testing.T) {
+	t.Parallel()
+
+	out := encodeColumnarTestRows(t, newAuditLogArrowEncoder(columnarTestCols, columnarTestKinds), [][]any{
+		{[]byte("q-1"), []byte("1234"), 0.5, []byte("1")},
+		{nil, []byte("n/a"), nil, []byte("0")},
+		{"q-3", int64(7), []byte("NaN"), true},
+	})
+
+	type message struct {
+		header flatTestTable
+		kind   uint8
+		body   []byte
+	}
+	var messages []message
+	for pos := 0; ; {
+		if pos%8 != 0 {
+			t.Fatalf("message at %d is not 8-aligned", pos)
+		}
+		if binary.LittleEndian.Uint32(out[pos:]) != arrowContinuationMarker {
+			t.Fatalf("missing continuation marker at %d", pos)
+		}
+		metaLen := int(binary.LittleEndian.Uint32(out[pos+4:]))
+		pos += 8
+		if metaLen == 0 {
+			if pos != len(out) {
+				t.Fatalf("trailing bytes after end-of-stream: %d", len(out)-pos)
+			}
+			break
+		}
+		msg := flatTestRoot(out[pos : pos+metaLen])
+		pos += metaLen
+		if got := msg.scalar(0, 2); got != arrowMetadataV5 {
+			t.Fatalf("unexpected metadata version: %d", got)
+		}
+		bodyLen := int(msg.scalar(3, 8))
+		if bodyLen%8 != 0 {
+			t.Fatalf("body length %d is not padded", bodyLen)
+		}
+		messages = append(messages, message{
+			header: msg.table(2),
+			kind:   uint8(msg.scalar(1, 1)),
+			body:   out[pos : pos+bodyLen],
+		})
+		pos += bodyLen
+	}
+	if len(messages) != 2 || messages[0].kind != arrowHeaderSchema || messages[1].kind != arrowHeaderRecordBatch {
+		t.Fatalf("want a schema and one record batch, got %d messages", len(messages))
+	}
+
+	fields := messages[0].header.tables(1)
+	wantTypes := []uint8{arrowTypeUtf8, arrowTypeInt, arrowTypeFloatingPoint, arrowTypeBool}
+	for i, field := range fields {
+		if field.str(0) != columnarTestCols[i] || field.scalar(1, 1) != 1 || uint8(field.scalar(2, 1)) != wantTypes[i] {
+			t.Fatalf("unexpected field %d: name=%q nullable=%d type=%d", i, field.str(0), field.scalar(1, 1), field.scalar(2, 1))
+		}
+		if len(field.tables(5)) != 0 {
+			t.Fatalf("field %d: unexpected children", i)
+		}
+	}
+	if got := fields[1].table(3).scalar(0, 4); got != 64 {
+		t.Fatalf("unexpected int bit width: %d", got)
+	}
+
+	batch, body := messages[1].header, messages[1].body
+	if batch.scalar(0, 8) != 3 {
+		t.Fatalf("unexpected batch length: %d", batch.scalar(0, 8))
+	}
+	nodes := batch.structs(1, 16)
+	wantNulls := []int{1, 1, 1, 0}
+	for i, node := range nodes {
+		if binary.LittleEndian.Uint64(node) != 3 || int(binary.LittleEndian.Uint64(node[8:])) != wantNulls[i] {
+			t.Fatalf("unexpected node %d: %v", i, node)
+		}
+	}
+	var buffers [][]byte
+	for _, b := range batch.structs(2, 16) {
+		offset, length := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+		if offset%8 != 0 {
+			t.Fatalf("buffer at %d is not 8-aligned", offset)
+		}
+		buffers = append(buffers, body[offset:offset+length])
+	}
+	if len(buffers) != 9 {
+		t.Fatalf("want 9 buffers, got %d", len(buffers))
+	}
+
+	// query_id: validity, offsets, data.
+	if buffers[0][0] != 0b101 {
+		t.Fatalf("unexpected query_id validity: %08b", buffers[0][0])
+	}
+	offsets := buffers[1]
+	if got := string(buffers[2][binary.LittleEndian.Uint32(offsets[8:]):binary.LittleEndian.Uint32(offsets[12:])]); got != "q-3" {
+		t.Fatalf("unexpected query_id[2]: %q", got)
+	}
+	// query_time: unparsable text becomes null.
+	if buffers[3][0] != 0b101 || int64(binary.LittleEndian.Uint64(buffers[4][16:])) != 7 {
+		t.Fatalf("unexpected query_time buffers: %v %v", buffers[3], buffers[4])
+	}
+	// cpu_share keeps NaN, which Arrow can represent.
+	if math.Float64frombits(binary.LittleEndian.Uint64(buffers[6])) != 0.5 ||
+		!math.IsNaN(math.Float64frombits(binary.LittleEndian.Uint64(buffers[6][16:]))) {
+		t.Fatalf("unexpected cpu_share values: %v", buffers[6])
+	}
+	// is_query has no nulls, so its validity buffer is omitted.
+	if len(buffers[7]) != 0 || buffers[8][0] != 0b101 {
+		t.Fatalf("unexpected is_query buffers: %v %v", buffers[7], buffers[8])
+	}
+}
+
+func TestAuditLogParquetEncoderWritesFile(t *testing.T) {
+	t.Parallel()
+
+	rows := make([][]any, auditLogColumnarBatchRows+2)
+	for i := range rows {
+		rows[i] = []any{[]byte("q"), int64(i), float64(i) / 2, i%2 == 0}
+	}
+	rows[1] = []any{nil, nil, nil, nil}
+	out := encodeColumnarTestRows(t, newAuditLogParquetEncoder(columnarTestCols, columnarTestKinds), rows)
+
+	if string(out[:4]) != parquetMagic || string(out[len(out)-4:]) != parquetMagic {
+		t.Fatalf("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(out[len(out)-8:]))
+	meta, n := thriftTestDecodeStruct(t, out[len(out)-8-footerLen:])
+	if n != footerLen {
+		t.Fatalf("footer length %d, decoded %d bytes", footerLen, n)
+	}
+	if meta[3] != int64(len(rows)) {
+		t.Fatalf("unexpected num_rows: %v", meta[3])
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != len(columnarTestCols)+1 || schema[0].(map[int16]any)[5] != int64(len(columnarTestCols)) {
+		t.Fatalf("unexpected schema root: %v", schema)
+	}
+	wantTypes := []int64{parquetTypeByteArray, parquetTypeInt64, parquetTypeDouble, parquetTypeBoolean}
+	for i, raw := range schema[1:] {
+		el := raw.(map[int16]any)
+		if string(el[4].([]byte)) != columnarTestCols[i] || el[1] != wantTypes[i] || el[3] != int64(parquetRepetitionOptional) {
+			t.Fatalf("unexpected schema element %d: %v", i, el)
+		}
+	}
+	if schema[1].(map[int16]any)[6] != int64(parquetConvertedUTF8) {
+		t.Fatalf("string column is missing the UTF8 annotation")
+	}
+
+	rowGroups := meta[4].([]any)
+	if len(rowGroups) != 2 || rowGroups[0].(map[int16]any)[3] != int64(auditLogColumnarBatchRows) ||
+		rowGroups[1].(map[int16]any)[3] != int64(2) {
+		t.Fatalf("unexpected row groups: %d", len(rowGroups))
+	}
+
+	// Read back the first row group's query_time page.
+	chunk := rowGroups[0].(map[int16]any)[1].([]any)[1].(map[int16]any)
+	colMeta := chunk[3].(map[int16]any)
+	offset := colMeta[9].(int64)
+	if chunk[2] != offset || colMeta[5] != int64(auditLogColumnarBatchRows) {
+		t.Fatalf("unexpected column chunk: %v", chunk)
+	}
+	header, headerLen := thriftTestDecodeStruct(t, out[offset:])
+	if colMeta[6] != int64(headerLen)+header[2].(int64) {
+		t.Fatalf("chunk size %v does not cover header %d and page %v", colMeta[6], headerLen, header[2])
+	}
+	if got := header[5].(map[int16]any)[1]; got != int64(auditLogColumnarBatchRows) {
+		t.Fatalf("unexpected page num_values: %v", got)
+	}
+	page := out[offset+int64(headerLen) : offset+int64(headerLen)+header[2].(int64)]
+	levelsLen := int(binary.LittleEndian.Uint32(page))
+	levels := page[4 : 4+levelsLen]
+	// RLE runs: one present, one null, then the rest present.
+	if !bytes.Equal(levels, []byte{1 << 1, 1, 1 << 1, 0, 0xfc, 0x7f, 1}) {
+		t.Fatalf("unexpected definition levels: %v", levels)
+	}
+	values := page[4+levelsLen:]
+	if len(values) != 8*(auditLogColumnarBatchRows-1) ||
+		binary.LittleEndian.Uint64(values) != 0 || binary.LittleEndian.Uint64(values[8:]) != 2 {
+		t.Fatalf("unexpected query_time values: %d bytes", len(values))
+	}
+}
+
+func TestAuditLogParquetEncoderWritesEmptyFile(t *testing.T) {
+	t.Parallel()
+
+	out := encodeColumnarTestRows(t, newAuditLogParquetEncoder(columnarTestCols, columnarTestKinds), nil)
+	footerLen := int(binary.LittleEndian.Uint32(out[len(out)-8:]))
+	if 4+footerLen+8 != len(out) {
+		t.Fatalf("unexpected file size %d for footer %d", len(out), footerLen)
+	}
+	meta, _ := thriftTestDecodeStruct(t, out[4:])
+	if meta[3] != int64(0) || len(meta[4].([]any)) != 0 {
+		t.Fatalf("unexpected metadata: %v", meta)
+	}
+}
+
+// flatTestTable reads a flatbuffer table at pos.
+type flatTestTable struct {
+	buf []byte
+	pos int
+}
+
+func flatTestRoot(buf []byte) flatTestTable {
+	return flatTestTable{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+func (t flatTestTable) field(id int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*id:]))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t flatTestTable) scalar(id int, size int) int64 {
+	at := t.field(id)
+	if at == 0 {
+		return 0
+	}
+	switch size {
+	case 1:
+		return int64(t.buf[at])
+	case 2:
+		return int64(int16(binary.LittleEndian.Uint16(t.buf[at:])))
+	case 4:
+		return int64(int32(binary.LittleEndian.Uint32(t.buf[at:])))
+	default:
+		return int64(binary.LittleEndian.Uint64(t.buf[at:]))
+	}
+}
+
+func (t flatTestTable) deref(at int) int {
+	return at + int(binary.LittleEndian.Uint32(t.buf[at:]))
+}
+
+func (t flatTestTable) table(id int) flatTestTable {
+	return flatTestTable{buf: t.buf, pos: t.deref(t.field(id))}
+}
+
+func (t flatTestTable) str(id int) string {
+	at := t.deref(t.field(id))
+	n := int(binary.LittleEndian.Uint32(t.buf[at:]))
+	if t.buf[at+4+n] != 0 {
+		panic("string is not null-terminated")
+	}
+	return string(t.buf[at+4 : at+4+n])
+}
+
+func (t flatTestTable) tables(id int) []flatTestTable {
+	at := t.deref(t.field(id))
+	out := make([]flatTestTable, binary.LittleEndian.Uint32(t.buf[at:]))
+	for i := range out {
+		out[i] = flatTestTable{buf: t.buf, pos: t.deref(at + 4 + 4*i)}
+	}
+	return out
+}
+
+func (t flatTestTable) structs(id int, size int) [][]byte {
+	at := t.deref(t.field(id))
+	out := make([][]byte, binary.LittleEndian.Uint32(t.buf[at:]))
+	for i := range out {
+		start := at + 4 + size*i
+		if start%8 != 0 {
+			panic("struct is not 8-aligned")
+		}
+		out[i] = t.buf[start : start+size]
+	}
+	return out
+}
+
+// thriftTestDecodeStruct decodes a compact-protocol struct into field id ->
+// int64, []byte, []any or nested map, and returns the bytes consumed.
+func thriftTestDecodeStruct(t *testing.T, buf []byte) (map[int16]any, int) {
+	t.Helper()
+
+	pos := 0
+	varint := func() uint64 {
+		v, n := binary.Uvarint(buf[pos:])
+		if n <= 0 {
+			t.Fatalf("bad varint at %d", pos)
+		}
+		pos += n
+		return v
+	}
+	zigzag := func() int64 {
+		v := varint()
+		return int64(v>>1) ^ -int64(v&1)
+	}
+	var value func(typ byte) any
+	var decodeStruct func() map[int16]any
+	value = func(typ byte) any {
+		switch typ {
+		case 1, 2:
+			return typ == 1
+		case thriftI32, thriftI64:
+			return zigzag()
+		case thriftBinary:
+			n := int(varint())
+			pos += n
+			return buf[pos-n : pos]
+		case thriftList:
+			header := buf[pos]
+			pos++
+			n := int(header >> 4)
+			if n == 15 {
+				n = int(varint())
+			}
+			list := make([]any, n)
+			for i := range list {
+				list[i] = value(header & 0x0f)
+			}
+			return list
+		case thriftStruct:
+			return decodeStruct()
+		}
+		t.Fatalf("unexpected thrift type %d at %d", typ, pos)
+		return nil
+	}
+	decodeStruct = func() map[int16]any {
+		fields := map[int16]any{}
+		var last int16
+		for {
+			header := buf[pos]
+			pos++
+			if header == 0 {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(zigzag())
+			}
+			fields[id] = value(header & 0x0f)
+			last = id
+		}
+	}
+	return decodeStruct(), pos
+}
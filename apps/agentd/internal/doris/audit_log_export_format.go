@@ -0,0 +1,232 @@
+package doris
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditLogExportFormatTSV     = "tsv"
+	AuditLogExportFormatNDJSON  = "ndjson"
+	AuditLogExportFormatArrow   = "arrow"
+	AuditLogExportFormatParquet = "parquet"
+)
+
+// NormalizeAuditLogExportFormat validates an export format name. Empty means TSV.
+// "arrow" is an Arrow IPC stream, not the random-access file (Feather v2) format.
+func NormalizeAuditLogExportFormat(format string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(format))
+	switch normalized {
+	case "", AuditLogExportFormatTSV:
+		return AuditLogExportFormatTSV, nil
+	case AuditLogExportFormatNDJSON, "jsonl":
+		return AuditLogExportFormatNDJSON, nil
+	case AuditLogExportFormatArrow, "arrow-ipc", "arrows":
+		return AuditLogExportFormatArrow, nil
+	case AuditLogExportFormatParquet:
+		return AuditLogExportFormatParquet, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s (want tsv, ndjson, arrow or parquet)", normalized)
+	}
+}
+
+// IsColumnarAuditLogExportFormat reports whether format is a binary columnar
+// format, which has no line structure to count rows by.
+func IsColumnarAuditLogExportFormat(format string) bool {
+	return format == AuditLogExportFormatArrow || format == AuditLogExportFormatParquet
+}
+
+type auditLogValueKind int
+
+const (
+	auditLogValueString auditLogValueKind = iota
+	// auditLogValueNumber is a number that may not fit int64 or float64
+	// (DECIMAL, LARGEINT); columnar formats keep its exact text.
+	auditLogValueNumber
+	auditLogValueInteger
+	auditLogValueFloat
+	auditLogValueBool
+)
+
+func auditLogValueKindOf(databaseTypeName string) auditLogValueKind {
+	switch strings.ToUpper(strings.TrimSpace(databaseTypeName)) {
+	case "TINYINT", "SMALLINT", "INT", "INTEGER", "MEDIUMINT", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT":
+		return auditLogValueInteger
+	case "FLOAT", "DOUBLE":
+		return auditLogValueFloat
+	case "LARGEINT", "UNSIGNED BIGINT", "DECIMAL", "DECIMALV3":
+		return auditLogValueNumber
+	case "BOOLEAN", "BOOL", "BIT":
+		return auditLogValueBool
+	default:
+		return auditLogValueString
+	}
+}
+
+// auditLogRowEncoder writes rows in one export format. finish is called once
+// after the last row; columnar formats flush their last batch and footer there.
+type auditLogRowEncoder interface {
+	writeHeader(bw *bufio.Writer) error
+	writeRow(bw *bufio.Writer, raw []any) error
+	finish(bw *bufio.Writer) error
+}
+
+func newAuditLogRowEncoder(
+	format string,
+	cols []string,
+	types []*sql.ColumnType,
+) (auditLogRowEncoder, error) {
+	kinds := make([]auditLogValueKind, len(cols))
+	for i := range kinds {
+		if i < len(types) && types[i] != nil {
+			kinds[i] = auditLogValueKindOf(types[i].DatabaseTypeName())
+		}
+	}
+	return newAuditLogRowEncoderWithKinds(format, cols, kinds)
+}
+
+func newAuditLogRowEncoderWithKinds(
+	format string,
+	cols []string,
+	kinds []auditLogValueKind,
+) (auditLogRowEncoder, error) {
+	switch format {
+	case AuditLogExportFormatTSV:
+		return &auditLogTSVEncoder{cols: cols, row: make([]string, len(cols))}, nil
+	case AuditLogExportFormatNDJSON:
+		return newAuditLogNDJSONEncoder(cols, kinds), nil
+	case AuditLogExportFormatArrow:
+		return newAuditLogArrowEncoder(cols, kinds), nil
+	case AuditLogExportFormatParquet:
+		return newAuditLogParquetEncoder(cols, kinds), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+type auditLogTSVEncoder struct {
+	cols []string
+	row  []string
+}
+
+func (e *auditLogTSVEncoder) writeHeader(bw *bufio.Writer) error {
+	_, err := bw.WriteString(strings.Join(e.cols, "\t") + "\n")
+	return err
+}
+
+func (e *auditLogTSVEncoder) writeRow(bw *bufio.Writer, raw []any) error {
+	for i := range e.row {
+		e.row[i] = formatOutfileField(raw[i])
+	}
+	_, err := bw.WriteString(strings.Join(e.row, "\t") + "\n")
+	return err
+}
+
+func (e *auditLogTSVEncoder) finish(*bufio.Writer) error {
+	return nil
+}
+
+// auditLogNDJSONEncoder writes one JSON object per row, keeping the column order
+// of the result set and emitting numeric and boolean columns as JSON scalars.
+type auditLogNDJSONEncoder struct {
+	keys  [][]byte
+	kinds []auditLogValueKind
+}
+
+func newAuditLogNDJSONEncoder(cols []string, kinds []auditLogValueKind) *auditLogNDJSONEncoder {
+	keys := make([][]byte, len(cols))
+	for i, col := range cols {
+		key, _ := json.Marshal(col)
+		keys[i] = append(key, ':')
+	}
+	return &auditLogNDJSONEncoder{keys: keys, kinds: kinds}
+}
+
+func (e *auditLogNDJSONEncoder) writeHeader(*bufio.Writer) error {
+	return nil
+}
+
+func (e *auditLogNDJSONEncoder) writeRow(bw *bufio.Writer, raw []any) error {
+	if err := bw.WriteByte('{'); err != nil {
+		return err
+	}
+	for i := range e.keys {
+		if i > 0 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.Write(e.keys[i]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(encodeAuditLogJSONValue(raw[i], e.kinds[i])); err != nil {
+			return err
+		}
+	}
+	_, err := bw.WriteString("}\n")
+	return err
+}
+
+func (e *auditLogNDJSONEncoder) finish(*bufio.Writer) error {
+	return nil
+}
+
+func encodeAuditLogJSONValue(v any, kind auditLogValueKind) []byte {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return []byte("null")
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	case time.Time:
+		s = x.Format(auditLogTimeLayout)
+	case bool:
+		return []byte(strconv.FormatBool(x))
+	case int64:
+		return strconv.AppendInt(nil, x, 10)
+	case float64:
+		return encodeAuditLogJSONFloat(x, 64)
+	case float32:
+		return encodeAuditLogJSONFloat(float64(x), 32)
+	default:
+		s = fmt.Sprint(x)
+	}
+
+	switch kind {
+	case auditLogValueNumber, auditLogValueInteger, auditLogValueFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return []byte("null")
+		}
+		if err == nil && json.Valid([]byte(s)) {
+			return []byte(s)
+		}
+	case auditLogValueBool:
+		switch strings.ToLower(s) {
+		case "1", "true":
+			return []byte("true")
+		case "0", "false":
+			return []byte("false")
+		}
+	}
+	out, _ := json.Marshal(s)
+	return out
+}
+
+// encodeAuditLogJSONFloat writes NaN and infinities, which JSON cannot
+// represent, as null.
+func encodeAuditLogJSONFloat(f float64, bitSize int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte("null")
+	}
+	return strconv.AppendFloat(nil, f, 'g', -1, bitSize)
+}
@@ -0,0 +1,104 @@
+package doris
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogNDJSONEncoderWritesTypedValues(t *testing.T) {
+	t.Parallel()
+
+	encoder := newAuditLogNDJSONEncoder(
+		[]string{"query_id", "query_time", "is_query", "stmt", "error_code"},
+		[]auditLogValueKind{
+			auditLogValueString,
+			auditLogValueNumber,
+			auditLogValueBool,
+			auditLogValueString,
+			auditLogValueNumber,
+		},
+	)
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	if err := encoder.writeHeader(bw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw := []any{[]byte("q-1"), []byte("1234"), []byte("1"), []byte("select \"a\"\n"), nil}
+	if err := encoder.writeRow(bw, raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"query_id":"q-1","query_time":1234,"is_query":true,"stmt":"select \"a\"\n","error_code":null}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\nwant: %q\ngot:  %q", want, buf.String())
+	}
+}
+
+func TestEncodeAuditLogJSONValueFallsBackToString(t *testing.T) {
+	t.Parallel()
+
+	if got := string(encodeAuditLogJSONValue([]byte("n/a"), auditLogValueNumber)); got != `"n/a"` {
+		t.Fatalf("unexpected number fallback: %s", got)
+	}
+	if got := string(encodeAuditLogJSONValue([]byte("maybe"), auditLogValueBool)); got != `"maybe"` {
+		t.Fatalf("unexpected bool fallback: %s", got)
+	}
+}
+
+func TestEncodeAuditLogJSONValueWritesNonFiniteAsNull(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []any{
+		math.NaN(),
+		math.Inf(1),
+		float32(math.Inf(-1)),
+		[]byte("NaN"),
+		[]byte("-Infinity"),
+		[]byte("1e400"),
+	} {
+		if got := string(encodeAuditLogJSONValue(v, auditLogValueNumber)); got != "null" {
+			t.Fatalf("%v: expected null, got %s", v, got)
+		}
+	}
+	if got := string(encodeAuditLogJSONValue(1.5, auditLogValueNumber)); got != "1.5" {
+		t.Fatalf("unexpected finite float: %s", got)
+	}
+}
+
+func TestNormalizeAuditLogExportFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "", want: AuditLogExportFormatTSV},
+		{in: "TSV", want: AuditLogExportFormatTSV},
+		{in: "ndjson", want: AuditLogExportFormatNDJSON},
+		{in: "jsonl", want: AuditLogExportFormatNDJSON},
+		{in: "parquet", want: AuditLogExportFormatParquet},
+		{in: "Arrow", want: AuditLogExportFormatArrow},
+		{in: "arrow-ipc", want: AuditLogExportFormatArrow},
+		{in: "feather", wantErr: "want tsv, ndjson, arrow or parquet"},
+		{in: "csv", wantErr: "want tsv, ndjson, arrow or parquet"},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeAuditLogExportFormat(tc.in)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("NormalizeAuditLogExportFormat(%q): expected error containing %q, got %v", tc.in, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("NormalizeAuditLogExportFormat(%q): want %q, got %q (err=%v)", tc.in, tc.want, got, err)
+		}
+	}
+}
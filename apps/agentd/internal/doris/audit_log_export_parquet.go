@@ -0,0 +1,320 @@
+package doris
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+)
+
+const parquetMagic = "PAR1"
+
+// Parquet enum values from parquet.thrift.
+const (
+	parquetTypeBoolean   = 0
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetRepetitionOptional = 1
+	parquetConvertedUTF8      = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageData           = 0
+)
+
+// Thrift compact protocol type ids.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// auditLogParquetEncoder writes a Parquet file with one row group per
+// auditLogColumnarBatch. Each column chunk is a single uncompressed PLAIN data
+// page (v1) of an OPTIONAL flat column; the footer is written by finish.
+type auditLogParquetEncoder struct {
+	cols      []string
+	batch     *auditLogColumnarBatch
+	offset    int64
+	rowGroups []parquetRowGroup
+	page      []byte
+}
+
+type parquetRowGroup struct {
+	rows   int64
+	chunks []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+func newAuditLogParquetEncoder(cols []string, kinds []auditLogValueKind) *auditLogParquetEncoder {
+	return &auditLogParquetEncoder{cols: cols, batch: newAuditLogColumnarBatch(kinds)}
+}
+
+func (e *auditLogParquetEncoder) writeHeader(bw *bufio.Writer) error {
+	return e.write(bw, []byte(parquetMagic))
+}
+
+func (e *auditLogParquetEncoder) write(bw *bufio.Writer, b []byte) error {
+	n, err := bw.Write(b)
+	e.offset += int64(n)
+	return err
+}
+
+func (e *auditLogParquetEncoder) writeRow(bw *bufio.Writer, raw []any) error {
+	e.batch.append(raw)
+	if e.batch.full() {
+		return e.flush(bw)
+	}
+	return nil
+}
+
+func (e *auditLogParquetEncoder) finish(bw *bufio.Writer) error {
+	if e.batch.rows > 0 {
+		if err := e.flush(bw); err != nil {
+			return err
+		}
+	}
+	footer := e.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+	return e.write(bw, footer)
+}
+
+// flush writes the batch as one row group.
+func (e *auditLogParquetEncoder) flush(bw *bufio.Writer) error {
+	rg := parquetRowGroup{rows: int64(e.batch.rows), chunks: make([]parquetColumnChunk, len(e.batch.cols))}
+	for i := range e.batch.cols {
+		page := appendParquetPage(e.page[:0], &e.batch.cols[i])
+		e.page = page
+
+		var h thriftCompactWriter
+		h.i32(1, parquetPageData)
+		h.i32(2, int32(len(page)))
+		h.i32(3, int32(len(page)))
+		h.beginStruct(5)
+		h.i32(1, int32(e.batch.rows))
+		h.i32(2, parquetEncodingPlain)
+		h.i32(3, parquetEncodingRLE)
+		h.i32(4, parquetEncodingRLE)
+		h.end()
+		h.end()
+
+		rg.chunks[i] = parquetColumnChunk{offset: e.offset, size: int64(len(h.buf) + len(page))}
+		if err := e.write(bw, h.buf); err != nil {
+			return err
+		}
+		if err := e.write(bw, page); err != nil {
+			return err
+		}
+	}
+	e.rowGroups = append(e.rowGroups, rg)
+	e.batch.reset()
+	return nil
+}
+
+// appendParquetPage encodes a v1 data page: the definition levels as a
+// length-prefixed RLE run list (bit width 1), then the non-null values.
+func appendParquetPage(page []byte, c *auditLogColumn) []byte {
+	page = append(page, 0, 0, 0, 0)
+	for i := 0; i < len(c.valid); {
+		j := i
+		for j < len(c.valid) && c.valid[j] == c.valid[i] {
+			j++
+		}
+		page = binary.AppendUvarint(page, uint64(j-i)<<1)
+		if c.valid[i] {
+			page = append(page, 1)
+		} else {
+			page = append(page, 0)
+		}
+		i = j
+	}
+	binary.LittleEndian.PutUint32(page, uint32(len(page)-4))
+
+	switch c.typ {
+	case auditLogColumnarInt64:
+		for i, n := range c.ints {
+			if c.valid[i] {
+				page = binary.LittleEndian.AppendUint64(page, uint64(n))
+			}
+		}
+	case auditLogColumnarFloat64:
+		for i, f := range c.floats {
+			if c.valid[i] {
+				page = binary.LittleEndian.AppendUint64(page, math.Float64bits(f))
+			}
+		}
+	case auditLogColumnarBool:
+		present := make([]bool, 0, len(c.bools)-c.nulls)
+		for i, t := range c.bools {
+			if c.valid[i] {
+				present = append(present, t)
+			}
+		}
+		page = appendAuditLogBitmap(page, present)
+	default:
+		for i := range c.valid {
+			if c.valid[i] {
+				value := c.data[c.offsets[i]:c.offsets[i+1]]
+				page = binary.LittleEndian.AppendUint32(page, uint32(len(value)))
+				page = append(page, value...)
+			}
+		}
+	}
+	return page
+}
+
+func parquetPhysicalType(typ auditLogColumnarType) int32 {
+	switch typ {
+	case auditLogColumnarInt64:
+		return parquetTypeInt64
+	case auditLogColumnarFloat64:
+		return parquetTypeDouble
+	case auditLogColumnarBool:
+		return parquetTypeBoolean
+	default:
+		return parquetTypeByteArray
+	}
+}
+
+// footer encodes the FileMetaData struct.
+func (e *auditLogParquetEncoder) footer() []byte {
+	var w thriftCompactWriter
+	w.i32(1, 1)
+
+	w.listBegin(2, thriftStruct, len(e.cols)+1)
+	w.begin()
+	w.binary(4, "schema")
+	w.i32(5, int32(len(e.cols)))
+	w.end()
+	for i, name := range e.cols {
+		typ := e.batch.cols[i].typ
+		w.begin()
+		w.i32(1, parquetPhysicalType(typ))
+		w.i32(3, parquetRepetitionOptional)
+		w.binary(4, name)
+		if typ == auditLogColumnarString {
+			w.i32(6, parquetConvertedUTF8)
+			w.beginStruct(10) // logicalType
+			w.beginStruct(1)  // STRING
+			w.end()
+			w.end()
+		}
+		w.end()
+	}
+
+	var rows int64
+	for _, rg := range e.rowGroups {
+		rows += rg.rows
+	}
+	w.i64(3, rows)
+
+	w.listBegin(4, thriftStruct, len(e.rowGroups))
+	for _, rg := range e.rowGroups {
+		var total int64
+		w.begin()
+		w.listBegin(1, thriftStruct, len(rg.chunks))
+		for i, chunk := range rg.chunks {
+			total += chunk.size
+			w.begin()
+			w.i64(2, chunk.offset)
+			w.beginStruct(3)
+			w.i32(1, parquetPhysicalType(e.batch.cols[i].typ))
+			w.listBegin(2, thriftI32, 2)
+			w.zigzag(parquetEncodingPlain)
+			w.zigzag(parquetEncodingRLE)
+			w.listBegin(3, thriftBinary, 1)
+			w.rawBinary(e.cols[i])
+			w.i32(4, parquetCodecUncompressed)
+			w.i64(5, rg.rows)
+			w.i64(6, chunk.size)
+			w.i64(7, chunk.size)
+			w.i64(9, chunk.offset)
+			w.end()
+			w.end()
+		}
+		w.i64(2, total)
+		w.i64(3, rg.rows)
+		w.end()
+	}
+	w.binary(6, "doris-dashboard agentd")
+	w.end()
+	return w.buf
+}
+
+// thriftCompactWriter encodes the Thrift compact protocol. begin and end
+// bracket a struct; the writer itself is the implicit top-level struct.
+type thriftCompactWriter struct {
+	buf    []byte
+	lastID int16
+	stack  []int16
+}
+
+func (w *thriftCompactWriter) field(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.zigzag(int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *thriftCompactWriter) zigzag(v int64) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(v<<1)^uint64(v>>63))
+}
+
+func (w *thriftCompactWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftCompactWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftCompactWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.rawBinary(s)
+}
+
+func (w *thriftCompactWriter) rawBinary(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *thriftCompactWriter) listBegin(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elemType)
+		return
+	}
+	w.buf = append(w.buf, 0xF0|elemType)
+	w.buf = binary.AppendUvarint(w.buf, uint64(n))
+}
+
+func (w *thriftCompactWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.begin()
+}
+
+func (w *thriftCompactWriter) begin() {
+	w.stack = append(w.stack, w.lastID)
+	w.lastID = 0
+}
+
+func (w *thriftCompactWriter) end() {
+	w.buf = append(w.buf, 0)
+	if n := len(w.stack); n > 0 {
+		w.lastID = w.stack[n-1]
+		w.stack = w.stack[:n-1]
+	}
+}
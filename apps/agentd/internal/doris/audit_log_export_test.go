@@ -0,0 +1,300 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildAuditLogExportQuery(t *testing.T) {
+	t.Parallel()
+
+	cursor := EncodeAuditLogCursor(AuditLogCursor{Time: "2024-01-01 10:00:00.123", QueryID: "q-1"})
+	cases := []struct {
+		name        string
+		opts        AuditLogExportOptions
+		wantContain []string
+		wantArgs    []any
+		wantRange   bool
+		wantErr     string
+	}{
+		{
+			name: "lookback",
+			opts: AuditLogExportOptions{LookbackSeconds: 60, Limit: 10},
+			wantContain: []string{
+				"INTERVAL 60 SECOND",
+				"ORDER BY `time` DESC LIMIT 10",
+			},
+		},
+		{
+			name: "absolute range",
+			opts: AuditLogExportOptions{From: "2024-01-01", To: "2024-01-02T00:00:00", Limit: 10},
+			wantContain: []string{
+				"`time` >= ? AND `time` < ?",
+				"ORDER BY `time` ASC, COALESCE(`query_id`, '') ASC LIMIT 11",
+			},
+			wantArgs:  []any{"2024-01-01 00:00:00.000000", "2024-01-02 00:00:00.000000"},
+			wantRange: true,
+		},
+		{
+			name: "cursor without upper bound",
+			opts: AuditLogExportOptions{Cursor: cursor, Limit: 5},
+			wantContain: []string{
+				"`time` <= NOW()",
+				"(`time` > ? OR (`time` = ? AND COALESCE(`query_id`, '') > ?))",
+			},
+			wantArgs:  []any{"2024-01-01 10:00:00.123000", "2024-01-01 10:00:00.123000", "q-1"},
+			wantRange: true,
+		},
+		{
+			name:    "lookback with cursor rejected",
+			opts:    AuditLogExportOptions{LookbackSeconds: 60, Cursor: cursor},
+			wantErr: "cannot be combined",
+		},
+		{
+			name:    "inverted range rejected",
+			opts:    AuditLogExportOptions{From: "2024-01-02", To: "2024-01-01"},
+			wantErr: "earlier than",
+		},
+		{
+			name:    "invalid time rejected",
+			opts:    AuditLogExportOptions{From: "yesterday"},
+			wantErr: "invalid time",
+		},
+		{
+			name:    "invalid cursor rejected",
+			opts:    AuditLogExportOptions{Cursor: "not a cursor"},
+			wantErr: "cursor is invalid",
+		},
+		{
+			name:    "limit too large",
+			opts:    AuditLogExportOptions{LookbackSeconds: 60, Limit: auditLogMaxLimit + 1},
+			wantErr: "limit too large",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := buildAuditLogExportQuery(tc.opts, AuditLogSchema{})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			query := got.sql(nil)
+			for _, want := range tc.wantContain {
+				if !strings.Contains(query, want) {
+					t.Fatalf("query missing %q:\n%s", want, query)
+				}
+			}
+			if got.RangeMode != tc.wantRange {
+				t.Fatalf("unexpected range mode: %v", got.RangeMode)
+			}
+			if len(got.Args) != len(tc.wantArgs) {
+				t.Fatalf("unexpected args: %v", got.Args)
+			}
+			for i := range tc.wantArgs {
+				if got.Args[i] != tc.wantArgs[i] {
+					t.Fatalf("unexpected arg %d: want %v, got %v", i, tc.wantArgs[i], got.Args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLogConnectionIgnoresSelectedCatalog(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []ConnConfig{
+		{Catalog: "hive"},
+		{Catalog: "hive", Database: "tpch"},
+		{Database: "tpch"},
+	} {
+		if got := connectDBName(auditLogConnConfig(cfg)); got != "" {
+			t.Fatalf("audit_log connection for %+v starts in %q, want the internal catalog default", cfg, got)
+		}
+	}
+}
+
+func TestAuditLogCursorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	token := EncodeAuditLogCursor(AuditLogCursor{Time: "2024-03-04 05:06:07", QueryID: "abc-123"})
+	got, err := DecodeAuditLogCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Time != "2024-03-04 05:06:07.000000" || got.QueryID != "abc-123" {
+		t.Fatalf("unexpected cursor: %+v", got)
+	}
+}
+
+func TestAuditLogCursorPagesPastNullQueryID(t *testing.T) {
+	t.Parallel()
+
+	// The last row of a page has a NULL query_id.
+	boundary := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	token := auditLogRowCursor(boundary, nil)
+	cursor, err := DecodeAuditLogCursor(token)
+	if err != nil {
+		t.Fatalf("cursor from a NULL query_id must decode: %v", err)
+	}
+	if cursor.Time != "2024-03-04 05:06:07.000000" || cursor.QueryID != "" {
+		t.Fatalf("unexpected cursor: %+v", cursor)
+	}
+	q, err := buildAuditLogExportQuery(AuditLogExportOptions{Cursor: token, Limit: 10}, AuditLogSchema{})
+	if err != nil {
+		t.Fatalf("next page: %v", err)
+	}
+	if !strings.Contains(q.From, "COALESCE(`query_id`, '') > ?") || q.Args[len(q.Args)-1] != "" {
+		t.Fatalf("next page must resume after the NULL query_id row: %s %v", q.From, q.Args)
+	}
+}
+
+func TestAuditLogExportFiltersPredicates(t *testing.T) {
+	t.Parallel()
+
+	predicates, args, err := AuditLogExportFilters{
+		User:           "alice",
+		ClientIP:       "10.0.0.1",
+		Database:       "tpch",
+		State:          "err",
+		StmtType:       "select",
+		MinQueryTimeMs: 1000,
+		MinScanBytes:   1 << 20,
+		SQLContains:    "lineitem",
+		SQLRegex:       `(?i)join\s+orders`,
+	}.predicates(AuditLogSchema{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := strings.Join(predicates, " AND ")
+	for _, want := range []string{
+		"`user` = ?",
+		"(`client_ip` = ? OR `client_ip` LIKE ?)",
+		"`db` = ?",
+		"`state` = ?",
+		"`stmt_type` = ?",
+		"`query_time` >= 1000",
+		"`scan_bytes` >= 1048576",
+		"LOCATE(?, `stmt`) > 0",
+		"`stmt` REGEXP ?",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("predicates missing %q: %s", want, got)
+		}
+	}
+	wantArgs := []any{"alice", "10.0.0.1", "10.0.0.1:%", "tpch", "ERR", "SELECT", "lineitem", `(?i)join\s+orders`}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	for i := range wantArgs {
+		if args[i] != wantArgs[i] {
+			t.Fatalf("unexpected arg %d: want %v, got %v", i, wantArgs[i], args[i])
+		}
+	}
+}
+
+func TestAuditLogExportFiltersClientIPPort(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		ip       string
+		wantLike string
+	}{
+		{ip: "10.0.0.1", wantLike: "10.0.0.1:%"},
+		{ip: "2001:db8::1", wantLike: "[2001:db8::1]:%"},
+		{ip: "::1", wantLike: "[::1]:%"},
+		{ip: "::ffff:10.0.0.1", wantLike: "[::ffff:10.0.0.1]:%"},
+	}
+	for _, tc := range cases {
+		_, args, err := AuditLogExportFilters{ClientIP: tc.ip}.predicates(AuditLogSchema{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.ip, err)
+		}
+		if len(args) != 2 || args[0] != tc.ip || args[1] != tc.wantLike {
+			t.Fatalf("%s: unexpected args: %v", tc.ip, args)
+		}
+	}
+}
+
+func TestAuditLogExportFiltersValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		filters AuditLogExportFilters
+		wantErr string
+	}{
+		{name: "invalid state", filters: AuditLogExportFilters{State: "FAILED"}, wantErr: "filters.state"},
+		{name: "invalid client ip", filters: AuditLogExportFilters{ClientIP: "not-an-ip"}, wantErr: "filters.clientIp"},
+		{name: "client ip with zone", filters: AuditLogExportFilters{ClientIP: "fe80::1%eth0"}, wantErr: "filters.clientIp"},
+		{name: "negative threshold", filters: AuditLogExportFilters{MinScanRows: -1}, wantErr: "filters.minScanRows"},
+		{name: "invalid regex", filters: AuditLogExportFilters{SQLRegex: "(unclosed"}, wantErr: "filters.sqlRegex"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := tc.filters.predicates(AuditLogSchema{})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAuditLogExportQueryUsesDetectedColumns(t *testing.T) {
+	t.Parallel()
+
+	// An older audit_log table that spells several columns differently.
+	schema := describeAuditLogSchema([]string{
+		"query_id", "time", "user", "clientIp", "database", "state", "query_time_ms", "scan_bytes", "statement",
+	})
+	q, err := buildAuditLogExportQuery(AuditLogExportOptions{
+		Cursor: EncodeAuditLogCursor(AuditLogCursor{Time: "2024-03-04 05:06:07", QueryID: "abc"}),
+		Limit:  10,
+		Filters: AuditLogExportFilters{
+			ClientIP:       "10.0.0.1",
+			Database:       "tpch",
+			MinQueryTimeMs: 1000,
+			SQLContains:    "lineitem",
+		},
+	}, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := q.sql(nil)
+	for _, want := range []string{
+		"(`time` > ? OR (`time` = ? AND COALESCE(`query_id`, '') > ?))",
+		"(`clientIp` = ? OR `clientIp` LIKE ?)",
+		"`database` = ?",
+		"`query_time_ms` >= 1000",
+		"LOCATE(?, `statement`) > 0",
+		"ORDER BY `time` ASC, COALESCE(`query_id`, '') ASC LIMIT 11",
+	} {
+		if !strings.Contains(query, want) {
+			t.Fatalf("query missing %q:\n%s", want, query)
+		}
+	}
+
+	_, err = buildAuditLogExportQuery(AuditLogExportOptions{
+		LookbackSeconds: 60,
+		Filters:         AuditLogExportFilters{MinScanRows: 10},
+	}, schema)
+	if err == nil || !strings.Contains(err.Error(), "filters.minScanRows is not supported") {
+		t.Fatalf("expected unsupported filter error, got %v", err)
+	}
+
+	_, err = buildAuditLogExportQuery(AuditLogExportOptions{From: "2024-01-01"}, describeAuditLogSchema([]string{"time", "stmt"}))
+	if err == nil || !strings.Contains(err.Error(), "missing query_id") {
+		t.Fatalf("expected missing query_id error, got %v", err)
+	}
+}
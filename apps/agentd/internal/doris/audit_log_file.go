@@ -0,0 +1,434 @@
+package doris
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	auditLogFileBaseName = "fe.audit.log"
+
+	// AuditLogSchemaVersionFile marks exports parsed from fe.audit.log files.
+	AuditLogSchemaVersionFile = "fe.audit.log"
+)
+
+var (
+	// auditLogFileRecordStart matches the first line of a record; statements may span lines.
+	auditLogFileRecordStart = regexp.MustCompile(
+		`^\x{FEFF}?\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}:\d{2}(?:[,.]\d{3,6})?(?:Z|[+-]\d{2}:?\d{2})?(?:\s+\[[^\]]+\])?\s+`,
+	)
+	auditLogFilePrefix = regexp.MustCompile(
+		`(?s)^[\x{FEFF}\s]*(\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}:\d{2}(?:[,.]\d{3,6})?(?:Z|[+-]\d{2}:?\d{2})?)\s+(.*)$`,
+	)
+	auditLogFileKey  = regexp.MustCompile(`\|([A-Za-z][A-Za-z0-9_().-]*)=`)
+	auditLogFileZone = regexp.MustCompile(`(?:Z|[+-]\d{2}:?\d{2})$`)
+)
+
+// auditLogFileKeyAliases maps fe.audit.log keys that do not reduce to a canonical
+// column name by dropping underscores.
+var auditLogFileKeyAliases = map[string]string{
+	"timestamp":          "time",
+	"time":               "query_time",
+	"time(ms)":           "query_time",
+	"client":             "client_ip",
+	"ctl":                "catalog",
+	"feip":               "frontend_ip",
+	"cloudclustername":   "compute_group",
+	"computegroupname":   "compute_group",
+	"cputime":            "cpu_time_ms",
+	"peakmemory":         "peak_memory_bytes",
+	"errmsg":             "error_message",
+	"workloadgroupname":  "workload_group",
+	"sqlhashvalue":       "sql_hash",
+	"scanbytesfromlocal": "scan_bytes_from_local_storage",
+}
+
+var auditLogFileCanonicalByCompactName = func() map[string]string {
+	m := make(map[string]string, len(auditLogCanonicalColumns))
+	for _, col := range auditLogCanonicalColumns {
+		m[strings.ReplaceAll(col, "_", "")] = col
+	}
+	return m
+}()
+
+var auditLogCanonicalKinds = map[string]auditLogValueKind{
+	"error_code":                     auditLogValueInteger,
+	"query_time":                     auditLogValueInteger,
+	"cpu_time_ms":                    auditLogValueInteger,
+	"scan_bytes":                     auditLogValueInteger,
+	"scan_rows":                      auditLogValueInteger,
+	"return_rows":                    auditLogValueInteger,
+	"shuffle_send_rows":              auditLogValueInteger,
+	"shuffle_send_bytes":             auditLogValueInteger,
+	"scan_bytes_from_local_storage":  auditLogValueInteger,
+	"scan_bytes_from_remote_storage": auditLogValueInteger,
+	"peak_memory_bytes":              auditLogValueInteger,
+	"stmt_id":                        auditLogValueInteger,
+	"is_query":                       auditLogValueBool,
+	"is_nereids":                     auditLogValueBool,
+}
+
+// AuditLogFileOptions selects records from fe.audit.log files in Dir, including
+// rotated and gzip-compressed ones. The output uses the canonical column set, so
+// it has the same shape as a normalized audit_log export.
+//
+// Times are FE wall-clock times as written in the log. Records are emitted in
+// file order, oldest file first; Limit keeps the oldest matching records.
+type AuditLogFileOptions struct {
+	Dir             string
+	LookbackSeconds int
+	From            string
+	To              string
+	Limit           int
+	Format          string
+	Columns         []string
+	Redact          string
+	RedactSalt      string
+	OnSchema        func(AuditLogSchema)
+}
+
+type auditLogFileWindow struct {
+	from string
+	to   string
+}
+
+func (w auditLogFileWindow) contains(t string) bool {
+	return (w.from == "" || t >= w.from) && (w.to == "" || t < w.to)
+}
+
+func buildAuditLogFileWindow(opts AuditLogFileOptions, now time.Time) (auditLogFileWindow, error) {
+	hasRange := strings.TrimSpace(opts.From) != "" || strings.TrimSpace(opts.To) != ""
+	if !hasRange {
+		lookbackSeconds := opts.LookbackSeconds
+		if lookbackSeconds <= 0 {
+			lookbackSeconds = auditLogDefaultLookbackSeconds
+		}
+		if lookbackSeconds > auditLogMaxLookbackSeconds {
+			return auditLogFileWindow{}, fmt.Errorf(
+				"lookbackSeconds too large: %d (max=%d)",
+				lookbackSeconds,
+				auditLogMaxLookbackSeconds,
+			)
+		}
+		return auditLogFileWindow{
+			from: now.Add(-time.Duration(lookbackSeconds) * time.Second).Format(auditLogTimeLayout),
+		}, nil
+	}
+	if opts.LookbackSeconds > 0 {
+		return auditLogFileWindow{}, errors.New("lookbackSeconds cannot be combined with from/to")
+	}
+	var window auditLogFileWindow
+	var err error
+	if strings.TrimSpace(opts.From) != "" {
+		if window.from, err = normalizeAuditLogTime(opts.From); err != nil {
+			return auditLogFileWindow{}, fmt.Errorf("from: %w", err)
+		}
+	}
+	if strings.TrimSpace(opts.To) != "" {
+		if window.to, err = normalizeAuditLogTime(opts.To); err != nil {
+			return auditLogFileWindow{}, fmt.Errorf("to: %w", err)
+		}
+	}
+	if window.from != "" && window.to != "" && window.from >= window.to {
+		return auditLogFileWindow{}, errors.New("from must be earlier than to")
+	}
+	return window, nil
+}
+
+// listAuditLogFiles returns fe.audit.log and its rotated siblings, oldest first.
+// Files last modified before the window starts cannot hold matching records.
+func listAuditLogFiles(dir string, window auditLogFileWindow) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		path    string
+		modTime time.Time
+	}
+	var from time.Time
+	if window.from != "" {
+		from, _ = time.ParseInLocation(auditLogTimeLayout, window.from, time.Local)
+	}
+	files := make([]candidate, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (name != auditLogFileBaseName && !strings.HasPrefix(name, auditLogFileBaseName+".")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if !from.IsZero() && info.ModTime().Before(from) {
+			continue
+		}
+		files = append(files, candidate{path: filepath.Join(dir, name), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.Before(files[j].modTime)
+		}
+		return files[i].path < files[j].path
+	})
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.path
+	}
+	return out, nil
+}
+
+func resolveAuditLogFileProjection(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return auditLogCanonicalColumns, nil
+	}
+	if len(requested) > auditLogMaxProjectedColumns {
+		return nil, fmt.Errorf("columns is invalid: too many columns (max=%d)", auditLogMaxProjectedColumns)
+	}
+	out := make([]string, 0, len(requested))
+	seen := make(map[string]struct{}, len(requested))
+	var unknown []string
+	for _, raw := range requested {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			return nil, errors.New("columns is invalid: empty column name")
+		}
+		canonical, ok := canonicalAuditLogColumnName(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if _, dup := seen[canonical]; dup {
+			continue
+		}
+		seen[canonical] = struct{}{}
+		out = append(out, canonical)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("columns is invalid: unknown audit_log columns: %s", strings.Join(unknown, ", "))
+	}
+	return out, nil
+}
+
+// StreamAuditLogFiles parses fe.audit.log files and streams the matching records
+// in the same shape as StreamAuditLogExport with Normalize set.
+func StreamAuditLogFiles(ctx context.Context, opts AuditLogFileOptions, w io.Writer) (AuditLogExportResult, error) {
+	format, err := NormalizeAuditLogExportFormat(opts.Format)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = auditLogDefaultLimit
+	}
+	if limit > auditLogMaxLimit {
+		return AuditLogExportResult{}, fmt.Errorf("limit too large: %d (max=%d)", limit, auditLogMaxLimit)
+	}
+	window, err := buildAuditLogFileWindow(opts, time.Now())
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	columns, err := resolveAuditLogFileProjection(opts.Columns)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	redactor, err := newSQLRedactor(opts.Redact, opts.RedactSalt)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	if strings.TrimSpace(opts.Dir) == "" {
+		return AuditLogExportResult{}, errors.New("dir is required")
+	}
+	files, err := listAuditLogFiles(opts.Dir, window)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+
+	kinds := make([]auditLogValueKind, len(columns))
+	for i, col := range columns {
+		kinds[i] = auditLogCanonicalKinds[col]
+	}
+	encoder, err := newAuditLogRowEncoderWithKinds(format, columns, kinds)
+	if err != nil {
+		return AuditLogExportResult{}, err
+	}
+	if opts.OnSchema != nil {
+		opts.OnSchema(AuditLogSchema{
+			Version:    AuditLogSchemaVersionFile,
+			Columns:    append([]string(nil), columns...),
+			Normalized: true,
+		})
+	}
+
+	bw := bufio.NewWriterSize(w, 256*1024)
+	if err := encoder.writeHeader(bw); err != nil {
+		return AuditLogExportResult{}, err
+	}
+	var result AuditLogExportResult
+	raw := make([]any, len(columns))
+	errStop := errors.New("stop")
+	for _, path := range files {
+		err := readAuditLogFileRecords(ctx, path, func(record map[string]string) error {
+			t, ok := record["time"]
+			if !ok || !window.contains(t) {
+				return nil
+			}
+			if result.Rows >= limit {
+				result.HasMore = true
+				return errStop
+			}
+			for i, col := range columns {
+				v, ok := record[col]
+				if !ok || v == "" {
+					raw[i] = nil
+					continue
+				}
+				if col == auditLogStmtColumn && redactor != nil {
+					v = redactor.redact(v)
+				}
+				raw[i] = v
+			}
+			if err := encoder.writeRow(bw, raw); err != nil {
+				return err
+			}
+			result.Rows++
+			return nil
+		})
+		if errors.Is(err, errStop) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	if err := encoder.finish(bw); err != nil {
+		return result, err
+	}
+	return result, bw.Flush()
+}
+
+// readAuditLogFileRecords calls fn with the canonical fields of every record in path.
+func readAuditLogFileRecords(ctx context.Context, path string, fn func(map[string]string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	br := bufio.NewReaderSize(r, 256*1024)
+	var block strings.Builder
+	flush := func() error {
+		if block.Len() == 0 {
+			return nil
+		}
+		record, ok := parseAuditLogFileRecord(block.String())
+		block.Reset()
+		if !ok {
+			return nil
+		}
+		return fn(record)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line != "" || readErr == nil {
+			if auditLogFileRecordStart.MatchString(line) {
+				if err := flush(); err != nil {
+					return err
+				}
+			} else if block.Len() > 0 {
+				block.WriteByte('\n')
+			}
+			block.WriteString(line)
+		}
+		if readErr == io.EOF {
+			return flush()
+		}
+	}
+}
+
+// parseAuditLogFileRecord extracts "|Key=value" pairs from one record. Values run
+// to the next key, so multi-line statements stay intact.
+func parseAuditLogFileRecord(block string) (map[string]string, bool) {
+	m := auditLogFilePrefix.FindStringSubmatch(block)
+	if m == nil {
+		return nil, false
+	}
+	body := m[2]
+	record := make(map[string]string, 32)
+	matches := auditLogFileKey.FindAllStringSubmatchIndex(body, -1)
+	for i, match := range matches {
+		valueEnd := len(body)
+		if i+1 < len(matches) {
+			valueEnd = matches[i+1][0]
+		}
+		value := strings.TrimSpace(body[match[1]:valueEnd])
+		if i+1 == len(matches) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, "|"))
+		}
+		canonical, ok := canonicalAuditLogFileKey(body[match[2]:match[3]])
+		if !ok {
+			continue
+		}
+		if _, dup := record[canonical]; !dup {
+			record[canonical] = value
+		}
+	}
+	if t, ok := normalizeAuditLogFileTime(record["time"]); ok {
+		record["time"] = t
+	} else if t, ok := normalizeAuditLogFileTime(m[1]); ok {
+		record["time"] = t
+	} else {
+		delete(record, "time")
+	}
+	return record, true
+}
+
+func canonicalAuditLogFileKey(key string) (string, bool) {
+	lower := strings.ToLower(key)
+	if canonical, ok := auditLogFileKeyAliases[lower]; ok {
+		return canonical, true
+	}
+	if canonical, ok := auditLogFileCanonicalByCompactName[strings.ReplaceAll(lower, "_", "")]; ok {
+		return canonical, true
+	}
+	return canonicalAuditLogColumnName(lower)
+}
+
+func normalizeAuditLogFileTime(value string) (string, bool) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return "", false
+	}
+	// Keep the FE wall-clock time; the audit_log table stores the same value.
+	s = auditLogFileZone.ReplaceAllString(s, "")
+	s = strings.Replace(s, ",", ".", 1)
+	t, err := normalizeAuditLogTime(s)
+	return t, err == nil
+}
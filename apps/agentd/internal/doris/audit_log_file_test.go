@@ -0,0 +1,131 @@
+package doris
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	auditLogFileRotated = "" +
+		"2024-01-01 09:59:59,000 [query] |Timestamp=2024-01-01 09:59:59.000|Client=10.0.0.1:5000|User=root|Db=tpch|State=EOF|Time(ms)=5|QueryId=q-0|IsQuery=true|Stmt=select 0\n" +
+		"2024-01-01 10:00:01,000 [query] |Timestamp=2024-01-01 10:00:01.000|Client=10.0.0.1:5000|User=root|Db=tpch|State=EOF|Time(ms)=7|QueryId=q-1|IsQuery=true|Stmt=select * from t\nwhere name = 'alice'|CpuTimeMS=3|PeakMemoryBytes=1024\n"
+	auditLogFileCurrent = "" +
+		"2024-01-01 10:00:02,500 [query] |Timestamp=2024-01-01 10:00:02.500|Client=10.0.0.2:5001|User=bob|Db=tpch|State=ERR|ErrorCode=1105|Time(ms)=9|QueryId=q-2|IsQuery=false|Stmt=insert into t values (1)|CloudClusterName=cg1\n" +
+		"2024-01-01 10:00:03,000 [query] |Timestamp=2024-01-01 10:00:03.000|User=carol|QueryId=q-3|Stmt=select 3\n"
+)
+
+func writeAuditLogFixtureDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write([]byte(auditLogFileRotated)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	files := []struct {
+		name    string
+		content []byte
+		modTime time.Time
+	}{
+		{name: "fe.audit.log.20240101-1.gz", content: gz.Bytes(), modTime: time.Now().Add(-time.Hour)},
+		{name: "fe.audit.log", content: []byte(auditLogFileCurrent), modTime: time.Now()},
+		{name: "fe.log", content: []byte("not an audit log\n"), modTime: time.Now()},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, f.content, 0o600); err != nil {
+			t.Fatalf("write fixture: %v", err)
+		}
+		if err := os.Chtimes(path, f.modTime, f.modTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestStreamAuditLogFilesNDJSON(t *testing.T) {
+	t.Parallel()
+
+	dir := writeAuditLogFixtureDir(t)
+	var schema AuditLogSchema
+	var buf bytes.Buffer
+	result, err := StreamAuditLogFiles(context.Background(), AuditLogFileOptions{
+		Dir:      dir,
+		From:     "2024-01-01 10:00:00",
+		To:       "2024-01-01 10:00:03",
+		Format:   AuditLogExportFormatNDJSON,
+		Columns:  []string{"time", "query_id", "client_ip", "state", "query_time", "is_query", "compute_group", "stmt"},
+		Redact:   SQLRedactionPlaceholder,
+		OnSchema: func(s AuditLogSchema) { schema = s },
+	}, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Rows != 2 || result.HasMore {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if schema.Version != AuditLogSchemaVersionFile || !schema.Normalized || len(schema.Columns) != 8 {
+		t.Fatalf("unexpected schema: %+v", schema)
+	}
+	want := `{"time":"2024-01-01 10:00:01.000000","query_id":"q-1","client_ip":"10.0.0.1:5000","state":"EOF","query_time":7,"is_query":true,"compute_group":null,"stmt":"select * from t\nwhere name = ?"}` + "\n" +
+		`{"time":"2024-01-01 10:00:02.500000","query_id":"q-2","client_ip":"10.0.0.2:5001","state":"ERR","query_time":9,"is_query":false,"compute_group":"cg1","stmt":"insert into t values (?)"}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\nwant: %s\ngot:  %s", want, buf.String())
+	}
+}
+
+func TestStreamAuditLogFilesTSVLimit(t *testing.T) {
+	t.Parallel()
+
+	dir := writeAuditLogFixtureDir(t)
+	var buf bytes.Buffer
+	result, err := StreamAuditLogFiles(context.Background(), AuditLogFileOptions{
+		Dir:     dir,
+		From:    "2024-01-01",
+		Limit:   2,
+		Columns: []string{"query_id", "user"},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Rows != 2 || !result.HasMore {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if got := buf.String(); got != "query_id\tuser\nq-0\troot\nq-1\troot\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestStreamAuditLogFilesRejectsUnknownColumns(t *testing.T) {
+	t.Parallel()
+
+	_, err := StreamAuditLogFiles(context.Background(), AuditLogFileOptions{
+		Dir:     t.TempDir(),
+		Columns: []string{"nope"},
+	}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "unknown audit_log columns") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseAuditLogFileRecordFallsBackToPrefixTime(t *testing.T) {
+	t.Parallel()
+
+	record, ok := parseAuditLogFileRecord("2024-05-06 07:08:09,123 INFO [query] |User=a|Time=12|Stmt=select 1|")
+	if !ok {
+		t.Fatalf("expected record")
+	}
+	if record["time"] != "2024-05-06 07:08:09.123000" || record["query_time"] != "12" || record["stmt"] != "select 1" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+}
@@ -0,0 +1,161 @@
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+const (
+	auditLogFilterValueMaxBytes = 256
+	auditLogSQLFilterMaxBytes   = 4096
+)
+
+var auditLogStates = map[string]struct{}{
+	"EOF": {},
+	"ERR": {},
+	"OK":  {},
+}
+
+// AuditLogExportFilters narrows the exported audit_log rows on the server side.
+// Zero values mean "no filter".
+type AuditLogExportFilters struct {
+	User           string
+	ClientIP       string
+	Database       string
+	Catalog        string
+	State          string
+	StmtType       string
+	MinQueryTimeMs int64
+	MinScanBytes   int64
+	MinScanRows    int64
+	SQLContains    string
+	SQLRegex       string
+}
+
+// predicates builds the WHERE conditions against the columns of schema, so
+// renamed columns on older audit_log tables are filtered under their live name.
+func (f AuditLogExportFilters) predicates(schema AuditLogSchema) ([]string, []any, error) {
+	predicates := make([]string, 0, 8)
+	args := make([]any, 0, 8)
+
+	column := func(canonical string, field string) (string, error) {
+		source, ok := schema.sourceColumn(canonical)
+		if !ok {
+			return "", fmt.Errorf("filters.%s is not supported: audit_log has no %s column", field, canonical)
+		}
+		return quoteSchemaAuditIdentifier(source), nil
+	}
+	addEquals := func(canonical string, field string, value string) error {
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			return nil
+		}
+		if len(trimmed) > auditLogFilterValueMaxBytes {
+			return fmt.Errorf("filters.%s is invalid: too long", field)
+		}
+		col, err := column(canonical, field)
+		if err != nil {
+			return err
+		}
+		predicates = append(predicates, col+" = ?")
+		args = append(args, trimmed)
+		return nil
+	}
+	if err := addEquals("user", "user", f.User); err != nil {
+		return nil, nil, err
+	}
+	if ip := strings.TrimSpace(f.ClientIP); ip != "" {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || addr.Zone() != "" {
+			return nil, nil, errors.New("filters.clientIp is invalid")
+		}
+		col, err := column("client_ip", "clientIp")
+		if err != nil {
+			return nil, nil, err
+		}
+		// audit_log stores client_ip with or without the client port depending on
+		// version. IPv6 addresses contain ':' themselves, so their port form is
+		// bracketed.
+		withPort := ip + ":%"
+		if !addr.Is4() {
+			withPort = "[" + ip + "]:%"
+		}
+		predicates = append(predicates, "("+col+" = ? OR "+col+" LIKE ?)")
+		args = append(args, ip, withPort)
+	}
+	if err := addEquals("db", "database", f.Database); err != nil {
+		return nil, nil, err
+	}
+	if err := addEquals("catalog", "catalog", f.Catalog); err != nil {
+		return nil, nil, err
+	}
+	if state := strings.ToUpper(strings.TrimSpace(f.State)); state != "" {
+		if _, ok := auditLogStates[state]; !ok {
+			return nil, nil, fmt.Errorf("filters.state is invalid: %q (want EOF, ERR or OK)", f.State)
+		}
+		col, err := column("state", "state")
+		if err != nil {
+			return nil, nil, err
+		}
+		predicates = append(predicates, col+" = ?")
+		args = append(args, state)
+	}
+	if err := addEquals("stmt_type", "stmtType", strings.ToUpper(f.StmtType)); err != nil {
+		return nil, nil, err
+	}
+
+	addMin := func(canonical string, field string, value int64) error {
+		if value < 0 {
+			return fmt.Errorf("filters.%s must not be negative", field)
+		}
+		if value == 0 {
+			return nil
+		}
+		col, err := column(canonical, field)
+		if err != nil {
+			return err
+		}
+		predicates = append(predicates, fmt.Sprintf("%s >= %d", col, value))
+		return nil
+	}
+	if err := addMin("query_time", "minQueryTimeMs", f.MinQueryTimeMs); err != nil {
+		return nil, nil, err
+	}
+	if err := addMin("scan_bytes", "minScanBytes", f.MinScanBytes); err != nil {
+		return nil, nil, err
+	}
+	if err := addMin("scan_rows", "minScanRows", f.MinScanRows); err != nil {
+		return nil, nil, err
+	}
+
+	if f.SQLContains != "" {
+		if len(f.SQLContains) > auditLogSQLFilterMaxBytes {
+			return nil, nil, errors.New("filters.sqlContains is invalid: too long")
+		}
+		col, err := column("stmt", "sqlContains")
+		if err != nil {
+			return nil, nil, err
+		}
+		predicates = append(predicates, "LOCATE(?, "+col+") > 0")
+		args = append(args, f.SQLContains)
+	}
+	if f.SQLRegex != "" {
+		if len(f.SQLRegex) > auditLogSQLFilterMaxBytes {
+			return nil, nil, errors.New("filters.sqlRegex is invalid: too long")
+		}
+		// Doris evaluates REGEXP with RE2, so Go's regexp syntax is a faithful pre-check.
+		if _, err := regexp.Compile(f.SQLRegex); err != nil {
+			return nil, nil, fmt.Errorf("filters.sqlRegex is invalid: %v", err)
+		}
+		col, err := column("stmt", "sqlRegex")
+		if err != nil {
+			return nil, nil, err
+		}
+		predicates = append(predicates, col+" REGEXP ?")
+		args = append(args, f.SQLRegex)
+	}
+	return predicates, args, nil
+}
@@ -0,0 +1,171 @@
+package doris
+
+import (
+	"sort"
+	"strings"
+)
+
+// auditLogCanonicalColumns is the column set every normalized export carries, in output order.
+// It is the union of audit_log columns across Doris 2.0, 2.1, 3.x and cloud mode.
+var auditLogCanonicalColumns = []string{
+	"query_id",
+	"time",
+	"client_ip",
+	"user",
+	"frontend_ip",
+	"catalog",
+	"db",
+	"state",
+	"error_code",
+	"error_message",
+	"query_time",
+	"cpu_time_ms",
+	"scan_bytes",
+	"scan_rows",
+	"return_rows",
+	"shuffle_send_rows",
+	"shuffle_send_bytes",
+	"scan_bytes_from_local_storage",
+	"scan_bytes_from_remote_storage",
+	"peak_memory_bytes",
+	"stmt_id",
+	"stmt_type",
+	"is_query",
+	"is_nereids",
+	"sql_hash",
+	"sql_digest",
+	"workload_group",
+	"compute_group",
+	"stmt",
+}
+
+// auditLogColumnAliases maps column names used by other Doris versions to canonical names.
+var auditLogColumnAliases = map[string]string{
+	"cloud_cluster_name": "compute_group",
+	"clientip":           "client_ip",
+	"client":             "client_ip",
+	"database":           "db",
+	"err_code":           "error_code",
+	"error_msg":          "error_message",
+	"err_msg":            "error_message",
+	"query_time_ms":      "query_time",
+	"cpu_time":           "cpu_time_ms",
+	"peak_memory":        "peak_memory_bytes",
+	"statement":          "stmt",
+}
+
+const (
+	AuditLogSchemaVersion20      = "2.0"
+	AuditLogSchemaVersion21      = "2.1"
+	AuditLogSchemaVersion3       = "3.x"
+	AuditLogSchemaVersionUnknown = "unknown"
+)
+
+// AuditLogSchema describes the live audit_log table and how it maps to the canonical column set.
+type AuditLogSchema struct {
+	// Version is inferred from marker columns; it is a schema generation, not the exact Doris release.
+	Version   string            `json:"version"`
+	CloudMode bool              `json:"cloudMode"`
+	Columns   []string          `json:"columns"`
+	Renamed   map[string]string `json:"renamed,omitempty"`
+	Missing   []string          `json:"missing,omitempty"`
+	Extra     []string          `json:"extra,omitempty"`
+	// Normalized reports whether the export was projected onto the canonical column set.
+	Normalized bool `json:"normalized"`
+
+	canonicalToSource map[string]string
+}
+
+func canonicalAuditLogColumnName(name string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := auditLogColumnAliases[key]; ok {
+		return canonical, true
+	}
+	for _, col := range auditLogCanonicalColumns {
+		if col == key {
+			return col, true
+		}
+	}
+	return "", false
+}
+
+func describeAuditLogSchema(columns []string) AuditLogSchema {
+	schema := AuditLogSchema{
+		Columns:           append([]string(nil), columns...),
+		canonicalToSource: make(map[string]string, len(columns)),
+	}
+	for _, col := range columns {
+		canonical, ok := canonicalAuditLogColumnName(col)
+		if !ok {
+			schema.Extra = append(schema.Extra, col)
+			continue
+		}
+		if prev, dup := schema.canonicalToSource[canonical]; dup {
+			// Prefer the exact canonical spelling over an alias when both exist;
+			// the column it displaces is reported as extra.
+			if !strings.EqualFold(col, canonical) {
+				schema.Extra = append(schema.Extra, col)
+				continue
+			}
+			delete(schema.Renamed, prev)
+			schema.Extra = append(schema.Extra, prev)
+		}
+		schema.canonicalToSource[canonical] = col
+		if col != canonical {
+			if schema.Renamed == nil {
+				schema.Renamed = make(map[string]string)
+			}
+			schema.Renamed[col] = canonical
+		}
+	}
+	for _, col := range auditLogCanonicalColumns {
+		if _, ok := schema.canonicalToSource[col]; !ok {
+			schema.Missing = append(schema.Missing, col)
+		}
+	}
+	sort.Strings(schema.Extra)
+
+	has := func(canonical string) bool {
+		_, ok := schema.canonicalToSource[canonical]
+		return ok
+	}
+	for _, col := range columns {
+		if strings.EqualFold(col, "cloud_cluster_name") {
+			schema.CloudMode = true
+		}
+	}
+	switch {
+	case !has("query_id") || !has("time"):
+		schema.Version = AuditLogSchemaVersionUnknown
+	case has("compute_group") || has("shuffle_send_bytes") || has("scan_bytes_from_local_storage"):
+		schema.Version = AuditLogSchemaVersion3
+	case has("workload_group"):
+		schema.Version = AuditLogSchemaVersion21
+	default:
+		schema.Version = AuditLogSchemaVersion20
+	}
+	return schema
+}
+
+// sourceColumn returns the live column backing a canonical column. When the
+// schema could not be detected it assumes the canonical name.
+func (s AuditLogSchema) sourceColumn(canonical string) (string, bool) {
+	if s.canonicalToSource == nil {
+		return canonical, true
+	}
+	col, ok := s.canonicalToSource[canonical]
+	return col, ok
+}
+
+// normalizedProjection projects the live columns onto the canonical set, filling
+// missing columns with NULL so every Doris version yields the same shape.
+func (s AuditLogSchema) normalizedProjection() []auditLogProjectionColumn {
+	out := make([]auditLogProjectionColumn, 0, len(auditLogCanonicalColumns))
+	for _, canonical := range auditLogCanonicalColumns {
+		out = append(out, auditLogProjectionColumn{
+			Source: s.canonicalToSource[canonical],
+			Alias:  canonical,
+		})
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package doris
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDescribeAuditLogSchemaVersions(t *testing.T) {
+	t.Parallel()
+
+	base := []string{
+		"query_id", "time", "client_ip", "user", "catalog", "db", "state", "error_code", "error_message",
+		"query_time", "scan_bytes", "scan_rows", "return_rows", "stmt_id", "is_query", "frontend_ip",
+		"cpu_time_ms", "sql_hash", "sql_digest", "peak_memory_bytes", "stmt",
+	}
+	cases := []struct {
+		name      string
+		columns   []string
+		want      string
+		wantCloud bool
+	}{
+		{name: "2.0", columns: base, want: AuditLogSchemaVersion20},
+		{name: "2.1", columns: append(append([]string{}, base...), "workload_group"), want: AuditLogSchemaVersion21},
+		{
+			name:    "3.x",
+			columns: append(append([]string{}, base...), "workload_group", "compute_group", "shuffle_send_bytes"),
+			want:    AuditLogSchemaVersion3,
+		},
+		{
+			name:      "cloud",
+			columns:   append(append([]string{}, base...), "workload_group", "cloud_cluster_name"),
+			want:      AuditLogSchemaVersion3,
+			wantCloud: true,
+		},
+		{name: "unknown", columns: []string{"stmt"}, want: AuditLogSchemaVersionUnknown},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := describeAuditLogSchema(tc.columns)
+			if got.Version != tc.want || got.CloudMode != tc.wantCloud {
+				t.Fatalf("unexpected schema: version=%s cloud=%v", got.Version, got.CloudMode)
+			}
+		})
+	}
+}
+
+func TestDescribeAuditLogSchemaMapsAliases(t *testing.T) {
+	t.Parallel()
+
+	got := describeAuditLogSchema([]string{"query_id", "time", "cloud_cluster_name", "custom_tag", "stmt"})
+	if !reflect.DeepEqual(got.Renamed, map[string]string{"cloud_cluster_name": "compute_group"}) {
+		t.Fatalf("unexpected renamed: %v", got.Renamed)
+	}
+	if !reflect.DeepEqual(got.Extra, []string{"custom_tag"}) {
+		t.Fatalf("unexpected extra: %v", got.Extra)
+	}
+	projection := got.normalizedProjection()
+	if len(projection) != len(auditLogCanonicalColumns) {
+		t.Fatalf("unexpected normalized projection size: %d", len(projection))
+	}
+	for _, col := range projection {
+		if col.Alias == "compute_group" && col.Source != "cloud_cluster_name" {
+			t.Fatalf("compute_group should read cloud_cluster_name, got %q", col.Source)
+		}
+		if col.Alias == "workload_group" && col.Source != "" {
+			t.Fatalf("workload_group should be NULL, got %q", col.Source)
+		}
+	}
+}
+
+func TestDescribeAuditLogSchemaPrefersCanonicalAfterAlias(t *testing.T) {
+	t.Parallel()
+
+	for _, columns := range [][]string{
+		{"query_id", "time", "database", "db"},
+		{"query_id", "time", "db", "database"},
+	} {
+		got := describeAuditLogSchema(columns)
+		if len(got.Renamed) != 0 {
+			t.Fatalf("%v: unexpected renamed: %v", columns, got.Renamed)
+		}
+		if !reflect.DeepEqual(got.Extra, []string{"database"}) {
+			t.Fatalf("%v: unexpected extra: %v", columns, got.Extra)
+		}
+		if source, _ := got.sourceColumn("db"); source != "db" {
+			t.Fatalf("%v: db should read db, got %q", columns, source)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package doris
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	auditLogTopTemplatesDefaultTopN = 20
+	auditLogTopTemplatesMaxTopN     = 500
+
+	AuditLogTemplateOrderCount          = "count"
+	AuditLogTemplateOrderTotalQueryTime = "totalQueryTime"
+	AuditLogTemplateOrderP99QueryTime   = "p99QueryTime"
+	AuditLogTemplateOrderTotalScanBytes = "totalScanBytes"
+	AuditLogTemplateOrderMaxPeakMemory  = "maxPeakMemory"
+	AuditLogTemplateOrderErrorRate      = "errorRate"
+)
+
+// AuditLogTopTemplatesOptions selects the audit_log window to aggregate.
+// The window is either LookbackSeconds or From/To, as for exports.
+type AuditLogTopTemplatesOptions struct {
+	LookbackSeconds int
+	From            string
+	To              string
+	Filters         AuditLogExportFilters
+	TopN            int
+	OrderBy         string
+	// MaxRows bounds how many audit_log rows are read; the newest rows win in lookback mode.
+	MaxRows int
+}
+
+type AuditLogTemplateStats struct {
+	Fingerprint        string  `json:"fingerprint"`
+	Template           string  `json:"template"`
+	Count              int64   `json:"count"`
+	ErrorCount         int64   `json:"errorCount"`
+	ErrorRate          float64 `json:"errorRate"`
+	TotalQueryTimeMs   int64   `json:"totalQueryTimeMs"`
+	P50QueryTimeMs     int64   `json:"p50QueryTimeMs"`
+	P95QueryTimeMs     int64   `json:"p95QueryTimeMs"`
+	P99QueryTimeMs     int64   `json:"p99QueryTimeMs"`
+	MaxQueryTimeMs     int64   `json:"maxQueryTimeMs"`
+	TotalScanBytes     int64   `json:"totalScanBytes"`
+	MaxScanBytes       int64   `json:"maxScanBytes"`
+	MaxPeakMemoryBytes int64   `json:"maxPeakMemoryBytes"`
+}
+
+// AuditLogTopTemplatesResult is aggregated from at most SampleLimit audit_log
+// rows. Truncated reports that the window held more rows than were sampled, in
+// which case the counts and percentiles cover SampledRows rows only.
+type AuditLogTopTemplatesResult struct {
+	SampledRows   int                     `json:"sampledRows"`
+	SampleLimit   int                     `json:"sampleLimit"`
+	Truncated     bool                    `json:"truncated"`
+	Warning       string                  `json:"warning,omitempty"`
+	TemplateCount int                     `json:"templateCount"`
+	OrderBy       string                  `json:"orderBy"`
+	Templates     []AuditLogTemplateStats `json:"templates"`
+}
+
+func normalizeAuditLogTemplateOrder(orderBy string) (string, error) {
+	trimmed := strings.TrimSpace(orderBy)
+	if trimmed == "" {
+		return AuditLogTemplateOrderCount, nil
+	}
+	for _, known := range []string{
+		AuditLogTemplateOrderCount,
+		AuditLogTemplateOrderTotalQueryTime,
+		AuditLogTemplateOrderP99QueryTime,
+		AuditLogTemplateOrderTotalScanBytes,
+		AuditLogTemplateOrderMaxPeakMemory,
+		AuditLogTemplateOrderErrorRate,
+	} {
+		if strings.EqualFold(trimmed, known) {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported orderBy: %s", trimmed)
+}
+
+type auditLogTemplateAccumulator struct {
+	stats      AuditLogTemplateStats
+	queryTimes []int64
+}
+
+type auditLogTemplateAggregator struct {
+	byFingerprint map[string]*auditLogTemplateAccumulator
+	rows          int
+}
+
+func newAuditLogTemplateAggregator() *auditLogTemplateAggregator {
+	return &auditLogTemplateAggregator{byFingerprint: make(map[string]*auditLogTemplateAccumulator)}
+}
+
+func (a *auditLogTemplateAggregator) add(stmt string, state string, queryTimeMs, scanBytes, peakMemoryBytes int64) {
+	a.rows++
+	template, fingerprint := FingerprintSQL(stmt)
+	acc, ok := a.byFingerprint[fingerprint]
+	if !ok {
+		acc = &auditLogTemplateAccumulator{stats: AuditLogTemplateStats{Fingerprint: fingerprint, Template: template}}
+		a.byFingerprint[fingerprint] = acc
+	}
+	s := &acc.stats
+	s.Count++
+	if strings.EqualFold(state, "ERR") {
+		s.ErrorCount++
+	}
+	s.TotalQueryTimeMs += queryTimeMs
+	s.TotalScanBytes += scanBytes
+	s.MaxScanBytes = max(s.MaxScanBytes, scanBytes)
+	s.MaxPeakMemoryBytes = max(s.MaxPeakMemoryBytes, peakMemoryBytes)
+	acc.queryTimes = append(acc.queryTimes, queryTimeMs)
+}
+
+func (a *auditLogTemplateAggregator) top(orderBy string, topN int) []AuditLogTemplateStats {
+	out := make([]AuditLogTemplateStats, 0, len(a.byFingerprint))
+	for _, acc := range a.byFingerprint {
+		s := acc.stats
+		sort.Slice(acc.queryTimes, func(i, j int) bool { return acc.queryTimes[i] < acc.queryTimes[j] })
+		s.P50QueryTimeMs = nearestRankPercentile(acc.queryTimes, 0.50)
+		s.P95QueryTimeMs = nearestRankPercentile(acc.queryTimes, 0.95)
+		s.P99QueryTimeMs = nearestRankPercentile(acc.queryTimes, 0.99)
+		s.MaxQueryTimeMs = acc.queryTimes[len(acc.queryTimes)-1]
+		s.ErrorRate = float64(s.ErrorCount) / float64(s.Count)
+		out = append(out, s)
+	}
+	key := func(s AuditLogTemplateStats) float64 {
+		switch orderBy {
+		case AuditLogTemplateOrderTotalQueryTime:
+			return float64(s.TotalQueryTimeMs)
+		case AuditLogTemplateOrderP99QueryTime:
+			return float64(s.P99QueryTimeMs)
+		case AuditLogTemplateOrderTotalScanBytes:
+			return float64(s.TotalScanBytes)
+		case AuditLogTemplateOrderMaxPeakMemory:
+			return float64(s.MaxPeakMemoryBytes)
+		case AuditLogTemplateOrderErrorRate:
+			return s.ErrorRate
+		default:
+			return float64(s.Count)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ki, kj := key(out[i]), key(out[j])
+		if ki != kj {
+			return ki > kj
+		}
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Fingerprint < out[j].Fingerprint
+	})
+	if len(out) > topN {
+		out = out[:topN]
+	}
+	return out
+}
+
+// nearestRankPercentile expects sorted values.
+func nearestRankPercentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// BuildAuditLogTopTemplates reads audit_log rows in the selected window, groups them
+// by SQL fingerprint and returns the top templates. Only the aggregate leaves the
+// agent, so a busy cluster can be summarized without exporting every row.
+func BuildAuditLogTopTemplates(
+	ctx context.Context,
+	cfg ConnConfig,
+	opts AuditLogTopTemplatesOptions,
+) (AuditLogTopTemplatesResult, error) {
+	orderBy, err := normalizeAuditLogTemplateOrder(opts.OrderBy)
+	if err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	topN := opts.TopN
+	if topN <= 0 {
+		topN = auditLogTopTemplatesDefaultTopN
+	}
+	if topN > auditLogTopTemplatesMaxTopN {
+		return AuditLogTopTemplatesResult{}, fmt.Errorf("topN too large: %d (max=%d)", topN, auditLogTopTemplatesMaxTopN)
+	}
+	maxRows := opts.MaxRows
+	if maxRows <= 0 {
+		maxRows = auditLogMaxLimit
+	}
+	queryOpts := AuditLogExportOptions{
+		LookbackSeconds: opts.LookbackSeconds,
+		Limit:           maxRows,
+		From:            opts.From,
+		To:              opts.To,
+		Filters:         opts.Filters,
+	}
+	// Validate the options before connecting; the query is rebuilt once the
+	// live schema is known.
+	if _, err := buildAuditLogExportQuery(queryOpts, AuditLogSchema{}); err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+
+	db, err := openAuditLogDB(ctx, cfg)
+	if err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	defer db.Close()
+
+	available, err := listAuditLogColumns(ctx, db)
+	if err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	schema := describeAuditLogSchema(available)
+	q, err := buildAuditLogExportQuery(queryOpts, schema)
+	if err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	projection := make([]auditLogProjectionColumn, 0, 5)
+	for _, canonical := range []string{"stmt", "state", "query_time", "scan_bytes", "peak_memory_bytes"} {
+		source := schema.canonicalToSource[canonical]
+		if source == "" && (canonical == "stmt" || canonical == "query_time") {
+			return AuditLogTopTemplatesResult{}, fmt.Errorf("unexpected audit_log schema: missing %s", canonical)
+		}
+		projection = append(projection, auditLogProjectionColumn{Source: source, Alias: canonical})
+	}
+
+	rows, err := db.QueryContext(ctx, q.sql(projection), q.Args...)
+	if err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	defer rows.Close()
+
+	agg := newAuditLogTemplateAggregator()
+	result := AuditLogTopTemplatesResult{OrderBy: orderBy}
+	raw := make([]any, len(projection))
+	ptrs := make([]any, len(projection))
+	for i := range raw {
+		ptrs[i] = &raw[i]
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return AuditLogTopTemplatesResult{}, err
+		}
+		if agg.rows >= q.Limit {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return AuditLogTopTemplatesResult{}, err
+		}
+		agg.add(
+			formatAuditLogCursorValue(raw[0]),
+			formatAuditLogCursorValue(raw[1]),
+			auditLogInt64Value(raw[2]),
+			auditLogInt64Value(raw[3]),
+			auditLogInt64Value(raw[4]),
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return AuditLogTopTemplatesResult{}, err
+	}
+	if !q.RangeMode && agg.rows >= q.Limit {
+		// Lookback mode has no look-ahead row, so a full page may hide older rows.
+		result.Truncated = true
+	}
+	result.SampledRows = agg.rows
+	result.SampleLimit = q.Limit
+	result.Warning = auditLogTopTemplatesSampleWarning(result.Truncated, q.RangeMode, q.Limit)
+	result.TemplateCount = len(agg.byFingerprint)
+	result.Templates = agg.top(orderBy, topN)
+	return result, nil
+}
+
+func auditLogTopTemplatesSampleWarning(truncated bool, rangeMode bool, limit int) string {
+	if !truncated {
+		return ""
+	}
+	which := "newest"
+	if rangeMode {
+		which = "oldest"
+	}
+	return fmt.Sprintf(
+		"Templates are computed from the %s %d rows in the window; narrow the window or raise maxRows to cover the rest.",
+		which,
+		limit,
+	)
+}
+
+func auditLogInt64Value(v any) int64 {
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	}
+	s := strings.TrimSpace(formatAuditLogCursorValue(v))
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int64(f)
+	}
+	return 0
+}
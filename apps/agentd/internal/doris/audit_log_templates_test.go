@@ -0,0 +1,85 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintSQLGroupsLiteralVariants(t *testing.T) {
+	t.Parallel()
+
+	a, fpA := FingerprintSQL("SELECT *  FROM t\n WHERE id IN (1, 2, 3) AND name = 'x' -- note\n;")
+	b, fpB := FingerprintSQL("SELECT * FROM t WHERE id IN (7) AND name = 'yy'")
+	if a != "SELECT * FROM t WHERE id IN (?) AND name = ?" {
+		t.Fatalf("unexpected template: %q", a)
+	}
+	if a != b || fpA != fpB || len(fpA) != 16 {
+		t.Fatalf("expected equal fingerprints: %q/%s vs %q/%s", a, fpA, b, fpB)
+	}
+	hinted, _ := FingerprintSQL("SELECT /*+ SET_VAR(parallel=4) */ 1 /* plain */ FROM t")
+	if hinted != "SELECT /*+ SET_VAR(parallel=4) */ ? FROM t" {
+		t.Fatalf("unexpected hinted template: %q", hinted)
+	}
+	if _, other := FingerprintSQL("SELECT * FROM u WHERE id IN (1)"); other == fpA {
+		t.Fatalf("expected different fingerprint for different table")
+	}
+}
+
+func TestAuditLogTemplateAggregatorTop(t *testing.T) {
+	t.Parallel()
+
+	agg := newAuditLogTemplateAggregator()
+	for i := int64(1); i <= 100; i++ {
+		agg.add("select * from a where id = "+string(rune('0'+i%10)), "EOF", i, 10, i*2)
+	}
+	agg.add("select * from b where k = 'v'", "ERR", 5000, 1000, 1)
+	agg.add("select * from b where k = 'w'", "EOF", 7000, 3000, 1)
+
+	byCount := agg.top(AuditLogTemplateOrderCount, 10)
+	if len(byCount) != 2 {
+		t.Fatalf("unexpected template count: %d", len(byCount))
+	}
+	a := byCount[0]
+	if a.Count != 100 || a.P50QueryTimeMs != 50 || a.P95QueryTimeMs != 95 || a.P99QueryTimeMs != 99 {
+		t.Fatalf("unexpected stats: %+v", a)
+	}
+	if a.MaxQueryTimeMs != 100 || a.TotalScanBytes != 1000 || a.MaxPeakMemoryBytes != 200 {
+		t.Fatalf("unexpected totals: %+v", a)
+	}
+
+	byP99 := agg.top(AuditLogTemplateOrderP99QueryTime, 1)
+	if len(byP99) != 1 || byP99[0].Template != "select * from b where k = ?" {
+		t.Fatalf("unexpected top by p99: %+v", byP99)
+	}
+	if byP99[0].ErrorCount != 1 || byP99[0].ErrorRate != 0.5 || byP99[0].MaxScanBytes != 3000 {
+		t.Fatalf("unexpected error stats: %+v", byP99[0])
+	}
+}
+
+func TestNormalizeAuditLogTemplateOrder(t *testing.T) {
+	t.Parallel()
+
+	if got, err := normalizeAuditLogTemplateOrder(""); err != nil || got != AuditLogTemplateOrderCount {
+		t.Fatalf("unexpected default: %q, %v", got, err)
+	}
+	if got, err := normalizeAuditLogTemplateOrder("TOTALQUERYTIME"); err != nil || got != AuditLogTemplateOrderTotalQueryTime {
+		t.Fatalf("unexpected normalized order: %q, %v", got, err)
+	}
+	if _, err := normalizeAuditLogTemplateOrder("cpu"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestAuditLogTopTemplatesSampleWarning(t *testing.T) {
+	t.Parallel()
+
+	if got := auditLogTopTemplatesSampleWarning(false, false, 100); got != "" {
+		t.Fatalf("expected no warning for a complete window, got %q", got)
+	}
+	if got := auditLogTopTemplatesSampleWarning(true, false, 100); !strings.Contains(got, "newest 100 rows") {
+		t.Fatalf("unexpected lookback warning: %q", got)
+	}
+	if got := auditLogTopTemplatesSampleWarning(true, true, 100); !strings.Contains(got, "oldest 100 rows") {
+		t.Fatalf("unexpected range warning: %q", got)
+	}
+}
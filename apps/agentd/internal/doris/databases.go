@@ -5,17 +5,28 @@ import (
 	"database/sql"
 	"errors"
 	"sort"
+	"strings"
 )
 
+type Catalog struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
 func ListDatabases(ctx context.Context, cfg ConnConfig) ([]string, error) {
+	query := "SHOW DATABASES"
+	if catalog := strings.TrimSpace(cfg.Catalog); catalog != "" {
+		query += " FROM " + quoteSchemaAuditIdentifier(catalog)
+	}
 	cfg.Database = ""
+	cfg.Catalog = ""
 	db, err := openAndPing(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	rows, err := db.QueryContext(ctx, "SHOW DATABASES")
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -46,3 +57,41 @@ func ListDatabases(ctx context.Context, cfg ConnConfig) ([]string, error) {
 	sort.Strings(databases)
 	return databases, nil
 }
+
+// ListCatalogs returns the catalogs visible to the user, internal first.
+func ListCatalogs(ctx context.Context, cfg ConnConfig) ([]Catalog, error) {
+	cfg.Database = ""
+	cfg.Catalog = ""
+	db, err := openAndPing(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, _, err := queryRowsAsStringMaps(ctx, db, "SHOW CATALOGS")
+	if err != nil {
+		return nil, err
+	}
+	return parseShowCatalogsRows(rows), nil
+}
+
+func parseShowCatalogsRows(rows []map[string]string) []Catalog {
+	catalogs := make([]Catalog, 0, len(rows))
+	for _, row := range rows {
+		name := strings.TrimSpace(firstNonEmptyValue(row, "catalogname", "catalog_name", "name"))
+		if name == "" {
+			continue
+		}
+		catalogs = append(catalogs, Catalog{
+			Name: name,
+			Type: strings.ToLower(strings.TrimSpace(firstNonEmptyValue(row, "type", "catalog_type"))),
+		})
+	}
+	sort.SliceStable(catalogs, func(i, j int) bool {
+		if (catalogs[i].Type == "internal") != (catalogs[j].Type == "internal") {
+			return catalogs[i].Type == "internal"
+		}
+		return catalogs[i].Name < catalogs[j].Name
+	})
+	return catalogs
+}
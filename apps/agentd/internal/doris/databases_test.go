@@ -0,0 +1,44 @@
+package doris
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConnectDBName(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		cfg  ConnConfig
+		want string
+	}{
+		{cfg: ConnConfig{}, want: ""},
+		{cfg: ConnConfig{Database: "tpch"}, want: "tpch"},
+		{cfg: ConnConfig{Catalog: "hive", Database: "tpch"}, want: "hive.tpch"},
+		{cfg: ConnConfig{Catalog: " hive "}, want: "hive.information_schema"},
+	}
+	for _, tc := range cases {
+		if got := connectDBName(tc.cfg); got != tc.want {
+			t.Fatalf("connectDBName(%+v) = %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
+
+func TestParseShowCatalogsRows(t *testing.T) {
+	t.Parallel()
+
+	rows := []map[string]string{
+		{"catalogid": "11", "catalogname": "iceberg", "type": "iceberg"},
+		{"catalogid": "10", "catalogname": "hive", "type": "hms"},
+		{"catalogid": "0", "catalogname": "internal", "type": "internal"},
+		{"catalogid": "12", "catalogname": " ", "type": "jdbc"},
+	}
+	want := []Catalog{
+		{Name: "internal", Type: "internal"},
+		{Name: "hive", Type: "hms"},
+		{Name: "iceberg", Type: "iceberg"},
+	}
+	if got := parseShowCatalogsRows(rows); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected catalogs: %+v", got)
+	}
+}
@@ -18,6 +18,9 @@ type ConnConfig struct {
 	User     string
 	Password string
 	Database string
+	// Catalog selects a multi-catalog (e.g. Hive or Iceberg) context. Empty
+	// means the session default, normally "internal".
+	Catalog string
 
 	ConnectTimeout time.Duration
 	ReadTimeout    time.Duration
@@ -40,9 +43,7 @@ func OpenDB(cfg ConnConfig) (*sql.DB, error) {
 	c.Addr = addr
 	c.User = cfg.User
 	c.Passwd = cfg.Password
-	if database := strings.TrimSpace(cfg.Database); database != "" {
-		c.DBName = database
-	}
+	c.DBName = connectDBName(cfg)
 	connectTimeout := cfg.ConnectTimeout
 	if connectTimeout <= 0 {
 		connectTimeout = 5 * time.Second
@@ -75,6 +76,21 @@ func OpenDB(cfg ConnConfig) (*sql.DB, error) {
 	return db, nil
 }
 
+// connectDBName returns the handshake database. Doris accepts "catalog.db"
+// there, so every pooled connection starts in the configured catalog; without
+// a database the catalog's own information_schema is used.
+func connectDBName(cfg ConnConfig) string {
+	database := strings.TrimSpace(cfg.Database)
+	catalog := strings.TrimSpace(cfg.Catalog)
+	if catalog == "" {
+		return database
+	}
+	if database == "" {
+		database = "information_schema"
+	}
+	return catalog + "." + database
+}
+
 func openAndPing(ctx context.Context, cfg ConnConfig) (*sql.DB, error) {
 	db, err := OpenDB(cfg)
 	if err != nil {
@@ -87,6 +103,19 @@ func openAndPing(ctx context.Context, cfg ConnConfig) (*sql.DB, error) {
 	return db, nil
 }
 
+// openAuditLogDB connects in the internal catalog without a default
+// database: audit_log and the information_schema that describes it live
+// there, whatever catalog and database the connection selects.
+func openAuditLogDB(ctx context.Context, cfg ConnConfig) (*sql.DB, error) {
+	return openAndPing(ctx, auditLogConnConfig(cfg))
+}
+
+func auditLogConnConfig(cfg ConnConfig) ConnConfig {
+	cfg.Catalog = ""
+	cfg.Database = ""
+	return cfg
+}
+
 func TestConnection(ctx context.Context, cfg ConnConfig) error {
 	db, err := openAndPing(ctx, cfg)
 	if err != nil {
@@ -0,0 +1,188 @@
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ExplainBatchMaxItems           = 50
+	ExplainBatchDefaultConcurrency = 4
+	ExplainBatchMaxConcurrency     = 8
+
+	explainBatchItemTimeout = 15 * time.Second
+)
+
+type ExplainBatchItem struct {
+	SQL string
+	// Database is used when SQL has no leading "USE db;".
+	Database string
+}
+
+type ExplainBatchOptions struct {
+	Format           string
+	SessionVariables map[string]string
+	Concurrency      int
+	// ItemTimeout bounds each item; the caller's context bounds the batch.
+	ItemTimeout time.Duration
+}
+
+type ExplainBatchItemResult struct {
+	Index    int    `json:"index"`
+	Database string `json:"database,omitempty"`
+	RawText  string `json:"rawText,omitempty"`
+	ExplainAnalysis
+	Error     string `json:"error,omitempty"`
+	ElapsedMs int64  `json:"elapsedMs"`
+}
+
+type ExplainBatchResult struct {
+	Items     []ExplainBatchItemResult `json:"items"`
+	Succeeded int                      `json:"succeeded"`
+	Failed    int                      `json:"failed"`
+}
+
+// ExplainBatch explains many statements over one connection pool with at most
+// Concurrency statements in flight. A failing item never fails the batch; its
+// error is reported on the item.
+func ExplainBatch(
+	ctx context.Context,
+	cfg ConnConfig,
+	items []ExplainBatchItem,
+	opts ExplainBatchOptions,
+) (ExplainBatchResult, error) {
+	if len(items) == 0 {
+		return ExplainBatchResult{}, errors.New("items is required")
+	}
+	if len(items) > ExplainBatchMaxItems {
+		return ExplainBatchResult{}, fmt.Errorf("too many items: %d (max=%d)", len(items), ExplainBatchMaxItems)
+	}
+	mode, err := NormalizeExplainMode(opts.Format)
+	if err != nil {
+		return ExplainBatchResult{}, err
+	}
+	builder, err := explainBuilderForFormat(mode)
+	if err != nil {
+		return ExplainBatchResult{}, err
+	}
+	sessionVariables, err := NormalizeExplainSessionVariables(opts.SessionVariables)
+	if err != nil {
+		return ExplainBatchResult{}, err
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = ExplainBatchDefaultConcurrency
+	}
+	if concurrency > ExplainBatchMaxConcurrency {
+		concurrency = ExplainBatchMaxConcurrency
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+	itemTimeout := opts.ItemTimeout
+	if itemTimeout <= 0 {
+		itemTimeout = explainBatchItemTimeout
+	}
+
+	db, err := openAndPing(ctx, cfg)
+	if err != nil {
+		return ExplainBatchResult{}, err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(concurrency)
+	db.SetMaxIdleConns(concurrency)
+
+	result := ExplainBatchResult{Items: make([]ExplainBatchItemResult, len(items))}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				result.Items[i] = explainBatchItem(ctx, db, i, items[i], mode, builder, sessionVariables, itemTimeout)
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i := range result.Items {
+		if result.Items[i].Error != "" {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+	}
+	return result, nil
+}
+
+func explainBatchItem(
+	ctx context.Context,
+	db *sql.DB,
+	index int,
+	item ExplainBatchItem,
+	mode string,
+	builder explainQueryBuilder,
+	sessionVariables map[string]string,
+	timeout time.Duration,
+) ExplainBatchItemResult {
+	out := ExplainBatchItemResult{Index: index}
+	started := time.Now()
+	rawText, database, err := explainBatchItemText(ctx, db, item, builder, sessionVariables, timeout)
+	out.ElapsedMs = time.Since(started).Milliseconds()
+	out.Database = database
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.RawText = rawText
+	out.ExplainAnalysis = AnalyzeExplainOutput(mode, rawText, item.SQL)
+	return out
+}
+
+func explainBatchItemText(
+	ctx context.Context,
+	db *sql.DB,
+	item ExplainBatchItem,
+	builder explainQueryBuilder,
+	sessionVariables map[string]string,
+	timeout time.Duration,
+) (string, string, error) {
+	stmt, err := prepareExplainStatement(item.SQL, builder)
+	if err != nil {
+		return "", "", err
+	}
+	if !stmt.changesContext() {
+		stmt.database = strings.TrimSpace(item.Database)
+		if strings.Contains(stmt.database, "`") {
+			return "", "", errors.New("database contains invalid character: '`'")
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", stmt.database, err
+	}
+
+	itemCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := db.Conn(itemCtx)
+	if err != nil {
+		return "", stmt.database, err
+	}
+	defer conn.Close()
+	rawText, err := runExplainOnConn(itemCtx, conn, stmt, sessionVariables)
+	if stmt.changesContext() {
+		// USE and SWITCH change the pooled connection; drop it so later items start clean.
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	return rawText, stmt.database, err
+}
@@ -0,0 +1,28 @@
+package doris
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplainBatchValidatesBeforeConnecting(t *testing.T) {
+	t.Parallel()
+
+	cfg := ConnConfig{Host: "127.0.0.1", Port: 1, User: "u"}
+	items := make([]ExplainBatchItem, ExplainBatchMaxItems+1)
+	if _, err := ExplainBatch(context.Background(), cfg, items, ExplainBatchOptions{Format: ExplainFormatTree}); err == nil ||
+		!strings.Contains(err.Error(), "too many items") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	one := []ExplainBatchItem{{SQL: "select 1"}}
+	if _, err := ExplainBatch(context.Background(), cfg, one, ExplainBatchOptions{Format: "analyze"}); err == nil ||
+		!strings.Contains(err.Error(), "unsupported explain mode: analyze") {
+		t.Fatalf("expected unsupported mode error, got %v", err)
+	}
+	vars := map[string]string{"sql_select_limit": "1"}
+	if _, err := ExplainBatch(context.Background(), cfg, one, ExplainBatchOptions{Format: ExplainFormatPlan, SessionVariables: vars}); err == nil ||
+		!strings.Contains(err.Error(), "unsupported session variable: sql_select_limit") {
+		t.Fatalf("expected session variable error, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package doris
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	explainOperatorIDSuffixRe = regexp.MustCompile(`\(\d+\)$`)
+	explainJoinDistributionRe = regexp.MustCompile(`(?i)\b(BROADCAST|PARTITIONED|BUCKET_SHUFFLE|COLOCATE|SHUFFLE)\b`)
+)
+
+// ExplainPlanDiff is a node-level comparison of two parsed plans. Nodes are
+// matched by operator and table because node ids are not stable across plans.
+type ExplainPlanDiff struct {
+	Identical        bool                 `json:"identical"`
+	AddedOperators   []ExplainOperatorRef `json:"addedOperators"`
+	RemovedOperators []ExplainOperatorRef `json:"removedOperators"`
+	JoinOrderChanged bool                 `json:"joinOrderChanged"`
+	LeftJoinOrder    []string             `json:"leftJoinOrder"`
+	RightJoinOrder   []string             `json:"rightJoinOrder"`
+	JoinChanges      []ExplainJoinChange  `json:"joinChanges"`
+	ScanChanges      []ExplainScanChange  `json:"scanChanges"`
+}
+
+type ExplainOperatorRef struct {
+	Operator   string `json:"operator"`
+	Table      string `json:"table,omitempty"`
+	NodeKey    string `json:"nodeKey"`
+	FragmentID *int   `json:"fragmentId,omitempty"`
+}
+
+// ExplainJoinChange reports a join over the same set of tables whose join type
+// or distribution (broadcast, shuffle, ...) differs between the plans.
+type ExplainJoinChange struct {
+	Tables            []string `json:"tables"`
+	LeftJoinOp        string   `json:"leftJoinOp"`
+	RightJoinOp       string   `json:"rightJoinOp"`
+	LeftDistribution  string   `json:"leftDistribution,omitempty"`
+	RightDistribution string   `json:"rightDistribution,omitempty"`
+}
+
+// ExplainScanChange reports a scan whose partition or tablet pruning differs.
+type ExplainScanChange struct {
+	Table           string            `json:"table"`
+	LeftPartitions  *ExplainScanRange `json:"leftPartitions,omitempty"`
+	RightPartitions *ExplainScanRange `json:"rightPartitions,omitempty"`
+	LeftTablets     *ExplainScanRange `json:"leftTablets,omitempty"`
+	RightTablets    *ExplainScanRange `json:"rightTablets,omitempty"`
+}
+
+type explainJoinInfo struct {
+	tables       []string
+	joinOp       string
+	distribution string
+}
+
+// DiffExplainPlans compares two parsed plans of the same or rewritten SQL.
+func DiffExplainPlans(left, right *ExplainPlanGraph) ExplainPlanDiff {
+	diff := ExplainPlanDiff{
+		AddedOperators:   []ExplainOperatorRef{},
+		RemovedOperators: []ExplainOperatorRef{},
+		JoinChanges:      []ExplainJoinChange{},
+		ScanChanges:      []ExplainScanChange{},
+	}
+	diff.RemovedOperators, diff.AddedOperators = diffExplainOperators(left, right)
+
+	diff.LeftJoinOrder = explainScanOrder(left)
+	diff.RightJoinOrder = explainScanOrder(right)
+	diff.JoinOrderChanged = !equalStrings(diff.LeftJoinOrder, diff.RightJoinOrder)
+
+	leftJoins := explainJoins(left)
+	rightJoins := explainJoins(right)
+	for key, l := range leftJoins {
+		r, ok := rightJoins[key]
+		if !ok || (l.joinOp == r.joinOp && l.distribution == r.distribution) {
+			continue
+		}
+		diff.JoinChanges = append(diff.JoinChanges, ExplainJoinChange{
+			Tables:            l.tables,
+			LeftJoinOp:        l.joinOp,
+			RightJoinOp:       r.joinOp,
+			LeftDistribution:  l.distribution,
+			RightDistribution: r.distribution,
+		})
+	}
+	sort.Slice(diff.JoinChanges, func(i, j int) bool {
+		return strings.Join(diff.JoinChanges[i].Tables, ",") < strings.Join(diff.JoinChanges[j].Tables, ",")
+	})
+
+	diff.ScanChanges = diffExplainScans(left, right)
+	diff.Identical = len(diff.AddedOperators) == 0 &&
+		len(diff.RemovedOperators) == 0 &&
+		!diff.JoinOrderChanged &&
+		len(diff.JoinChanges) == 0 &&
+		len(diff.ScanChanges) == 0
+	return diff
+}
+
+func explainOperatorName(op string) string {
+	return strings.ToUpper(strings.TrimSpace(explainOperatorIDSuffixRe.ReplaceAllString(strings.TrimSpace(op), "")))
+}
+
+func isExplainFragmentHeader(n *ExplainPlanNode) bool {
+	return strings.HasPrefix(strings.ToUpper(n.Operator), "PLAN FRAGMENT")
+}
+
+// diffExplainOperators matches operators as a multiset keyed by name and table.
+func diffExplainOperators(left, right *ExplainPlanGraph) (removed, added []ExplainOperatorRef) {
+	key := func(n *ExplainPlanNode) string { return explainOperatorName(n.Operator) + "\x00" + n.Table }
+	remaining := make(map[string]int)
+	for i := range right.Nodes {
+		if n := &right.Nodes[i]; !isExplainFragmentHeader(n) {
+			remaining[key(n)]++
+		}
+	}
+	removed = []ExplainOperatorRef{}
+	for i := range left.Nodes {
+		n := &left.Nodes[i]
+		if isExplainFragmentHeader(n) {
+			continue
+		}
+		if remaining[key(n)] > 0 {
+			remaining[key(n)]--
+			continue
+		}
+		removed = append(removed, explainOperatorRef(n))
+	}
+	added = []ExplainOperatorRef{}
+	for i := len(right.Nodes) - 1; i >= 0; i-- {
+		n := &right.Nodes[i]
+		if isExplainFragmentHeader(n) || remaining[key(n)] == 0 {
+			continue
+		}
+		remaining[key(n)]--
+		added = append(added, explainOperatorRef(n))
+	}
+	for i, j := 0, len(added)-1; i < j; i, j = i+1, j-1 {
+		added[i], added[j] = added[j], added[i]
+	}
+	return removed, added
+}
+
+func explainOperatorRef(n *ExplainPlanNode) ExplainOperatorRef {
+	return ExplainOperatorRef{Operator: n.Operator, Table: n.Table, NodeKey: n.Key, FragmentID: n.FragmentID}
+}
+
+// explainScanOrder lists scanned tables in the order the plan visits them,
+// following exchanges into the fragments that feed them.
+func explainScanOrder(g *ExplainPlanGraph) []string {
+	children := explainChildren(g)
+	order := []string{}
+	visited := make(map[string]bool)
+	var walk func(key string)
+	walk = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		n := g.nodeByKey(key)
+		if n == nil {
+			return
+		}
+		if n.Table != "" {
+			order = append(order, n.Table)
+		}
+		for _, child := range children[key] {
+			walk(child)
+		}
+	}
+	for i := range g.Nodes {
+		if g.Nodes[i].ParentKey == "" {
+			walk(g.Nodes[i].Key)
+		}
+	}
+	return order
+}
+
+// explainJoins indexes join nodes by the sorted set of tables beneath them.
+func explainJoins(g *ExplainPlanGraph) map[string]explainJoinInfo {
+	children := explainChildren(g)
+	memo := make(map[string][]string)
+	var tablesUnder func(key string, seen map[string]bool) []string
+	tablesUnder = func(key string, seen map[string]bool) []string {
+		if v, ok := memo[key]; ok {
+			return v
+		}
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		n := g.nodeByKey(key)
+		var tables []string
+		if n != nil && n.Table != "" {
+			tables = append(tables, n.Table)
+		}
+		for _, child := range children[key] {
+			for _, t := range tablesUnder(child, seen) {
+				if !containsString(tables, t) {
+					tables = append(tables, t)
+				}
+			}
+		}
+		sort.Strings(tables)
+		memo[key] = tables
+		return tables
+	}
+
+	out := make(map[string]explainJoinInfo)
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if !strings.Contains(strings.ToUpper(n.Operator), "JOIN") {
+			continue
+		}
+		tables := tablesUnder(n.Key, make(map[string]bool))
+		if len(tables) == 0 {
+			continue
+		}
+		joinOp, distribution := explainJoinDistribution(n)
+		out[strings.Join(tables, ",")] = explainJoinInfo{tables: tables, joinOp: joinOp, distribution: distribution}
+	}
+	return out
+}
+
+// explainJoinDistribution returns the join op text and its distribution
+// (BROADCAST, PARTITIONED, ...) when the plan shows one.
+func explainJoinDistribution(n *ExplainPlanNode) (joinOp string, distribution string) {
+	joinOp = n.Properties["JOIN_OP"]
+	if joinOp == "" {
+		joinOp = n.Operator
+	}
+	if m := explainJoinDistributionRe.FindStringSubmatch(joinOp); m != nil {
+		distribution = strings.ToUpper(m[1])
+	} else if m := explainJoinDistributionRe.FindStringSubmatch(n.Operator); m != nil {
+		distribution = strings.ToUpper(m[1])
+	}
+	return joinOp, distribution
+}
+
+// explainChildren maps each node to its children in output order. Exchange
+// nodes also get the nodes of the fragments sending to them, so walks cross
+// fragment boundaries in EXPLAIN PLAN output where indentation restarts.
+func explainChildren(g *ExplainPlanGraph) map[string][]string {
+	children := make(map[string][]string)
+	for i := range g.Nodes {
+		if p := g.Nodes[i].ParentKey; p != "" {
+			children[p] = append(children[p], g.Nodes[i].Key)
+		}
+	}
+	fragmentRoots := make(map[int][]string)
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if n.FragmentID == nil || isExplainFragmentHeader(n) {
+			continue
+		}
+		parent := g.nodeByKey(n.ParentKey)
+		if parent == nil || isExplainFragmentHeader(parent) || parent.FragmentID == nil || *parent.FragmentID != *n.FragmentID {
+			fragmentRoots[*n.FragmentID] = append(fragmentRoots[*n.FragmentID], n.Key)
+		}
+	}
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if n.ExchangeID == "" || n.isStreamSink() || n.FragmentID == nil {
+			continue
+		}
+		for _, e := range g.Exchanges {
+			if e.ExchangeID != n.ExchangeID || e.ToFragmentID != *n.FragmentID {
+				continue
+			}
+			for _, root := range fragmentRoots[e.FromFragmentID] {
+				if !containsString(children[n.Key], root) {
+					children[n.Key] = append(children[n.Key], root)
+				}
+			}
+		}
+	}
+	return children
+}
+
+func (g *ExplainPlanGraph) nodeByKey(key string) *ExplainPlanNode {
+	for i := range g.Nodes {
+		if g.Nodes[i].Key == key {
+			return &g.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// diffExplainScans pairs scans of the same table in plan order.
+func diffExplainScans(left, right *ExplainPlanGraph) []ExplainScanChange {
+	rightScans := make(map[string][]*ExplainPlanNode)
+	for i := range right.Nodes {
+		if n := &right.Nodes[i]; n.Table != "" {
+			rightScans[n.Table] = append(rightScans[n.Table], n)
+		}
+	}
+	changes := []ExplainScanChange{}
+	for i := range left.Nodes {
+		l := &left.Nodes[i]
+		if l.Table == "" || len(rightScans[l.Table]) == 0 {
+			continue
+		}
+		r := rightScans[l.Table][0]
+		rightScans[l.Table] = rightScans[l.Table][1:]
+		if equalScanRange(l.Partitions, r.Partitions) && equalScanRange(l.Tablets, r.Tablets) {
+			continue
+		}
+		changes = append(changes, ExplainScanChange{
+			Table:           l.Table,
+			LeftPartitions:  l.Partitions,
+			RightPartitions: r.Partitions,
+			LeftTablets:     l.Tablets,
+			RightTablets:    r.Tablets,
+		})
+	}
+	return changes
+}
+
+func equalScanRange(a, b *ExplainScanRange) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Selected == b.Selected && a.Total == b.Total && equalStrings(a.Items, b.Items)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package doris
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	explainDiffShuffleFixture = `PLAN FRAGMENT 0
+  PARTITION: UNPARTITIONED
+
+  VRESULT SINK
+
+  2:VHASH JOIN
+  |  join op: INNER JOIN(PARTITIONED)[]
+  |
+  |----1:VOlapScanNode
+  |       TABLE: db.small(small)
+  |       partitions=1/1
+  |
+  0:VOlapScanNode
+     TABLE: db.big(big)
+     partitions=4/4
+`
+	explainDiffBroadcastFixture = `PLAN FRAGMENT 0
+  PARTITION: UNPARTITIONED
+
+  VRESULT SINK
+
+  3:VHASH JOIN
+  |  join op: INNER JOIN(BROADCAST)[]
+  |  runtime filters: RF000[bloom] <- k[#1]
+  |
+  |----2:VEXCHANGE
+  |
+  0:VOlapScanNode
+     TABLE: db.big(big)
+     partitions=1/4 (p1)
+
+PLAN FRAGMENT 1
+  PARTITION: RANDOM
+
+  STREAM DATA SINK
+    EXCHANGE ID: 02
+    UNPARTITIONED
+
+  1:VOlapScanNode
+     TABLE: db.small(small)
+     partitions=1/1
+`
+)
+
+func mustParseExplain(t *testing.T, rawText string) *ExplainPlanGraph {
+	t.Helper()
+	g, err := ParseExplain(rawText)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return g
+}
+
+func TestDiffExplainPlans(t *testing.T) {
+	t.Parallel()
+
+	left := mustParseExplain(t, explainDiffShuffleFixture)
+	right := mustParseExplain(t, explainDiffBroadcastFixture)
+	diff := DiffExplainPlans(left, right)
+	if diff.Identical {
+		t.Fatalf("expected differences")
+	}
+	var added []string
+	for _, op := range diff.AddedOperators {
+		added = append(added, op.Operator)
+	}
+	if !reflect.DeepEqual(added, []string{"VEXCHANGE", "STREAM DATA SINK"}) || len(diff.RemovedOperators) != 0 {
+		t.Fatalf("unexpected operator changes: +%v -%+v", added, diff.RemovedOperators)
+	}
+	if diff.JoinOrderChanged || !reflect.DeepEqual(diff.RightJoinOrder, []string{"db.small(small)", "db.big(big)"}) {
+		t.Fatalf("unexpected join order: %v -> %v", diff.LeftJoinOrder, diff.RightJoinOrder)
+	}
+	wantJoin := []ExplainJoinChange{{
+		Tables:            []string{"db.big(big)", "db.small(small)"},
+		LeftJoinOp:        "INNER JOIN(PARTITIONED)[]",
+		RightJoinOp:       "INNER JOIN(BROADCAST)[]",
+		LeftDistribution:  "PARTITIONED",
+		RightDistribution: "BROADCAST",
+	}}
+	if !reflect.DeepEqual(diff.JoinChanges, wantJoin) {
+		t.Fatalf("unexpected join changes: %+v", diff.JoinChanges)
+	}
+	if len(diff.ScanChanges) != 1 || diff.ScanChanges[0].Table != "db.big(big)" ||
+		diff.ScanChanges[0].LeftPartitions.Selected != 4 || diff.ScanChanges[0].RightPartitions.Selected != 1 {
+		t.Fatalf("unexpected scan changes: %+v", diff.ScanChanges)
+	}
+
+	if same := DiffExplainPlans(left, mustParseExplain(t, explainDiffShuffleFixture)); !same.Identical {
+		t.Fatalf("expected identical plans: %+v", same)
+	}
+}
@@ -0,0 +1,220 @@
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Explain modes beyond "tree" and "plan" (ExplainFormatTree/ExplainFormatPlan).
+const (
+	ExplainModeVerbose     = "verbose"
+	ExplainModeGraph       = "graph"
+	ExplainModeShape       = "shape"
+	ExplainModeMemo        = "memo"
+	ExplainModePhysical    = "physical"
+	ExplainModeOptimized   = "optimized"
+	ExplainModeDistributed = "distributed"
+)
+
+// explainModePrefixes maps a mode to the EXPLAIN clause Doris expects.
+var explainModePrefixes = map[string]string{
+	ExplainModeVerbose:     "EXPLAIN VERBOSE ",
+	ExplainModeGraph:       "EXPLAIN GRAPH ",
+	ExplainModeShape:       "EXPLAIN SHAPE PLAN ",
+	ExplainModeMemo:        "EXPLAIN MEMO PLAN ",
+	ExplainModePhysical:    "EXPLAIN PHYSICAL PLAN ",
+	ExplainModeOptimized:   "EXPLAIN OPTIMIZED PLAN ",
+	ExplainModeDistributed: "EXPLAIN DISTRIBUTED PLAN ",
+}
+
+var (
+	explainShapeOperatorRe = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:\[([^\]]*)\])?`)
+	explainMemoGroupRe     = regexp.MustCompile(`Group\[@\d+\]`)
+	explainMemoExprRe      = regexp.MustCompile(`\b(Logical|Physical)[A-Z][A-Za-z]*`)
+)
+
+// NormalizeExplainMode lower-cases mode and defaults it to "tree".
+func NormalizeExplainMode(mode string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(mode))
+	switch normalized {
+	case "", ExplainFormatTree:
+		return ExplainFormatTree, nil
+	case ExplainFormatPlan:
+		return ExplainFormatPlan, nil
+	}
+	if _, ok := explainModePrefixes[normalized]; ok {
+		return normalized, nil
+	}
+	return "", errors.New("unsupported explain mode: " + normalized)
+}
+
+// ExplainModeHasPlanGraph reports whether mode output parses with ParseExplain.
+func ExplainModeHasPlanGraph(mode string) bool {
+	switch mode {
+	case ExplainFormatTree, ExplainFormatPlan, ExplainModeVerbose, ExplainModeDistributed:
+		return true
+	}
+	return false
+}
+
+func explainModeBuilder(mode string) explainQueryBuilder {
+	prefix := explainModePrefixes[mode]
+	return func(sqlText string) (string, error) {
+		normalizedSQL, err := normalizeExplainSQL(sqlText)
+		if err != nil {
+			return "", err
+		}
+		word, _ := scanLeadingWord(normalizedSQL)
+		if strings.EqualFold(word, "EXPLAIN") {
+			return "", fmt.Errorf("omit EXPLAIN from sql when mode is %s", mode)
+		}
+		return prefix + normalizedSQL, nil
+	}
+}
+
+// ExplainShape is a Nereids operator tree from SHAPE, PHYSICAL or OPTIMIZED
+// output. JoinTree renders the join order, e.g. "((a JOIN b) JOIN c)".
+type ExplainShape struct {
+	Nodes    []ExplainShapeNode `json:"nodes"`
+	Tables   []string           `json:"tables"`
+	JoinTree string             `json:"joinTree,omitempty"`
+}
+
+type ExplainShapeNode struct {
+	Depth    int    `json:"depth"`
+	Operator string `json:"operator"`
+	Detail   string `json:"detail,omitempty"`
+	Table    string `json:"table,omitempty"`
+	JoinType string `json:"joinType,omitempty"`
+}
+
+type ExplainMemoSummary struct {
+	Groups              int `json:"groups"`
+	LogicalExpressions  int `json:"logicalExpressions"`
+	PhysicalExpressions int `json:"physicalExpressions"`
+}
+
+// ExplainAnalysis is the structured view of EXPLAIN output; which fields are
+// set depends on the mode.
+type ExplainAnalysis struct {
+	Plan      *ExplainPlanGraph    `json:"plan,omitempty"`
+	PlanError string               `json:"planError,omitempty"`
+	Findings  []SchemaAuditFinding `json:"findings,omitempty"`
+	Shape     *ExplainShape        `json:"shape,omitempty"`
+	Memo      *ExplainMemoSummary  `json:"memo,omitempty"`
+}
+
+// AnalyzeExplainOutput parses rawText according to mode. GRAPH output is
+// ASCII art and is left as raw text only.
+func AnalyzeExplainOutput(mode, rawText, sqlText string) ExplainAnalysis {
+	var out ExplainAnalysis
+	switch {
+	case ExplainModeHasPlanGraph(mode):
+		plan, err := ParseExplain(rawText)
+		if err != nil {
+			out.PlanError = err.Error()
+			return out
+		}
+		out.Plan = plan
+		out.Findings = LintExplainPlan(plan, sqlText)
+	case mode == ExplainModeShape || mode == ExplainModePhysical || mode == ExplainModeOptimized:
+		shape, err := ParseExplainShape(rawText)
+		if err != nil {
+			out.PlanError = err.Error()
+			return out
+		}
+		out.Shape = shape
+	case mode == ExplainModeMemo:
+		out.Memo = summarizeExplainMemo(rawText)
+	}
+	return out
+}
+
+// ParseExplainShape reads operator trees indented with "--" per level (SHAPE)
+// or with "+--"/"|  " every three columns (PHYSICAL, OPTIMIZED).
+func ParseExplainShape(rawText string) (*ExplainShape, error) {
+	lines, _ := normalizeExplainLines(rawText)
+	shape := &ExplainShape{Nodes: []ExplainShapeNode{}, Tables: []string{}}
+	for _, line := range lines {
+		body := strings.TrimLeft(line, " -|+")
+		prefix := line[:len(line)-len(body)]
+		m := explainShapeOperatorRe.FindStringSubmatch(body)
+		if m == nil || isExplainPlanMetaLine(strings.TrimSpace(line)) {
+			continue
+		}
+		depth := len(prefix) / 2
+		if strings.ContainsAny(prefix, "+|") {
+			depth = len(prefix) / 3
+		}
+		node := ExplainShapeNode{
+			Depth:    depth,
+			Operator: m[1],
+			Detail:   strings.TrimSpace(body[len(m[1]):]),
+		}
+		// PHYSICAL and OPTIMIZED print plan ids in brackets on most operators.
+		bracket := m[2]
+		if _, err := strconv.Atoi(bracket); err == nil {
+			bracket = ""
+		}
+		lower := strings.ToLower(m[1])
+		switch {
+		case strings.Contains(lower, "join"):
+			node.JoinType = bracket
+		case strings.Contains(lower, "scan") && bracket != "":
+			node.Table = bracket
+			shape.Tables = append(shape.Tables, bracket)
+		}
+		shape.Nodes = append(shape.Nodes, node)
+	}
+	if len(shape.Nodes) == 0 {
+		return nil, errors.New("no plan shape nodes found in input")
+	}
+	if tree, _ := explainShapeJoinTree(shape.Nodes, 0); strings.Contains(tree, " JOIN ") {
+		shape.JoinTree = tree
+	}
+	return shape, nil
+}
+
+// explainShapeJoinTree renders the subtree starting at nodes[i] and returns it
+// with the index of the next node outside the subtree.
+func explainShapeJoinTree(nodes []ExplainShapeNode, i int) (string, int) {
+	n := nodes[i]
+	next := i + 1
+	var parts []string
+	for next < len(nodes) && nodes[next].Depth > n.Depth {
+		var part string
+		part, next = explainShapeJoinTree(nodes, next)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	switch {
+	case n.Table != "":
+		return n.Table, next
+	case strings.Contains(strings.ToLower(n.Operator), "join"):
+		return "(" + strings.Join(parts, " JOIN ") + ")", next
+	default:
+		return strings.Join(parts, ", "), next
+	}
+}
+
+func summarizeExplainMemo(rawText string) *ExplainMemoSummary {
+	out := &ExplainMemoSummary{}
+	for _, line := range strings.Split(rawText, "\n") {
+		if explainMemoGroupRe.MatchString(line) {
+			out.Groups++
+			continue
+		}
+		if m := explainMemoExprRe.FindStringSubmatch(line); m != nil {
+			if m[1] == "Logical" {
+				out.LogicalExpressions++
+			} else {
+				out.PhysicalExpressions++
+			}
+		}
+	}
+	return out
+}
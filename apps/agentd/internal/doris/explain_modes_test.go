@@ -0,0 +1,130 @@
+package doris
+
+import "testing"
+
+func TestExplainModeQueries(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		mode    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{mode: " VERBOSE ", in: "select 1;", want: "EXPLAIN VERBOSE select 1"},
+		{mode: "graph", in: "select 1", want: "EXPLAIN GRAPH select 1"},
+		{mode: "shape", in: "select 1", want: "EXPLAIN SHAPE PLAN select 1"},
+		{mode: "memo", in: "select 1", want: "EXPLAIN MEMO PLAN select 1"},
+		{mode: "physical", in: "select 1", want: "EXPLAIN PHYSICAL PLAN select 1"},
+		{mode: "optimized", in: "select 1", want: "EXPLAIN OPTIMIZED PLAN select 1"},
+		{mode: "distributed", in: "select 1", want: "EXPLAIN DISTRIBUTED PLAN select 1"},
+		{mode: "shape", in: "explain shape plan select 1", wantErr: true},
+		{mode: "dump", in: "select 1", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Parallel()
+			got, err := func() (string, error) {
+				mode, err := NormalizeExplainMode(tc.mode)
+				if err != nil {
+					return "", err
+				}
+				builder, err := explainBuilderForFormat(mode)
+				if err != nil {
+					return "", err
+				}
+				return builder(tc.in)
+			}()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil (result=%q)", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("unexpected result:\nwant: %q\ngot:  %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseExplainShape(t *testing.T) {
+	t.Parallel()
+
+	shapeText := `PhysicalResultSink
+--hashAgg[GLOBAL]
+----PhysicalDistribute[DistributionSpecGather]
+------hashJoin[INNER_JOIN] hashCondition=((a.k = c.k)) otherCondition=()
+--------hashJoin[INNER_JOIN] hashCondition=((a.k = b.k)) otherCondition=()
+----------PhysicalOlapScan[a]
+----------PhysicalDistribute[DistributionSpecReplicated]
+------------PhysicalOlapScan[b]
+--------PhysicalDistribute[DistributionSpecHash]
+----------PhysicalProject
+------------PhysicalOlapScan[c]
+`
+	shape, err := ParseExplainShape(shapeText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(shape.Nodes) != 11 || shape.Nodes[3].JoinType != "INNER_JOIN" || shape.Nodes[3].Depth != 3 {
+		t.Fatalf("unexpected nodes: %+v", shape.Nodes)
+	}
+	if !equalStrings(shape.Tables, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected tables: %v", shape.Tables)
+	}
+	if shape.JoinTree != "((a JOIN b) JOIN c)" {
+		t.Fatalf("unexpected join tree: %q", shape.JoinTree)
+	}
+
+	physicalText := `PhysicalResultSink[120] ( outputExprs=[k#0] )
++--PhysicalHashJoin[117]@4 ( type=INNER_JOIN, hashCondition=[(k#0 = k#2)] )
+   |--PhysicalOlapScan[b]@2 ( stats=10 )
+   +--PhysicalOlapScan[a]@1 ( stats=1000 )
+`
+	shape, err = ParseExplainShape(physicalText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shape.Nodes[1].Depth != 1 || shape.Nodes[1].JoinType != "" || shape.Nodes[2].Depth != 2 {
+		t.Fatalf("unexpected nodes: %+v", shape.Nodes)
+	}
+	if shape.JoinTree != "(b JOIN a)" {
+		t.Fatalf("unexpected join tree: %q", shape.JoinTree)
+	}
+
+	if _, err := ParseExplainShape("\n\n"); err == nil {
+		t.Fatalf("expected error for empty input")
+	}
+}
+
+func TestAnalyzeExplainOutputMemo(t *testing.T) {
+	t.Parallel()
+
+	memoText := `========== MEMO ==========
+Group[@0]
+  logical expressions:
+    id:1#0 cost=null estRows=1 children=[ ] (plan=LogicalOlapScan ( qualified=db.t ))
+  physical expressions:
+    id:5#0 cost=1 estRows=1 children=[ ] (plan=PhysicalOlapScan[t]@0 ( stats=1 ))
+Group[@1]
+  logical expressions:
+    id:2#1 cost=null estRows=1 children=[@0 ] (plan=LogicalProject[3] ( distinct=false ))
+`
+	got := AnalyzeExplainOutput(ExplainModeMemo, memoText, "select k from t")
+	if got.Memo == nil || *got.Memo != (ExplainMemoSummary{Groups: 2, LogicalExpressions: 2, PhysicalExpressions: 1}) {
+		t.Fatalf("unexpected memo summary: %+v", got.Memo)
+	}
+	if got.Plan != nil || got.Shape != nil {
+		t.Fatalf("unexpected analysis: %+v", got)
+	}
+
+	if got := AnalyzeExplainOutput(ExplainModeGraph, "+---+", ""); got.Plan != nil || got.PlanError != "" {
+		t.Fatalf("graph output should stay raw: %+v", got)
+	}
+}
@@ -0,0 +1,542 @@
+package doris
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ExplainFormatTree = "tree"
+	ExplainFormatPlan = "plan"
+
+	ExplainRuntimeFilterProducer = "producer"
+	ExplainRuntimeFilterConsumer = "consumer"
+)
+
+var (
+	explainTreeHeaderRe      = regexp.MustCompile(`^\[([^\]]*)\]:\[(\d+)\s*:\s*(.+)\]$`)
+	explainTreeFragmentRe    = regexp.MustCompile(`^\[Fragment:\s*(\d+)\]$`)
+	explainPlanFragmentRe    = regexp.MustCompile(`(?i)^PLAN FRAGMENT\s+(\d+)\b`)
+	explainPlanNodeRe        = regexp.MustCompile(`^([|\s-]*?)(\d+):(.+)$`)
+	explainPlanSinkRe        = regexp.MustCompile(`(?i)^[A-Za-z0-9_ ]+SINK$`)
+	explainKvEqRe            = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(.+)$`)
+	explainKvColonRe         = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_ ]*):\s*(.+)$`)
+	explainKvKeyStartRe      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*\s*[:=]`)
+	explainExchangeIDRe      = regexp.MustCompile(`(?i)EXCHANGE\s+ID\s*:\s*([0-9]+)`)
+	explainStreamSinkRe      = regexp.MustCompile(`(?i)STREAM\s+DATA\s+SINK|DATASTREAMSINK`)
+	explainScanRangeRe       = regexp.MustCompile(`^(\d+)\s*/\s*(\d+)(?:\s*\((.*)\))?`)
+	explainRuntimeFilterRe   = regexp.MustCompile(`(RF\d+)\[([^\]]*)\]\s*(<-|->)\s*`)
+	explainTableFormatLineRe = regexp.MustCompile(`^\+[-+]+\+$`)
+)
+
+// ExplainPlanGraph is the typed form of EXPLAIN TREE or EXPLAIN PLAN output.
+// Nodes are in output order; ParentKey is inferred from indentation, so in
+// EXPLAIN PLAN output it does not cross fragment boundaries.
+type ExplainPlanGraph struct {
+	Format    string                `json:"format"`
+	Fragments []ExplainPlanFragment `json:"fragments"`
+	Nodes     []ExplainPlanNode     `json:"nodes"`
+	Exchanges []ExplainPlanExchange `json:"exchanges"`
+	Warnings  []string              `json:"warnings,omitempty"`
+}
+
+type ExplainPlanFragment struct {
+	ID                  int      `json:"id"`
+	Partition           string   `json:"partition,omitempty"`
+	HasColocatePlanNode *bool    `json:"hasColocatePlanNode,omitempty"`
+	RootOperator        string   `json:"rootOperator,omitempty"`
+	NodeKeys            []string `json:"nodeKeys"`
+	Tables              []string `json:"tables,omitempty"`
+}
+
+// ExplainPlanExchange links the fragment that sends rows to the fragment that receives them.
+type ExplainPlanExchange struct {
+	ExchangeID     string `json:"exchangeId"`
+	FromFragmentID int    `json:"fromFragmentId"`
+	ToFragmentID   int    `json:"toFragmentId"`
+}
+
+type ExplainPlanNode struct {
+	Key            string                     `json:"key"`
+	ParentKey      string                     `json:"parentKey,omitempty"`
+	Depth          int                        `json:"depth"`
+	FragmentID     *int                       `json:"fragmentId,omitempty"`
+	NodeID         *int                       `json:"nodeId,omitempty"`
+	Operator       string                     `json:"operator"`
+	Table          string                     `json:"table,omitempty"`
+	Cardinality    *int64                     `json:"cardinality,omitempty"`
+	Predicates     string                     `json:"predicates,omitempty"`
+	Partitions     *ExplainScanRange          `json:"partitions,omitempty"`
+	Tablets        *ExplainScanRange          `json:"tablets,omitempty"`
+	RuntimeFilters []ExplainPlanRuntimeFilter `json:"runtimeFilters,omitempty"`
+	ExchangeID     string                     `json:"exchangeId,omitempty"`
+	Properties     map[string]string          `json:"properties"`
+	RawLine        string                     `json:"rawLine"`
+	segments       []string
+}
+
+// ExplainScanRange is "selected/total" for partitions or tablets, with the listed items.
+type ExplainScanRange struct {
+	Selected int64    `json:"selected"`
+	Total    int64    `json:"total"`
+	Items    []string `json:"items,omitempty"`
+}
+
+type ExplainPlanRuntimeFilter struct {
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+	// Role is "producer" on the join that builds the filter and "consumer" on the scan it is pushed to.
+	Role string `json:"role"`
+	Expr string `json:"expr"`
+}
+
+// ParseExplain parses EXPLAIN TREE output and falls back to EXPLAIN PLAN output.
+func ParseExplain(rawText string) (*ExplainPlanGraph, error) {
+	if graph, err := ParseExplainTree(rawText); err == nil {
+		return graph, nil
+	}
+	return ParseExplainPlan(rawText)
+}
+
+// ParseExplainTree parses the "[ids]:[id: op]||..." lines printed by EXPLAIN TREE.
+func ParseExplainTree(rawText string) (*ExplainPlanGraph, error) {
+	lines, warnings := normalizeExplainLines(rawText)
+	graph := &ExplainPlanGraph{Format: ExplainFormatTree, Warnings: warnings}
+	for _, line := range lines {
+		leading := len(line) - len(strings.TrimLeft(line, "-"))
+		rest := strings.TrimLeft(line[leading:], asciiWhitespace)
+		if !strings.HasPrefix(rest, "[") || !strings.Contains(rest, "]||") {
+			continue
+		}
+		var segments []string
+		for _, seg := range strings.Split(rest, "||") {
+			if seg = strings.TrimSpace(seg); seg != "" {
+				segments = append(segments, seg)
+			}
+		}
+		if len(segments) == 0 {
+			continue
+		}
+		m := explainTreeHeaderRe.FindStringSubmatch(segments[0])
+		if m == nil {
+			graph.Warnings = append(graph.Warnings, "unrecognized header: "+segments[0])
+			continue
+		}
+		nodeID, _ := strconv.Atoi(m[2])
+		node := ExplainPlanNode{
+			Depth:      leading / 2,
+			NodeID:     &nodeID,
+			Operator:   strings.TrimSpace(m[3]),
+			Properties: make(map[string]string),
+			RawLine:    line,
+		}
+		for _, seg := range segments {
+			if fm := explainTreeFragmentRe.FindStringSubmatch(seg); fm != nil && node.FragmentID == nil {
+				id, _ := strconv.Atoi(fm[1])
+				node.FragmentID = &id
+			}
+		}
+		for _, seg := range segments[1:] {
+			node.addSegment(seg)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if len(graph.Nodes) == 0 {
+		return nil, errors.New("no EXPLAIN TREE nodes found in input")
+	}
+	graph.finish()
+	return graph, nil
+}
+
+// ParseExplainPlan parses the indented "PLAN FRAGMENT n" text printed by EXPLAIN.
+func ParseExplainPlan(rawText string) (*ExplainPlanGraph, error) {
+	lines, warnings := normalizeExplainLines(rawText)
+	graph := &ExplainPlanGraph{Format: ExplainFormatPlan, Warnings: warnings}
+	var fragmentID *int
+	current := -1
+	baseDepth := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if isExplainPlanMetaLine(trimmed) {
+			continue
+		}
+		if m := explainPlanFragmentRe.FindStringSubmatch(trimmed); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			fragmentID = &id
+			baseDepth = 1
+			graph.Nodes = append(graph.Nodes, ExplainPlanNode{
+				FragmentID: fragmentID,
+				Operator:   "PLAN FRAGMENT " + m[1],
+				Properties: make(map[string]string),
+				RawLine:    line,
+			})
+			current = len(graph.Nodes) - 1
+			continue
+		}
+		if m := explainPlanNodeRe.FindStringSubmatch(line); m != nil {
+			nodeID, err := strconv.Atoi(m[2])
+			if err == nil {
+				prefix := strings.Join(strings.Fields(m[1]), "")
+				depth := strings.Count(prefix, "|") + strings.Count(prefix, "-")/4
+				graph.Nodes = append(graph.Nodes, ExplainPlanNode{
+					Depth:      baseDepth + depth,
+					FragmentID: fragmentID,
+					NodeID:     &nodeID,
+					Operator:   strings.TrimSpace(m[3]),
+					Properties: make(map[string]string),
+					RawLine:    line,
+				})
+				current = len(graph.Nodes) - 1
+				continue
+			}
+		}
+		if !strings.Contains(trimmed, ":") && explainPlanSinkRe.MatchString(trimmed) {
+			graph.Nodes = append(graph.Nodes, ExplainPlanNode{
+				Depth:      baseDepth,
+				FragmentID: fragmentID,
+				Operator:   trimmed,
+				Properties: make(map[string]string),
+				RawLine:    line,
+			})
+			current = len(graph.Nodes) - 1
+			continue
+		}
+		if current >= 0 {
+			seg := strings.TrimSpace(strings.TrimPrefix(strings.TrimLeft(line, asciiWhitespace), "|"))
+			graph.Nodes[current].addSegment(seg)
+		}
+	}
+	if len(graph.Nodes) == 0 {
+		return nil, errors.New("no EXPLAIN PLAN nodes found in input")
+	}
+	graph.finish()
+	return graph, nil
+}
+
+// normalizeExplainLines strips mysql client table borders so pasted output parses too.
+func normalizeExplainLines(rawText string) ([]string, []string) {
+	text := strings.ReplaceAll(strings.ReplaceAll(rawText, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	stripped := false
+	for _, line := range lines {
+		v := strings.TrimRight(line, asciiWhitespace)
+		if v == "" {
+			continue
+		}
+		if explainTableFormatLineRe.MatchString(v) {
+			stripped = true
+			continue
+		}
+		if len(v) >= 2 && strings.HasPrefix(v, "|") && strings.HasSuffix(v, "|") {
+			stripped = true
+			v = strings.TrimSpace(v[1 : len(v)-1])
+			if v == "" {
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	var warnings []string
+	if stripped {
+		warnings = append(warnings, "mysql table formatting detected and normalized")
+	}
+	return out, warnings
+}
+
+func isExplainPlanMetaLine(trimmed string) bool {
+	return trimmed == "" ||
+		strings.HasPrefix(strings.ToLower(trimmed), "explain string") ||
+		strings.HasPrefix(trimmed, "=") ||
+		strings.HasPrefix(strings.ToLower(trimmed), "planed ")
+}
+
+func (n *ExplainPlanNode) addSegment(seg string) {
+	if seg == "" {
+		return
+	}
+	n.segments = append(n.segments, seg)
+	for _, part := range splitExplainSegment(seg) {
+		key, value, ok := parseExplainKv(part)
+		if !ok || value == "" {
+			continue
+		}
+		prev, exists := n.Properties[key]
+		switch {
+		case !exists || prev == "":
+			n.Properties[key] = value
+		case prev == value || containsString(strings.Split(prev, "; "), value):
+		default:
+			n.Properties[key] = prev + "; " + value
+		}
+	}
+}
+
+// parseExplainKv reads "key=value" (lower-cased key) or "Key Name: value"
+// (upper-cased key with underscores), following the web UI parser.
+func parseExplainKv(part string) (string, string, bool) {
+	if m := explainKvEqRe.FindStringSubmatch(part); m != nil {
+		return strings.ToLower(strings.TrimSpace(m[1])), strings.TrimSpace(m[2]), true
+	}
+	if m := explainKvColonRe.FindStringSubmatch(part); m != nil {
+		key := strings.Join(strings.Fields(strings.ToUpper(m[1])), "_")
+		return key, strings.TrimSpace(m[2]), true
+	}
+	return "", "", false
+}
+
+// splitExplainSegment splits "a=1, b=2" into key/value parts. Commas inside
+// brackets or quotes, and commas not followed by a key, stay in the value.
+func splitExplainSegment(seg string) []string {
+	seg = strings.TrimSpace(seg)
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(seg); i++ {
+		c := seg[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth > 0 {
+				depth--
+			}
+		case c == ',' && depth == 0:
+			next := strings.TrimLeft(seg[i+1:], asciiWhitespace)
+			if len(next) < len(seg)-i-1 && explainKvKeyStartRe.MatchString(next) {
+				parts = append(parts, strings.TrimSpace(seg[start:i]))
+				start = len(seg) - len(next)
+				i = start - 1
+			}
+		}
+	}
+	if tail := strings.TrimSpace(seg[start:]); tail != "" {
+		parts = append(parts, tail)
+	}
+	return parts
+}
+
+// finish derives typed fields, parent links, fragments and exchanges.
+func (g *ExplainPlanGraph) finish() {
+	var stack []int
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		n.Key = "n" + strconv.Itoa(i)
+		if isExplainFragmentHeader(n) {
+			stack = stack[:0]
+		}
+		// EXPLAIN PLAN prints a node's first child at the node's own indentation.
+		for len(stack) > 0 && (g.Nodes[stack[len(stack)-1]].Depth > n.Depth ||
+			g.Format == ExplainFormatTree && g.Nodes[stack[len(stack)-1]].Depth == n.Depth) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			n.ParentKey = g.Nodes[stack[len(stack)-1]].Key
+		}
+		stack = append(stack, i)
+
+		n.Table = n.Properties["TABLE"]
+		n.Predicates = n.Properties["PREDICATES"]
+		if v, ok := parseExplainNumber(n.Properties["cardinality"]); ok {
+			n.Cardinality = &v
+		}
+		n.Partitions = parseExplainScanRange(n.Properties["partitions"], "")
+		n.Tablets = parseExplainScanRange(n.Properties["tablets"], n.Properties["tabletlist"])
+		n.RuntimeFilters = parseExplainRuntimeFilters(n.Properties["RUNTIME_FILTERS"])
+		n.ExchangeID = n.exchangeID()
+	}
+
+	fragments := make(map[int]*ExplainPlanFragment)
+	producers := make(map[string][]int)
+	consumers := make(map[string][]int)
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if n.FragmentID == nil {
+			continue
+		}
+		id := *n.FragmentID
+		f, ok := fragments[id]
+		if !ok {
+			f = &ExplainPlanFragment{ID: id}
+			fragments[id] = f
+		}
+		if isExplainFragmentHeader(n) {
+			f.Partition = n.Properties["PARTITION"]
+			if v, ok := n.Properties["HAS_COLO_PLAN_NODE"]; ok {
+				b := strings.EqualFold(strings.TrimSpace(v), "true")
+				f.HasColocatePlanNode = &b
+			}
+			continue
+		}
+		if f.RootOperator == "" {
+			f.RootOperator = n.Operator
+		}
+		f.NodeKeys = append(f.NodeKeys, n.Key)
+		if n.Table != "" && !containsString(f.Tables, n.Table) {
+			f.Tables = append(f.Tables, n.Table)
+		}
+		if n.ExchangeID == "" {
+			continue
+		}
+		if n.isStreamSink() {
+			producers[n.ExchangeID] = append(producers[n.ExchangeID], id)
+		} else {
+			consumers[n.ExchangeID] = append(consumers[n.ExchangeID], id)
+		}
+	}
+
+	g.Fragments = make([]ExplainPlanFragment, 0, len(fragments))
+	for _, f := range fragments {
+		sort.Strings(f.Tables)
+		if f.NodeKeys == nil {
+			f.NodeKeys = []string{}
+		}
+		g.Fragments = append(g.Fragments, *f)
+	}
+	sort.Slice(g.Fragments, func(i, j int) bool { return g.Fragments[i].ID < g.Fragments[j].ID })
+
+	g.Exchanges = []ExplainPlanExchange{}
+	seen := make(map[ExplainPlanExchange]struct{})
+	for exchangeID, from := range producers {
+		for _, fromID := range from {
+			for _, toID := range consumers[exchangeID] {
+				edge := ExplainPlanExchange{ExchangeID: exchangeID, FromFragmentID: fromID, ToFragmentID: toID}
+				if _, dup := seen[edge]; dup || fromID == toID {
+					continue
+				}
+				seen[edge] = struct{}{}
+				g.Exchanges = append(g.Exchanges, edge)
+			}
+		}
+	}
+	sort.Slice(g.Exchanges, func(i, j int) bool {
+		a, b := g.Exchanges[i], g.Exchanges[j]
+		if a.FromFragmentID != b.FromFragmentID {
+			return a.FromFragmentID < b.FromFragmentID
+		}
+		if a.ToFragmentID != b.ToFragmentID {
+			return a.ToFragmentID < b.ToFragmentID
+		}
+		return a.ExchangeID < b.ExchangeID
+	})
+}
+
+func (n *ExplainPlanNode) isStreamSink() bool {
+	if explainStreamSinkRe.MatchString(n.Operator) {
+		return true
+	}
+	for _, seg := range n.segments {
+		if explainStreamSinkRe.MatchString(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *ExplainPlanNode) isExchangeConsumer() bool {
+	op := strings.ToUpper(n.Operator)
+	return strings.Contains(op, "EXCHANGE") && !strings.HasSuffix(op, "SINK") && !n.isStreamSink()
+}
+
+// exchangeID returns the exchange a stream sink feeds or an exchange node reads.
+func (n *ExplainPlanNode) exchangeID() string {
+	if !n.isStreamSink() && !n.isExchangeConsumer() {
+		return ""
+	}
+	for _, text := range append(append([]string{}, n.segments...), n.RawLine) {
+		if m := explainExchangeIDRe.FindStringSubmatch(text); m != nil {
+			return trimExplainID(m[1])
+		}
+	}
+	if n.isExchangeConsumer() && n.NodeID != nil {
+		return strconv.Itoa(*n.NodeID)
+	}
+	return ""
+}
+
+func trimExplainID(id string) string {
+	if v, err := strconv.Atoi(id); err == nil {
+		return strconv.Itoa(v)
+	}
+	return id
+}
+
+func parseExplainNumber(v string) (int64, bool) {
+	cleaned := strings.NewReplacer(",", "", "_", "", " ", "").Replace(strings.TrimSpace(v))
+	if cleaned == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(cleaned, 10, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		return int64(f), true
+	}
+	return 0, false
+}
+
+func parseExplainScanRange(v string, list string) *ExplainScanRange {
+	m := explainScanRangeRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return nil
+	}
+	selected, _ := strconv.ParseInt(m[1], 10, 64)
+	total, _ := strconv.ParseInt(m[2], 10, 64)
+	r := &ExplainScanRange{Selected: selected, Total: total}
+	items := m[3]
+	if items == "" {
+		items = list
+	}
+	for _, item := range strings.Split(items, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			r.Items = append(r.Items, item)
+		}
+	}
+	return r
+}
+
+// parseExplainRuntimeFilters reads "RF000[bloom] <- expr" (built by a join) and
+// "RF000[bloom] -> expr" (applied at a scan) entries.
+func parseExplainRuntimeFilters(v string) []ExplainPlanRuntimeFilter {
+	matches := explainRuntimeFilterRe.FindAllStringSubmatchIndex(v, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	out := make([]ExplainPlanRuntimeFilter, 0, len(matches))
+	for i, m := range matches {
+		end := len(v)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		role := ExplainRuntimeFilterConsumer
+		if v[m[6]:m[7]] == "<-" {
+			role = ExplainRuntimeFilterProducer
+		}
+		out = append(out, ExplainPlanRuntimeFilter{
+			ID:   v[m[2]:m[3]],
+			Type: v[m[4]:m[5]],
+			Role: role,
+			Expr: strings.TrimRight(strings.TrimSpace(v[m[1]:end]), ",; "),
+		})
+	}
+	return out
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package doris
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	explainTreeFixtureMultiFragment = `Explain String(Nereids Planner)
+[05]:[5: ResultSink]||[Fragment: 0]||VRESULT SINK||MYSQL_PROTOCAL||
+--[05]:[5: VMERGING-EXCHANGE]||[Fragment: 0]||offset: 0||
+----[09]:[9: DataStreamSink]||[Fragment: 1]||STREAM DATA SINK||EXCHANGE ID: 05||UNPARTITIONED
+------[04]:[4: VTOP-N]||[Fragment: 1]||
+--------[03]:[3: VAGGREGATE (merge finalize)]||[Fragment: 1]||cardinality=3||
+----------[02]:[2: VEXCHANGE]||[Fragment: 1]||offset: 0||
+------------[02]:[2: DataStreamSink]||[Fragment: 2]||STREAM DATA SINK||EXCHANGE ID: 02||HASH_PARTITIONED
+--------------[00]:[0: VOlapScanNode]||[Fragment: 2]||TABLE: tpch.lineitem(lineitem)||cardinality=149,996,355||afterFilter=1,841,539||PREDICATES: 2||
+========== STATISTICS ==========
+`
+
+	explainPlanFixture = `Explain String(Nereids Planner)
+PLAN FRAGMENT 0
+  OUTPUT EXPRS:
+    k1[#0]
+  PARTITION: UNPARTITIONED
+
+  VRESULT SINK
+     MYSQL_PROTOCAL
+
+  3:VHASH JOIN
+  |  join op: INNER JOIN(BROADCAST)[]
+  |  equal join conjunct: (k1[#0] = k1[#2])
+  |  runtime filters: RF000[bloom] <- k1[#2](1/1/2097152)
+  |  cardinality=10
+  |
+  |----2:VEXCHANGE
+  |       offset: 0
+  |
+  1:VEXCHANGE
+     offset: 0
+
+PLAN FRAGMENT 1
+  PARTITION: HASH_PARTITIONED: k1[#0]
+  HAS_COLO_PLAN_NODE: false
+
+  STREAM DATA SINK
+    EXCHANGE ID: 01
+    UNPARTITIONED
+
+  0:VOlapScanNode(85)
+     TABLE: test_db.t(t)
+     PREDICATES: ((k1[#0] >= '2024-01-10'), (k2[#1] = 'a, b=c'))
+     runtime filters: RF000[bloom] -> k1[#0]
+     partitions=1/3 (p202401)
+     tablets=2/8, tabletList=123,456
+     cardinality=1
+========== STATISTICS ==========
+`
+)
+
+func TestParseExplainTreeMultiFragment(t *testing.T) {
+	t.Parallel()
+
+	graph, err := ParseExplain(explainTreeFixtureMultiFragment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if graph.Format != ExplainFormatTree || len(graph.Nodes) != 8 || len(graph.Fragments) != 3 {
+		t.Fatalf("unexpected graph: format=%s nodes=%d fragments=%d", graph.Format, len(graph.Nodes), len(graph.Fragments))
+	}
+	wantEdges := []ExplainPlanExchange{
+		{ExchangeID: "5", FromFragmentID: 1, ToFragmentID: 0},
+		{ExchangeID: "2", FromFragmentID: 2, ToFragmentID: 1},
+	}
+	if !reflect.DeepEqual(graph.Exchanges, wantEdges) {
+		t.Fatalf("unexpected exchanges: %+v", graph.Exchanges)
+	}
+
+	scan := graph.Nodes[7]
+	if scan.Operator != "VOlapScanNode" || scan.Table != "tpch.lineitem(lineitem)" || scan.Predicates != "2" {
+		t.Fatalf("unexpected scan: %+v", scan)
+	}
+	if scan.Cardinality == nil || *scan.Cardinality != 149996355 {
+		t.Fatalf("unexpected cardinality: %v", scan.Cardinality)
+	}
+	if scan.Depth != 7 || scan.ParentKey != "n6" {
+		t.Fatalf("unexpected position: depth=%d parent=%s", scan.Depth, scan.ParentKey)
+	}
+	if got := graph.Fragments[2]; got.RootOperator != "DataStreamSink" || !reflect.DeepEqual(got.Tables, []string{"tpch.lineitem(lineitem)"}) {
+		t.Fatalf("unexpected fragment: %+v", got)
+	}
+}
+
+func TestParseExplainPlanScanDetails(t *testing.T) {
+	t.Parallel()
+
+	graph, err := ParseExplain(explainPlanFixture)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if graph.Format != ExplainFormatPlan {
+		t.Fatalf("unexpected format: %s", graph.Format)
+	}
+	if !reflect.DeepEqual(graph.Exchanges, []ExplainPlanExchange{{ExchangeID: "1", FromFragmentID: 1, ToFragmentID: 0}}) {
+		t.Fatalf("unexpected exchanges: %+v", graph.Exchanges)
+	}
+	f1 := graph.Fragments[1]
+	if f1.Partition != "HASH_PARTITIONED: k1[#0]" || f1.HasColocatePlanNode == nil || *f1.HasColocatePlanNode {
+		t.Fatalf("unexpected fragment: %+v", f1)
+	}
+
+	var join, scan *ExplainPlanNode
+	for i := range graph.Nodes {
+		switch graph.Nodes[i].Operator {
+		case "VHASH JOIN":
+			join = &graph.Nodes[i]
+		case "VOlapScanNode(85)":
+			scan = &graph.Nodes[i]
+		}
+	}
+	if join == nil || scan == nil {
+		t.Fatalf("missing nodes: %+v", graph.Nodes)
+	}
+	wantJoinRF := []ExplainPlanRuntimeFilter{{ID: "RF000", Type: "bloom", Role: ExplainRuntimeFilterProducer, Expr: "k1[#2](1/1/2097152)"}}
+	if !reflect.DeepEqual(join.RuntimeFilters, wantJoinRF) {
+		t.Fatalf("unexpected join runtime filters: %+v", join.RuntimeFilters)
+	}
+	if scan.Table != "test_db.t(t)" || scan.Predicates != "((k1[#0] >= '2024-01-10'), (k2[#1] = 'a, b=c'))" {
+		t.Fatalf("unexpected scan: %+v", scan)
+	}
+	if !reflect.DeepEqual(scan.Partitions, &ExplainScanRange{Selected: 1, Total: 3, Items: []string{"p202401"}}) {
+		t.Fatalf("unexpected partitions: %+v", scan.Partitions)
+	}
+	if !reflect.DeepEqual(scan.Tablets, &ExplainScanRange{Selected: 2, Total: 8, Items: []string{"123", "456"}}) {
+		t.Fatalf("unexpected tablets: %+v", scan.Tablets)
+	}
+	if len(scan.RuntimeFilters) != 1 || scan.RuntimeFilters[0].Role != ExplainRuntimeFilterConsumer || scan.RuntimeFilters[0].Expr != "k1[#0]" {
+		t.Fatalf("unexpected scan runtime filters: %+v", scan.RuntimeFilters)
+	}
+	if scan.Cardinality == nil || *scan.Cardinality != 1 {
+		t.Fatalf("unexpected cardinality: %v", scan.Cardinality)
+	}
+}
+
+func TestParseExplainRejectsUnknownText(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseExplain("Query OK, 0 rows affected"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
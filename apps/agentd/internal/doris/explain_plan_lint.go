@@ -0,0 +1,285 @@
+package doris
+
+import (
+	"strings"
+)
+
+const (
+	planLintBroadcastRowsWarn     = 10_000_000
+	planLintBroadcastRowsCritical = 100_000_000
+	planLintFullScanRowsWarn      = 1_000_000
+	planLintWideOutputColumns     = 20
+)
+
+// LintExplainPlan reports plan anti-patterns in the same finding shape as the
+// schema audit. sqlText is the statement that was explained; it is only used
+// for checks the plan cannot answer on its own, such as SELECT *.
+func LintExplainPlan(g *ExplainPlanGraph, sqlText string) []SchemaAuditFinding {
+	findings := make([]SchemaAuditFinding, 0, 4)
+	if g == nil {
+		return findings
+	}
+	children := explainChildren(g)
+	hasAnyRuntimeFilter := false
+	for i := range g.Nodes {
+		if len(g.Nodes[i].RuntimeFilters) > 0 {
+			hasAnyRuntimeFilter = true
+			break
+		}
+	}
+
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if n.Table != "" {
+			findings = append(findings, lintExplainScan(n)...)
+		}
+		if strings.Contains(strings.ToUpper(n.Operator), "JOIN") {
+			findings = append(findings, lintExplainJoin(g, children, n, hasAnyRuntimeFilter)...)
+		}
+	}
+	if f, ok := lintExplainSelectStar(g, sqlText); ok {
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func lintExplainScan(n *ExplainPlanNode) []SchemaAuditFinding {
+	var findings []SchemaAuditFinding
+	if p := n.Partitions; p != nil && p.Total > 1 && p.Selected >= p.Total {
+		findings = append(findings, SchemaAuditFinding{
+			RuleID:     "PL-P001",
+			Severity:   "warn",
+			Confidence: 0.9,
+			Summary:    "Scan reads every partition of a partitioned table",
+			Evidence: map[string]any{
+				"nodeKey":            n.Key,
+				"table":              n.Table,
+				"selectedPartitions": p.Selected,
+				"totalPartitions":    p.Total,
+				"predicates":         n.Predicates,
+			},
+			Recommendation: "Filter on the partition column so the planner can prune partitions.",
+		})
+	}
+	if t := n.Tablets; t != nil && t.Total > 1 && t.Selected >= t.Total && n.Predicates == "" {
+		severity := "info"
+		if n.Cardinality != nil && *n.Cardinality >= planLintFullScanRowsWarn {
+			severity = "warn"
+		}
+		findings = append(findings, SchemaAuditFinding{
+			RuleID:     "PL-T001",
+			Severity:   severity,
+			Confidence: 0.8,
+			Summary:    "Scan reads every tablet without predicates",
+			Evidence: map[string]any{
+				"nodeKey":         n.Key,
+				"table":           n.Table,
+				"selectedTablets": t.Selected,
+				"totalTablets":    t.Total,
+				"cardinality":     n.Cardinality,
+				"threshold":       planLintFullScanRowsWarn,
+			},
+			Recommendation: "Add a selective filter on key or bucket columns, or pre-aggregate with a materialized view.",
+		})
+	}
+	return findings
+}
+
+func lintExplainJoin(
+	g *ExplainPlanGraph,
+	children map[string][]string,
+	n *ExplainPlanNode,
+	hasAnyRuntimeFilter bool,
+) []SchemaAuditFinding {
+	var findings []SchemaAuditFinding
+	joinOp, distribution := explainJoinDistribution(n)
+	upperJoin := strings.ToUpper(joinOp + " " + n.Operator)
+
+	if strings.Contains(upperJoin, "CROSS JOIN") {
+		findings = append(findings, SchemaAuditFinding{
+			RuleID:     "PL-J002",
+			Severity:   "warn",
+			Confidence: 0.9,
+			Summary:    "Plan contains a cross join",
+			Evidence: map[string]any{
+				"nodeKey":  n.Key,
+				"operator": n.Operator,
+				"joinOp":   joinOp,
+			},
+			Recommendation: "Add an equality join condition, or confirm the cartesian product is intended and small.",
+		})
+		return findings
+	}
+
+	build := explainJoinBuildSide(g, children, n)
+	if build != nil && distribution == "BROADCAST" {
+		if rows, ok := explainSubtreeCardinality(g, children, build.Key); ok && rows >= planLintBroadcastRowsWarn {
+			severity := "warn"
+			if rows >= planLintBroadcastRowsCritical {
+				severity = "critical"
+			}
+			findings = append(findings, SchemaAuditFinding{
+				RuleID:     "PL-J001",
+				Severity:   severity,
+				Confidence: 0.7,
+				Summary:    "Broadcast join builds from a large right side",
+				Evidence: map[string]any{
+					"nodeKey":           n.Key,
+					"joinOp":            joinOp,
+					"buildCardinality":  rows,
+					"warnThreshold":     planLintBroadcastRowsWarn,
+					"criticalThreshold": planLintBroadcastRowsCritical,
+				},
+				Recommendation: "Use a shuffle join (hint [shuffle]) or put the smaller table on the right side.",
+			})
+		}
+	}
+
+	if strings.Contains(upperJoin, "HASH JOIN") && !hasExplainRuntimeFilterRole(n, ExplainRuntimeFilterProducer) {
+		confidence := 0.8
+		if !hasAnyRuntimeFilter && g.Format == ExplainFormatTree {
+			// EXPLAIN TREE may omit runtime filter details entirely.
+			confidence = 0.5
+		}
+		findings = append(findings, SchemaAuditFinding{
+			RuleID:     "PL-R001",
+			Severity:   "info",
+			Confidence: confidence,
+			Summary:    "Hash join does not build any runtime filter",
+			Evidence: map[string]any{
+				"nodeKey": n.Key,
+				"joinOp":  joinOp,
+			},
+			Recommendation: "Check runtime_filter_type and runtime_filter_mode; join keys with mismatched types also block runtime filters.",
+		})
+	}
+	return findings
+}
+
+func hasExplainRuntimeFilterRole(n *ExplainPlanNode, role string) bool {
+	for _, rf := range n.RuntimeFilters {
+		if rf.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// explainJoinBuildSide returns the right (build) child of a join. EXPLAIN PLAN
+// prints it first and indented with "|----"; EXPLAIN TREE prints it last.
+func explainJoinBuildSide(g *ExplainPlanGraph, children map[string][]string, join *ExplainPlanNode) *ExplainPlanNode {
+	kids := children[join.Key]
+	if len(kids) < 2 && g.Format == ExplainFormatTree {
+		return nil
+	}
+	if g.Format == ExplainFormatPlan {
+		for _, key := range kids {
+			if c := g.nodeByKey(key); c != nil && c.Depth > join.Depth {
+				return c
+			}
+		}
+		return nil
+	}
+	return g.nodeByKey(kids[len(kids)-1])
+}
+
+// explainSubtreeCardinality returns the first cardinality found walking down
+// from key, which is the estimate closest to the subtree output.
+func explainSubtreeCardinality(g *ExplainPlanGraph, children map[string][]string, key string) (int64, bool) {
+	queue := []string{key}
+	seen := make(map[string]bool)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		n := g.nodeByKey(cur)
+		if n == nil {
+			continue
+		}
+		if n.Cardinality != nil {
+			return *n.Cardinality, true
+		}
+		queue = append(queue, children[cur]...)
+	}
+	return 0, false
+}
+
+func lintExplainSelectStar(g *ExplainPlanGraph, sqlText string) (SchemaAuditFinding, bool) {
+	if !sqlSelectsStar(sqlText) {
+		return SchemaAuditFinding{}, false
+	}
+	width, known := explainOutputColumnCount(g)
+	if known && width < planLintWideOutputColumns {
+		return SchemaAuditFinding{}, false
+	}
+	f := SchemaAuditFinding{
+		RuleID:     "PL-S001",
+		Severity:   "warn",
+		Confidence: 0.85,
+		Summary:    "SELECT * returns a wide row",
+		Evidence: map[string]any{
+			"outputColumns": width,
+			"threshold":     planLintWideOutputColumns,
+		},
+		Recommendation: "List only the needed columns so scans can skip unused column data.",
+	}
+	if !known {
+		f.Severity = "info"
+		f.Confidence = 0.5
+		f.Summary = "SELECT * used; output width is not shown in this explain mode"
+		f.Evidence["outputColumns"] = nil
+	}
+	return f, true
+}
+
+// sqlSelectsStar reports whether any SELECT list starts with * or t.*.
+func sqlSelectsStar(sqlText string) bool {
+	tokens, _ := lexSQL(sqlText)
+	for i := range tokens {
+		if !tokens[i].isWord("SELECT") {
+			continue
+		}
+		j := nextSQLTokenIndex(tokens, i+1)
+		for j < len(tokens) && (tokens[j].isWord("DISTINCT") || tokens[j].isWord("ALL")) {
+			j = nextSQLTokenIndex(tokens, j+1)
+		}
+		if j >= len(tokens) {
+			continue
+		}
+		if tokens[j].isSymbol('*') {
+			return true
+		}
+		if tokens[j].Kind == sqlTokenWord || tokens[j].Kind == sqlTokenQuotedIdent {
+			dot := nextSQLTokenIndex(tokens, j+1)
+			star := nextSQLTokenIndex(tokens, dot+1)
+			if dot < len(tokens) && tokens[dot].isSymbol('.') && star < len(tokens) && tokens[star].isSymbol('*') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// explainOutputColumnCount counts the "OUTPUT EXPRS:" entries of EXPLAIN PLAN.
+func explainOutputColumnCount(g *ExplainPlanGraph) (int, bool) {
+	for i := range g.Nodes {
+		segs := g.Nodes[i].segments
+		for j, seg := range segs {
+			if !strings.EqualFold(strings.TrimSpace(seg), "OUTPUT EXPRS:") {
+				continue
+			}
+			count := 0
+			for _, next := range segs[j+1:] {
+				if _, _, ok := parseExplainKv(next); ok {
+					break
+				}
+				count++
+			}
+			return count, count > 0
+		}
+	}
+	return 0, false
+}
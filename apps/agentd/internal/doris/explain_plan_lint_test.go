@@ -0,0 +1,81 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+)
+
+const explainLintFixture = `PLAN FRAGMENT 0
+  OUTPUT EXPRS:
+    k1[#0]
+    v1[#1]
+  PARTITION: UNPARTITIONED
+
+  VRESULT SINK
+
+  4:VNESTED LOOP JOIN
+  |  join op: CROSS JOIN()
+  |
+  |----3:VOlapScanNode
+  |       TABLE: db.dim(dim)
+  |       cardinality=10
+  |
+  2:VHASH JOIN
+  |  join op: INNER JOIN(BROADCAST)[]
+  |  equal join conjunct: (k1[#0] = k1[#2])
+  |  cardinality=1000
+  |
+  |----1:VOlapScanNode
+  |       TABLE: db.big(big)
+  |       PREDICATES: (v[#3] > 0)
+  |       partitions=1/4 (p1)
+  |       cardinality=50,000,000
+  |
+  0:VOlapScanNode
+     TABLE: db.fact(fact)
+     partitions=12/12 (p1,p2,p3,p4,p5,p6,p7,p8,p9,p10,p11,p12)
+     tablets=120/120
+     cardinality=2,000,000
+`
+
+func TestLintExplainPlan(t *testing.T) {
+	t.Parallel()
+
+	g := mustParseExplain(t, explainLintFixture)
+	findings := LintExplainPlan(g, "select * from fact, dim")
+	got := make(map[string]SchemaAuditFinding)
+	var ids []string
+	for _, f := range findings {
+		got[f.RuleID] = f
+		ids = append(ids, f.RuleID)
+	}
+	want := []string{"PL-J002", "PL-J001", "PL-R001", "PL-P001", "PL-T001"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected findings: %v", ids)
+	}
+	if f := got["PL-P001"]; f.Evidence["table"] != "db.fact(fact)" || f.Evidence["totalPartitions"] != int64(12) {
+		t.Fatalf("unexpected partition finding: %+v", f)
+	}
+	if f := got["PL-T001"]; f.Severity != "warn" {
+		t.Fatalf("unexpected tablet finding: %+v", f)
+	}
+	if f := got["PL-J001"]; f.Evidence["buildCardinality"] != int64(50_000_000) || f.Severity != "warn" {
+		t.Fatalf("unexpected broadcast finding: %+v", f)
+	}
+}
+
+func TestLintExplainSelectStar(t *testing.T) {
+	t.Parallel()
+
+	g := mustParseExplain(t, "PLAN FRAGMENT 0\n  0:VOlapScanNode\n     TABLE: db.t(t)")
+	findings := LintExplainPlan(g, "SELECT /* all */ t.* FROM t")
+	if len(findings) != 1 || findings[0].RuleID != "PL-S001" || findings[0].Severity != "info" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if findings := LintExplainPlan(g, "SELECT count(*) FROM t"); len(findings) != 0 {
+		t.Fatalf("unexpected findings for count(*): %+v", findings)
+	}
+	if findings := LintExplainPlan(g, "SELECT '*' FROM t"); len(findings) != 0 {
+		t.Fatalf("unexpected findings for literal: %+v", findings)
+	}
+}
@@ -0,0 +1,139 @@
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const explainSessionVariablesMax = 32
+
+type explainSessionVarKind int
+
+const (
+	explainSessionVarBool explainSessionVarKind = iota
+	explainSessionVarInt
+	explainSessionVarNumber
+	// explainSessionVarList is a comma-separated list of identifiers, sent quoted.
+	explainSessionVarList
+)
+
+// explainSessionVariableKinds is the allowlist of planner-related session
+// variables an EXPLAIN request may set. Anything that changes data, privileges
+// or the connection itself stays out.
+var explainSessionVariableKinds = map[string]explainSessionVarKind{
+	"auto_broadcast_join_threshold":              explainSessionVarNumber,
+	"broadcast_right_table_scale_factor":         explainSessionVarNumber,
+	"broadcast_row_count_limit":                  explainSessionVarInt,
+	"disable_join_reorder":                       explainSessionVarBool,
+	"disable_nereids_rules":                      explainSessionVarList,
+	"enable_bucket_shuffle_join":                 explainSessionVarBool,
+	"enable_cost_based_join_reorder":             explainSessionVarBool,
+	"enable_fallback_to_original_planner":        explainSessionVarBool,
+	"enable_local_shuffle":                       explainSessionVarBool,
+	"enable_nereids_planner":                     explainSessionVarBool,
+	"enable_partition_topn":                      explainSessionVarBool,
+	"enable_pipeline_engine":                     explainSessionVarBool,
+	"enable_pipeline_x_engine":                   explainSessionVarBool,
+	"enable_runtime_filter_prune":                explainSessionVarBool,
+	"enable_share_hash_table_for_broadcast_join": explainSessionVarBool,
+	"max_join_number_bushy_tree":                 explainSessionVarInt,
+	"parallel_fragment_exec_instance_num":        explainSessionVarInt,
+	"parallel_pipeline_task_num":                 explainSessionVarInt,
+	"runtime_filter_max_in_num":                  explainSessionVarInt,
+	"runtime_filter_mode":                        explainSessionVarList,
+	"runtime_filter_type":                        explainSessionVarList,
+	"runtime_filter_wait_time_ms":                explainSessionVarInt,
+}
+
+var explainSessionListValueRe = regexp.MustCompile(`^[A-Za-z0-9_]+(\s*,\s*[A-Za-z0-9_]+)*$`)
+
+// NormalizeExplainSessionVariables checks names against the allowlist and
+// values against each variable's kind, returning lower-cased names and
+// canonical values.
+func NormalizeExplainSessionVariables(vars map[string]string) (map[string]string, error) {
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	if len(vars) > explainSessionVariablesMax {
+		return nil, fmt.Errorf("too many session variables: %d (max=%d)", len(vars), explainSessionVariablesMax)
+	}
+	out := make(map[string]string, len(vars))
+	for name, value := range vars {
+		key := strings.ToLower(strings.TrimSpace(name))
+		kind, ok := explainSessionVariableKinds[key]
+		if !ok {
+			return nil, fmt.Errorf("unsupported session variable: %s", name)
+		}
+		normalized, ok := normalizeExplainSessionValue(kind, strings.TrimSpace(value))
+		if !ok {
+			return nil, fmt.Errorf("invalid value for session variable %s: %q", key, value)
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("duplicate session variable: %s", key)
+		}
+		out[key] = normalized
+	}
+	return out, nil
+}
+
+func normalizeExplainSessionValue(kind explainSessionVarKind, value string) (string, bool) {
+	switch kind {
+	case explainSessionVarBool:
+		switch strings.ToLower(value) {
+		case "true", "1", "on":
+			return "true", true
+		case "false", "0", "off":
+			return "false", true
+		}
+		return "", false
+	case explainSessionVarInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatInt(n, 10), true
+	case explainSessionVarNumber:
+		// ParseFloat accepts "NaN" and "Inf", and overflows like 1e400 to Inf.
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	case explainSessionVarList:
+		if !explainSessionListValueRe.MatchString(value) {
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// applyExplainSessionVariables runs SET for each variable on the pinned
+// connection, in name order so failures are reproducible.
+func applyExplainSessionVariables(ctx context.Context, conn *sql.Conn, vars map[string]string) error {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind, ok := explainSessionVariableKinds[name]
+		if !ok {
+			return fmt.Errorf("unsupported session variable: %s", name)
+		}
+		value := vars[name]
+		if kind == explainSessionVarList {
+			value = "'" + value + "'"
+		}
+		if _, err := conn.ExecContext(ctx, "SET "+name+" = "+value); err != nil {
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package doris
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeExplainSessionVariables(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make(map[string]string, explainSessionVariablesMax+1)
+	for i := 0; i <= explainSessionVariablesMax; i++ {
+		tooMany[fmt.Sprintf("var_%d", i)] = "1"
+	}
+
+	tests := []struct {
+		name    string
+		in      map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "empty",
+			in:   nil,
+			want: nil,
+		},
+		{
+			name: "names are case-insensitive and values canonical",
+			in: map[string]string{
+				"Enable_Nereids_Planner":        "ON",
+				"disable_join_reorder":          "0",
+				"parallel_pipeline_task_num":    " 8 ",
+				"auto_broadcast_join_threshold": "0.80",
+				"runtime_filter_type":           "IN_OR_BLOOM_FILTER, MIN_MAX",
+			},
+			want: map[string]string{
+				"enable_nereids_planner":        "true",
+				"disable_join_reorder":          "false",
+				"parallel_pipeline_task_num":    "8",
+				"auto_broadcast_join_threshold": "0.8",
+				"runtime_filter_type":           "IN_OR_BLOOM_FILTER, MIN_MAX",
+			},
+		},
+		{
+			name:    "unknown variable",
+			in:      map[string]string{"sql_mode": ""},
+			wantErr: "unsupported session variable: sql_mode",
+		},
+		{
+			name:    "privilege variable is not allowlisted",
+			in:      map[string]string{"sql_select_limit": "1"},
+			wantErr: "unsupported session variable",
+		},
+		{
+			name:    "invalid bool",
+			in:      map[string]string{"disable_join_reorder": "maybe"},
+			wantErr: "invalid value for session variable disable_join_reorder",
+		},
+		{
+			name:    "statement smuggled into int",
+			in:      map[string]string{"parallel_pipeline_task_num": "8; drop table t"},
+			wantErr: "invalid value for session variable parallel_pipeline_task_num",
+		},
+		{
+			name:    "invalid number",
+			in:      map[string]string{"auto_broadcast_join_threshold": "lots"},
+			wantErr: "invalid value for session variable auto_broadcast_join_threshold",
+		},
+		{
+			name:    "NaN number",
+			in:      map[string]string{"auto_broadcast_join_threshold": "NaN"},
+			wantErr: "invalid value for session variable auto_broadcast_join_threshold",
+		},
+		{
+			name:    "infinite number",
+			in:      map[string]string{"broadcast_right_table_scale_factor": "-Inf"},
+			wantErr: "invalid value for session variable broadcast_right_table_scale_factor",
+		},
+		{
+			name:    "number out of range",
+			in:      map[string]string{"auto_broadcast_join_threshold": "1e400"},
+			wantErr: "invalid value for session variable auto_broadcast_join_threshold",
+		},
+		{
+			name:    "fraction in int",
+			in:      map[string]string{"broadcast_row_count_limit": "1.5"},
+			wantErr: "invalid value for session variable broadcast_row_count_limit",
+		},
+		{
+			name:    "quote in list",
+			in:      map[string]string{"runtime_filter_type": "BLOOM' , x='"},
+			wantErr: "invalid value for session variable runtime_filter_type",
+		},
+		{
+			name:    "duplicate after case folding",
+			in:      map[string]string{"enable_local_shuffle": "true", "ENABLE_LOCAL_SHUFFLE": "false"},
+			wantErr: "duplicate session variable: enable_local_shuffle",
+		},
+		{
+			name:    "too many variables",
+			in:      tooMany,
+			wantErr: "too many session variables",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NormalizeExplainSessionVariables(tc.in)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("unexpected variables: got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExplainSessionVariableAllowlistKeepsConnectionStateOut(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{
+		"sql_mode", "autocommit", "time_zone", "query_timeout", "exec_mem_limit", "sql_select_limit", "enable_sql_cache",
+	} {
+		if _, ok := explainSessionVariableKinds[name]; ok {
+			t.Fatalf("%s must not be settable from EXPLAIN requests", name)
+		}
+	}
+	for name := range explainSessionVariableKinds {
+		if name != strings.ToLower(name) {
+			t.Fatalf("allowlist key %q must be lower-case", name)
+		}
+	}
+}
@@ -14,68 +14,6 @@ const (
 	asciiWhitespace       = " \t\n\r\f\v"
 )
 
-func parseLeadingUseDatabase(sqlText string) (db string, rest string, ok bool, err error) {
-	trimmed := strings.TrimSpace(sqlText)
-	if trimmed == "" {
-		return "", "", false, nil
-	}
-
-	word, _ := scanLeadingWord(trimmed)
-	if strings.ToUpper(word) != "USE" {
-		return "", sqlText, false, nil
-	}
-	afterUse := strings.TrimSpace(trimmed[len(word):])
-	if afterUse == "" {
-		return "", "", true, errors.New("USE statement requires a database name")
-	}
-
-	var dbName string
-	var tail string
-	if afterUse[0] == '`' {
-		end := strings.Index(afterUse[1:], "`")
-		if end < 0 {
-			return "", "", true, errors.New("USE statement has an unterminated quoted identifier")
-		}
-		dbName = afterUse[1 : 1+end]
-		tail = afterUse[1+end+1:]
-	} else {
-		i := 0
-		for i < len(afterUse) {
-			c := afterUse[i]
-			if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
-				i++
-				continue
-			}
-			break
-		}
-		if i == 0 {
-			return "", "", true, errors.New("USE statement has an invalid database name")
-		}
-		if i < len(afterUse) {
-			c := afterUse[i]
-			if c != ';' && !strings.ContainsRune(asciiWhitespace, rune(c)) {
-				return "", "", true, errors.New("USE statement has an invalid database name")
-			}
-		}
-		dbName = afterUse[:i]
-		tail = afterUse[i:]
-	}
-	dbName = strings.TrimSpace(dbName)
-	if dbName == "" {
-		return "", "", true, errors.New("USE statement requires a database name")
-	}
-
-	tail = strings.TrimLeft(tail, asciiWhitespace)
-	if tail == "" || tail[0] != ';' {
-		return "", "", true, errors.New("USE statement must end with ';'")
-	}
-	restSQL := strings.TrimSpace(tail[1:])
-	if restSQL == "" {
-		return "", "", true, errors.New("sql is required after USE")
-	}
-	return dbName, restSQL, true, nil
-}
-
 var explainPlanTypeTokens = map[string]struct{}{
 	"PARSED":      {},
 	"ANALYZED":    {},
@@ -117,32 +55,6 @@ func scanLeadingWord(s string) (word string, rest string) {
 	return s[:i], s[i:]
 }
 
-func stripLeadingCommentsAndSpace(s string) string {
-	for {
-		s = strings.TrimLeft(s, asciiWhitespace)
-		if s == "" {
-			return ""
-		}
-		if strings.HasPrefix(s, "--") {
-			nl := strings.IndexByte(s, '\n')
-			if nl < 0 {
-				return ""
-			}
-			s = s[nl+1:]
-			continue
-		}
-		if strings.HasPrefix(s, "/*") {
-			end := strings.Index(s, "*/")
-			if end < 0 {
-				return ""
-			}
-			s = s[end+2:]
-			continue
-		}
-		return s
-	}
-}
-
 func normalizeExplainSQL(sqlText string) (string, error) {
 	sqlText = strings.TrimSpace(sqlText)
 	if sqlText == "" {
@@ -203,12 +115,7 @@ func buildExplainTreeQuery(sqlText string) (string, error) {
 			return "", errors.New("only EXPLAIN TREE is supported")
 		}
 
-		check := strings.ToUpper(stripLeadingCommentsAndSpace(rest))
-		if strings.HasPrefix(check, "SELECT") ||
-			strings.HasPrefix(check, "WITH") ||
-			strings.HasPrefix(check, "INSERT") ||
-			strings.HasPrefix(check, "UPDATE") ||
-			strings.HasPrefix(check, "DELETE") {
+		if isExplainableSQLKind(classifySQL(rest)) {
 			if planType != "" {
 				return "EXPLAIN " + planType + " TREE " + rest, nil
 			}
@@ -249,25 +156,58 @@ func buildExplainPlanQuery(sqlText string) (string, error) {
 
 type explainQueryBuilder func(sqlText string) (string, error)
 
+// explainStatement is a validated EXPLAIN query plus the catalog and database
+// a leading "SWITCH catalog;" or "USE [catalog.]db;" selected, if any.
+type explainStatement struct {
+	catalog  string
+	database string
+	query    string
+}
+
+func (s explainStatement) changesContext() bool {
+	return s.catalog != "" || s.database != ""
+}
+
+func prepareExplainStatement(sqlText string, builder explainQueryBuilder) (explainStatement, error) {
+	if len(sqlText) > explainSQLMaxBytes {
+		return explainStatement{}, fmt.Errorf("sql too large: %d bytes (max=%d)", len(sqlText), explainSQLMaxBytes)
+	}
+	guarded, err := GuardSQL(sqlText)
+	if err != nil {
+		return explainStatement{}, err
+	}
+	if strings.Contains(guarded.Database, "`") {
+		return explainStatement{}, errors.New("USE database name contains invalid character: '`'")
+	}
+	if strings.Contains(guarded.Catalog, "`") {
+		return explainStatement{}, errors.New("catalog name contains invalid character: '`'")
+	}
+	queryText, err := builder(guarded.Statement.Text)
+	if err != nil {
+		return explainStatement{}, err
+	}
+	return explainStatement{catalog: guarded.Catalog, database: guarded.Database, query: queryText}, nil
+}
+
 func explainWithBuilder(
 	ctx context.Context,
 	cfg ConnConfig,
 	sqlText string,
 	builder explainQueryBuilder,
+	sessionVariables map[string]string,
 ) (string, error) {
-	dbName, restSQL, hasUse, err := parseLeadingUseDatabase(sqlText)
+	sessionVariables, err := NormalizeExplainSessionVariables(sessionVariables)
 	if err != nil {
 		return "", err
 	}
-	if hasUse {
-		sqlText = restSQL
-		cfg.Database = ""
-	}
-
-	queryText, err := builder(sqlText)
+	stmt, err := prepareExplainStatement(sqlText, builder)
 	if err != nil {
 		return "", err
 	}
+	if stmt.changesContext() {
+		// A bare "USE db;" still resolves inside cfg.Catalog.
+		cfg.Database = ""
+	}
 
 	db, err := openAndPing(ctx, cfg)
 	if err != nil {
@@ -281,16 +221,34 @@ func explainWithBuilder(
 	}
 	defer conn.Close()
 
-	if hasUse {
-		if strings.Contains(dbName, "`") {
-			return "", errors.New("USE database name contains invalid character: '`'")
-		}
-		if _, err := conn.ExecContext(ctx, "USE `"+dbName+"`"); err != nil {
+	return runExplainOnConn(ctx, conn, stmt, sessionVariables)
+}
+
+func runExplainOnConn(
+	ctx context.Context,
+	conn *sql.Conn,
+	stmt explainStatement,
+	sessionVariables map[string]string,
+) (string, error) {
+	var switchSQL string
+	switch {
+	case stmt.catalog != "" && stmt.database != "":
+		switchSQL = "USE `" + stmt.catalog + "`.`" + stmt.database + "`"
+	case stmt.database != "":
+		switchSQL = "USE `" + stmt.database + "`"
+	case stmt.catalog != "":
+		switchSQL = "SWITCH `" + stmt.catalog + "`"
+	}
+	if switchSQL != "" {
+		if _, err := conn.ExecContext(ctx, switchSQL); err != nil {
 			return "", err
 		}
 	}
+	if err := applyExplainSessionVariables(ctx, conn, sessionVariables); err != nil {
+		return "", err
+	}
 
-	rows, err := conn.QueryContext(ctx, queryText)
+	rows, err := conn.QueryContext(ctx, stmt.query)
 	if err != nil {
 		return "", err
 	}
@@ -336,9 +294,38 @@ func explainWithBuilder(
 }
 
 func ExplainTree(ctx context.Context, cfg ConnConfig, sqlText string) (string, error) {
-	return explainWithBuilder(ctx, cfg, sqlText, buildExplainTreeQuery)
+	return explainWithBuilder(ctx, cfg, sqlText, buildExplainTreeQuery, nil)
 }
 
 func ExplainPlan(ctx context.Context, cfg ConnConfig, sqlText string) (string, error) {
-	return explainWithBuilder(ctx, cfg, sqlText, buildExplainPlanQuery)
+	return explainWithBuilder(ctx, cfg, sqlText, buildExplainPlanQuery, nil)
+}
+
+// Explain runs EXPLAIN in the given mode (see NormalizeExplainMode) after
+// applying the allowlisted session variables on the same connection.
+func Explain(
+	ctx context.Context,
+	cfg ConnConfig,
+	sqlText string,
+	format string,
+	sessionVariables map[string]string,
+) (string, error) {
+	builder, err := explainBuilderForFormat(format)
+	if err != nil {
+		return "", err
+	}
+	return explainWithBuilder(ctx, cfg, sqlText, builder, sessionVariables)
+}
+
+func explainBuilderForFormat(format string) (explainQueryBuilder, error) {
+	switch format {
+	case ExplainFormatTree:
+		return buildExplainTreeQuery, nil
+	case ExplainFormatPlan:
+		return buildExplainPlanQuery, nil
+	}
+	if _, ok := explainModePrefixes[format]; ok {
+		return explainModeBuilder(format), nil
+	}
+	return nil, fmt.Errorf("unsupported explain mode: %s", format)
 }
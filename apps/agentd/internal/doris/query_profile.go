@@ -0,0 +1,479 @@
+package doris
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	QueryProfileDefaultHTTPPort = 8030
+
+	queryProfileMaxBytes        = 32 << 20
+	queryProfileHotspotLimit    = 5
+	queryProfileSkewRatio       = 2.0
+	queryProfileSkewMinTimeNs   = int64(10_000_000)
+	queryProfileHotMemoryBytes  = int64(1 << 30)
+	queryProfileHotTimeShareMin = 0.2
+)
+
+var (
+	queryProfileIDRe          = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+	queryProfileOperatorRe    = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_ ]*?)\s*\((?:[^)]*?\b)?id=(-?\d+)[^)]*\)\s*:?\s*(?:\((.*)\))?\s*$`)
+	queryProfileFragmentRe    = regexp.MustCompile(`^Fragment\s+(\d+)\s*:`)
+	queryProfileSectionRe     = regexp.MustCompile(`^(Pipeline|PipelineTask|Instance|Fragments|Execution Profile|MergedProfile|DetailProfile|Summary|Execution Summary)\b`)
+	queryProfileCounterRe     = regexp.MustCompile(`^-\s*([A-Za-z][A-Za-z0-9_]*)\s*:\s*(.*)$`)
+	queryProfileDurationRe    = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(hour|min|sec|ms|us|ns|h|m|s)`)
+	queryProfileBytesRe       = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*(TB|GB|MB|KB|B)$`)
+	queryProfileExactRe       = regexp.MustCompile(`\((\d+)\)`)
+	queryProfileCountRe       = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([KMB])?$`)
+	queryProfileActiveRe      = regexp.MustCompile(`Active:\s*([^,)]+)`)
+	queryProfileDurationUnits = map[string]float64{
+		"hour": 3600e9, "h": 3600e9,
+		"min": 60e9, "m": 60e9,
+		"sec": 1e9, "s": 1e9,
+		"ms": 1e6, "us": 1e3, "ns": 1,
+	}
+	queryProfileTimeCounters   = []string{"ExecTime", "TotalTime"}
+	queryProfileRowsCounters   = []string{"RowsProduced", "RowsReturned"}
+	queryProfileMemoryCounters = []string{"PeakMemoryUsage", "MemoryUsagePeak", "PeakMemUsage"}
+)
+
+type QueryProfileOptions struct {
+	QueryID string
+	// HTTPPort is the FE http_port; profiles are only fetched from the connection host.
+	HTTPPort int
+}
+
+type QueryProfileResult struct {
+	QueryID     string                 `json:"queryId"`
+	Source      string                 `json:"source"`
+	Summary     map[string]string      `json:"summary"`
+	TotalTimeNs int64                  `json:"totalTimeNs"`
+	Operators   []QueryProfileOperator `json:"operators"`
+	Hotspots    []QueryProfileHotspot  `json:"hotspots"`
+	RawText     string                 `json:"rawText"`
+}
+
+// QueryProfileOperator aggregates one plan operator over all of its instances.
+type QueryProfileOperator struct {
+	FragmentID      *int    `json:"fragmentId,omitempty"`
+	Name            string  `json:"name"`
+	ID              int     `json:"id"`
+	Samples         int     `json:"samples"`
+	AvgTimeNs       int64   `json:"avgTimeNs"`
+	MaxTimeNs       int64   `json:"maxTimeNs"`
+	MinTimeNs       int64   `json:"minTimeNs"`
+	Rows            int64   `json:"rows"`
+	PeakMemoryBytes int64   `json:"peakMemoryBytes"`
+	TimeSkew        float64 `json:"timeSkew"`
+}
+
+type QueryProfileHotspot struct {
+	FragmentID      *int     `json:"fragmentId,omitempty"`
+	Operator        string   `json:"operator"`
+	ID              int      `json:"id"`
+	Reasons         []string `json:"reasons"`
+	MaxTimeNs       int64    `json:"maxTimeNs"`
+	TimeShare       float64  `json:"timeShare"`
+	TimeSkew        float64  `json:"timeSkew"`
+	PeakMemoryBytes int64    `json:"peakMemoryBytes"`
+}
+
+// queryProfileSample is one occurrence of an operator: a single instance in
+// older profiles, or a merged avg/max/min line in newer ones.
+type queryProfileSample struct {
+	avg, max, min int64
+	hasTime       bool
+	rows          int64
+	memory        int64
+}
+
+type queryProfileEndpoint struct {
+	path string
+	json bool
+}
+
+// FetchQueryProfile downloads a query profile from the FE HTTP API and
+// analyzes it. Newer FEs serve /api/profile/text; older ones only the JSON
+// /api/profile and the manager endpoint, so each is tried in turn.
+func FetchQueryProfile(ctx context.Context, cfg ConnConfig, opts QueryProfileOptions) (QueryProfileResult, error) {
+	queryID := strings.TrimSpace(opts.QueryID)
+	if !queryProfileIDRe.MatchString(queryID) {
+		return QueryProfileResult{}, errors.New("queryId must be 1-128 letters, digits, '-' or '_'")
+	}
+	port := opts.HTTPPort
+	if port == 0 {
+		port = QueryProfileDefaultHTTPPort
+	}
+	if port < 0 || port > 65535 {
+		return QueryProfileResult{}, errors.New("httpPort must be in 0..65535 (0 = default)")
+	}
+	base := "http://" + net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	escaped := url.QueryEscape(queryID)
+	endpoints := []queryProfileEndpoint{
+		{path: "/api/profile/text?query_id=" + escaped},
+		{path: "/api/profile?query_id=" + escaped, json: true},
+		{path: "/rest/v2/manager/query/profile/text/" + url.PathEscape(queryID), json: true},
+	}
+
+	client := &http.Client{Timeout: cfg.ReadTimeout}
+	var errs []string
+	for _, ep := range endpoints {
+		text, err := fetchQueryProfileText(ctx, client, cfg, base+ep.path, ep.json)
+		if err == nil && strings.TrimSpace(text) != "" {
+			result := ParseQueryProfile(text)
+			result.QueryID = queryID
+			result.Source = strings.SplitN(ep.path, "?", 2)[0]
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return QueryProfileResult{}, ctx.Err()
+		}
+		if err == nil {
+			err = errors.New("empty profile")
+		}
+		errs = append(errs, strings.SplitN(ep.path, "?", 2)[0]+": "+err.Error())
+	}
+	return QueryProfileResult{}, fmt.Errorf("query profile %s not available (%s)", queryID, strings.Join(errs, "; "))
+}
+
+func fetchQueryProfileText(ctx context.Context, client *http.Client, cfg ConnConfig, target string, isJSON bool) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(cfg.User, cfg.Password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, queryProfileMaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > queryProfileMaxBytes {
+		return "", fmt.Errorf("profile too large (max=%d bytes)", queryProfileMaxBytes)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	if !isJSON && !json.Valid(body) {
+		return string(body), nil
+	}
+	return decodeQueryProfileJSON(body)
+}
+
+// decodeQueryProfileJSON unwraps {"code":0,"data":{"profile":"..."}} and its
+// variants where data is the profile string itself.
+func decodeQueryProfileJSON(body []byte) (string, error) {
+	var envelope struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return "", fmt.Errorf("unexpected profile response: %w", err)
+	}
+	if envelope.Code != 0 {
+		return "", fmt.Errorf("fe error %d: %s", envelope.Code, envelope.Msg)
+	}
+	var text string
+	if err := json.Unmarshal(envelope.Data, &text); err == nil {
+		return text, nil
+	}
+	var data struct {
+		Profile string `json:"profile"`
+	}
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		return "", fmt.Errorf("unexpected profile response: %w", err)
+	}
+	return data.Profile, nil
+}
+
+// ParseQueryProfile extracts the summary and per-operator counters from
+// profile text and ranks hotspots. Unknown lines are ignored.
+func ParseQueryProfile(text string) QueryProfileResult {
+	result := QueryProfileResult{
+		Summary:   make(map[string]string),
+		Operators: []QueryProfileOperator{},
+		Hotspots:  []QueryProfileHotspot{},
+		RawText:   text,
+	}
+	type opKey struct {
+		fragment int
+		name     string
+		id       int
+	}
+	samples := make(map[opKey][]queryProfileSample)
+	var order []opKey
+	var fragmentID *int
+	var current *queryProfileSample
+	var currentKey opKey
+	inSummary := false
+	flush := func() {
+		if current != nil {
+			samples[currentKey] = append(samples[currentKey], *current)
+			current = nil
+		}
+	}
+
+	for _, rawLine := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" {
+			continue
+		}
+		if m := queryProfileFragmentRe.FindStringSubmatch(line); m != nil {
+			flush()
+			id, _ := strconv.Atoi(m[1])
+			fragmentID = &id
+			inSummary = false
+			continue
+		}
+		if m := queryProfileSectionRe.FindStringSubmatch(line); m != nil {
+			flush()
+			inSummary = m[1] == "Summary" || m[1] == "Execution Summary"
+			continue
+		}
+		if m := queryProfileOperatorRe.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, "-") {
+			flush()
+			id, _ := strconv.Atoi(m[2])
+			currentKey = opKey{fragment: -1, name: strings.TrimSpace(m[1]), id: id}
+			if fragmentID != nil {
+				currentKey.fragment = *fragmentID
+			}
+			if _, seen := samples[currentKey]; !seen {
+				samples[currentKey] = nil
+				order = append(order, currentKey)
+			}
+			current = &queryProfileSample{min: math.MaxInt64}
+			if am := queryProfileActiveRe.FindStringSubmatch(m[3]); am != nil {
+				if ns, ok := parseQueryProfileDuration(am[1]); ok {
+					current.avg, current.max, current.min, current.hasTime = ns, ns, ns, true
+				}
+			}
+			inSummary = false
+			continue
+		}
+		if inSummary {
+			// Summary keys may contain spaces ("Profile ID"), unlike counters.
+			if key, value, ok := strings.Cut(strings.TrimPrefix(line, "-"), ":"); ok && strings.HasPrefix(line, "-") {
+				result.Summary[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+			continue
+		}
+		if m := queryProfileCounterRe.FindStringSubmatch(line); m != nil && current != nil {
+			current.addCounter(m[1], strings.TrimSpace(m[2]))
+		}
+	}
+	flush()
+
+	if total, ok := result.Summary["Total"]; ok {
+		result.TotalTimeNs, _ = parseQueryProfileDuration(total)
+	}
+	for _, key := range order {
+		op := QueryProfileOperator{Name: key.name, ID: key.id, MinTimeNs: math.MaxInt64}
+		if key.fragment >= 0 {
+			id := key.fragment
+			op.FragmentID = &id
+		}
+		var sum int64
+		var timed int
+		for _, s := range samples[key] {
+			op.Samples++
+			op.Rows += s.rows
+			if s.memory > op.PeakMemoryBytes {
+				op.PeakMemoryBytes = s.memory
+			}
+			if !s.hasTime {
+				continue
+			}
+			timed++
+			sum += s.avg
+			if s.max > op.MaxTimeNs {
+				op.MaxTimeNs = s.max
+			}
+			if s.min < op.MinTimeNs {
+				op.MinTimeNs = s.min
+			}
+		}
+		if timed == 0 {
+			op.MinTimeNs = 0
+		} else {
+			op.AvgTimeNs = sum / int64(timed)
+		}
+		if op.AvgTimeNs > 0 {
+			op.TimeSkew = math.Round(float64(op.MaxTimeNs)/float64(op.AvgTimeNs)*100) / 100
+		}
+		result.Operators = append(result.Operators, op)
+	}
+	result.Hotspots = rankQueryProfileHotspots(result.Operators, result.TotalTimeNs)
+	return result
+}
+
+func (s *queryProfileSample) addCounter(name, value string) {
+	switch {
+	case containsString(queryProfileTimeCounters, name):
+		stats := parseQueryProfileStats(value, parseQueryProfileDuration)
+		if avg, ok := stats["avg"]; ok {
+			s.avg, s.max, s.min, s.hasTime = avg, avg, avg, true
+			if v, ok := stats["max"]; ok {
+				s.max = v
+			}
+			if v, ok := stats["min"]; ok {
+				s.min = v
+			}
+		}
+	case containsString(queryProfileRowsCounters, name):
+		stats := parseQueryProfileStats(value, parseQueryProfileCount)
+		if v, ok := stats["sum"]; ok {
+			s.rows = v
+		} else if v, ok := stats["avg"]; ok {
+			s.rows = v
+		}
+	case containsString(queryProfileMemoryCounters, name):
+		stats := parseQueryProfileStats(value, parseQueryProfileBytes)
+		if v, ok := stats["max"]; ok {
+			s.memory = v
+		} else if v, ok := stats["avg"]; ok {
+			s.memory = v
+		}
+	}
+}
+
+// parseQueryProfileStats reads "sum 1, avg 2, max 3, min 4" from merged
+// profiles; a plain value is returned as both avg and sum.
+func parseQueryProfileStats(value string, parse func(string) (int64, bool)) map[string]int64 {
+	out := make(map[string]int64)
+	for _, part := range strings.Split(value, ", ") {
+		part = strings.TrimSpace(part)
+		word, rest, _ := strings.Cut(part, " ")
+		switch word {
+		case "sum", "avg", "max", "min":
+			if v, ok := parse(rest); ok {
+				out[word] = v
+			}
+		}
+	}
+	if len(out) == 0 {
+		if v, ok := parse(value); ok {
+			out["avg"] = v
+			out["sum"] = v
+		}
+	}
+	return out
+}
+
+func parseQueryProfileDuration(v string) (int64, bool) {
+	v = strings.TrimSpace(v)
+	matches := queryProfileDurationRe.FindAllStringSubmatchIndex(v, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	var total float64
+	consumed := 0
+	for _, m := range matches {
+		if strings.TrimSpace(v[consumed:m[0]]) != "" {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(v[m[2]:m[3]], 64)
+		if err != nil {
+			return 0, false
+		}
+		total += n * queryProfileDurationUnits[v[m[4]:m[5]]]
+		consumed = m[1]
+	}
+	if strings.TrimSpace(v[consumed:]) != "" {
+		return 0, false
+	}
+	return int64(total), true
+}
+
+func parseQueryProfileBytes(v string) (int64, bool) {
+	m := queryProfileBytesRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	shift := map[string]uint{"B": 0, "KB": 10, "MB": 20, "GB": 30, "TB": 40}[strings.ToUpper(m[2])]
+	return int64(n * float64(uint64(1)<<shift)), true
+}
+
+// parseQueryProfileCount prefers the exact value in "1.23K (1234)".
+func parseQueryProfileCount(v string) (int64, bool) {
+	v = strings.TrimSpace(v)
+	if m := queryProfileExactRe.FindStringSubmatch(v); m != nil {
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		return n, err == nil
+	}
+	m := queryProfileCountRe.FindStringSubmatch(v)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToUpper(m[2]) {
+	case "K":
+		n *= 1e3
+	case "M":
+		n *= 1e6
+	case "B":
+		n *= 1e9
+	}
+	return int64(n), true
+}
+
+func rankQueryProfileHotspots(ops []QueryProfileOperator, totalTimeNs int64) []QueryProfileHotspot {
+	ranked := make([]QueryProfileOperator, len(ops))
+	copy(ranked, ops)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].MaxTimeNs > ranked[j].MaxTimeNs })
+
+	hotspots := []QueryProfileHotspot{}
+	for i, op := range ranked {
+		h := QueryProfileHotspot{
+			FragmentID:      op.FragmentID,
+			Operator:        op.Name,
+			ID:              op.ID,
+			Reasons:         []string{},
+			MaxTimeNs:       op.MaxTimeNs,
+			TimeSkew:        op.TimeSkew,
+			PeakMemoryBytes: op.PeakMemoryBytes,
+		}
+		if totalTimeNs > 0 {
+			h.TimeShare = math.Round(float64(op.MaxTimeNs)/float64(totalTimeNs)*1000) / 1000
+		}
+		if op.MaxTimeNs > 0 && (i == 0 || h.TimeShare >= queryProfileHotTimeShareMin) {
+			h.Reasons = append(h.Reasons, "time")
+		}
+		if op.TimeSkew >= queryProfileSkewRatio && op.MaxTimeNs >= queryProfileSkewMinTimeNs {
+			h.Reasons = append(h.Reasons, "skew")
+		}
+		if op.PeakMemoryBytes >= queryProfileHotMemoryBytes {
+			h.Reasons = append(h.Reasons, "memory")
+		}
+		if len(h.Reasons) == 0 {
+			continue
+		}
+		hotspots = append(hotspots, h)
+		if len(hotspots) >= queryProfileHotspotLimit {
+			break
+		}
+	}
+	return hotspots
+}
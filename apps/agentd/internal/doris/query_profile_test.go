@@ -0,0 +1,127 @@
+package doris
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const queryProfileFixture = `Summary:
+   - Profile ID: 6f3c1a2b9d8e4f70-a1b2c3d4e5f60718
+   - Task Type: QUERY
+   - Total: 2sec500ms
+   - Sql Statement: select k, count(*) from t group by k
+
+MergedProfile:
+  Fragments:
+    Fragment 0:
+      Pipeline : 0(instance_num=1):
+        RESULT_SINK_OPERATOR (id=0):
+          - ExecTime: avg 1.500ms, max 1.500ms, min 1.500ms
+          - RowsProduced: sum 10, avg 10, max 10, min 10
+        EXCHANGE_OPERATOR (id=3):
+          - ExecTime: avg 2.0ms, max 2.0ms, min 2.0ms
+    Fragment 1:
+      Pipeline : 0(instance_num=4):
+        AGGREGATION_OPERATOR (id=2 , nereids_id=88):
+          - ExecTime: avg 300ms, max 1sec200ms, min 100ms
+          - RowsProduced: sum 1.234K (1234), avg 308, max 900, min 10
+          - PeakMemoryUsage: sum 6.00 GB, avg 1.50 GB, max 3.00 GB, min 512.00 MB
+        OLAP_SCAN_OPERATOR (id=1. nereids_id=80. table name = t):
+          - ExecTime: avg 400ms, max 450ms, min 350ms
+          - RowsProduced: sum 1.000M (1000000), avg 250.000K (250000), max 260.000K (260000), min 240.000K (240000)
+`
+
+func TestParseQueryProfile(t *testing.T) {
+	t.Parallel()
+
+	result := ParseQueryProfile(queryProfileFixture)
+	if result.TotalTimeNs != 2_500_000_000 || result.Summary["Profile ID"] != "6f3c1a2b9d8e4f70-a1b2c3d4e5f60718" {
+		t.Fatalf("unexpected summary: %d %v", result.TotalTimeNs, result.Summary)
+	}
+	if len(result.Operators) != 4 {
+		t.Fatalf("unexpected operators: %+v", result.Operators)
+	}
+	agg := result.Operators[2]
+	if agg.Name != "AGGREGATION_OPERATOR" || agg.ID != 2 || agg.FragmentID == nil || *agg.FragmentID != 1 {
+		t.Fatalf("unexpected operator: %+v", agg)
+	}
+	if agg.AvgTimeNs != 300_000_000 || agg.MaxTimeNs != 1_200_000_000 || agg.MinTimeNs != 100_000_000 || agg.TimeSkew != 4 {
+		t.Fatalf("unexpected timing: %+v", agg)
+	}
+	if agg.Rows != 1234 || agg.PeakMemoryBytes != 3<<30 {
+		t.Fatalf("unexpected rows/memory: %+v", agg)
+	}
+	if scan := result.Operators[3]; scan.Name != "OLAP_SCAN_OPERATOR" || scan.Rows != 1_000_000 {
+		t.Fatalf("unexpected scan: %+v", scan)
+	}
+
+	if len(result.Hotspots) != 1 {
+		t.Fatalf("unexpected hotspots: %+v", result.Hotspots)
+	}
+	h := result.Hotspots[0]
+	if h.Operator != "AGGREGATION_OPERATOR" || h.TimeShare != 0.48 || len(h.Reasons) != 3 {
+		t.Fatalf("unexpected hotspot: %+v", h)
+	}
+}
+
+func TestParseQueryProfilePerInstance(t *testing.T) {
+	t.Parallel()
+
+	text := `Fragment 1:
+  Instance a-1 (host=TNetworkAddress(hostname:10.0.0.1, port:9060)):(Active: 1sec, % non-child: 0.00%)
+    VOlapScanNode (id=0):(Active: 900ms, % non-child: 90.00%)
+      - RowsReturned: 100
+  Instance a-2 (host=TNetworkAddress(hostname:10.0.0.2, port:9060)):(Active: 1sec, % non-child: 0.00%)
+    VOlapScanNode (id=0):(Active: 100ms, % non-child: 10.00%)
+      - RowsReturned: 20
+`
+	result := ParseQueryProfile(text)
+	if len(result.Operators) != 1 {
+		t.Fatalf("unexpected operators: %+v", result.Operators)
+	}
+	op := result.Operators[0]
+	if op.Samples != 2 || op.Rows != 120 || op.AvgTimeNs != 500_000_000 || op.MaxTimeNs != 900_000_000 || op.TimeSkew != 1.8 {
+		t.Fatalf("unexpected operator: %+v", op)
+	}
+}
+
+func TestFetchQueryProfileFallsBackToJSONEndpoint(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "root" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/profile":
+			if r.URL.Query().Get("query_id") != "q-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"msg":"success","data":{"profile":"Summary:\n  - Total: 1sec\n"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	host, portText, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portText)
+	cfg := ConnConfig{Host: host, Port: 9030, User: "root", Password: "secret"}
+	result, err := FetchQueryProfile(context.Background(), cfg, QueryProfileOptions{QueryID: "q-1", HTTPPort: port})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Source != "/api/profile" || result.TotalTimeNs != 1_000_000_000 || result.QueryID != "q-1" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if _, err := FetchQueryProfile(context.Background(), cfg, QueryProfileOptions{QueryID: "../etc"}); err == nil {
+		t.Fatalf("expected invalid query id error")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -73,6 +74,14 @@ func BuildSchemaAuditScan(
 		Page:      opts.Page,
 		PageSize:  opts.PageSize,
 	}
+	rules, err := normalizeSchemaAuditRuleConfig(opts.Rules)
+	if err != nil {
+		return SchemaAuditScanResult{}, err
+	}
+	normalized.Rules = rules
+	if err := defaultSchemaAuditRules.ValidateRuleIDs(opts.DisabledRules); err != nil {
+		return SchemaAuditScanResult{}, err
+	}
 	cfg.Database = ""
 
 	db, err := openAndPing(ctx, cfg)
@@ -86,44 +95,69 @@ func BuildSchemaAuditScan(
 		return SchemaAuditScanResult{}, err
 	}
 	scanRows := scanCollection.Rows
-
-	databaseSet := make(map[string]struct{}, len(scanRows))
-	items := make([]SchemaAuditScanItem, 0, len(scanRows))
-	inventory := SchemaAuditInventory{
-		TableCount: len(scanRows),
+	inventory := summarizeSchemaAuditInventory(scanRows)
+	if opts.OnInventory != nil {
+		opts.OnInventory(inventory)
 	}
-	for i := range scanRows {
-		key := scanRows[i].Key
-		databaseSet[key.Database] = struct{}{}
 
-		partitionSummary := scanRows[i].PartitionSummary
-		if partitionSummary.PartitionCount > 0 {
-			inventory.PartitionedTableCount++
+	warnings := make([]string, 0, 3)
+	warn := func(message string) {
+		if message == "" {
+			return
 		}
-		inventory.TotalPartitionCount += partitionSummary.PartitionCount
-		inventory.EmptyPartitionCount += partitionSummary.EmptyPartitionCount
+		warnings = append(warnings, message)
+		if opts.OnWarning != nil {
+			opts.OnWarning(message)
+		}
+	}
+	warn(schemaAuditScanWarning(scanCollection))
 
-		dynamicProperties := scanRows[i].DynamicProperties
-		dynamicPartitionEnabled := isDynamicPartitionEnabled(dynamicProperties)
-		if dynamicPartitionEnabled {
-			inventory.DynamicPartitionTableCount++
+	now := time.Now()
+	items := make([]SchemaAuditScanItem, len(scanRows))
+	evaluate := func(i int, deepTable *schemaAuditDeepTable) {
+		items[i] = evaluateSchemaAuditScanItem(scanRows[i], deepTable, rules, opts, now)
+		if opts.OnItem != nil {
+			opts.OnItem(items[i])
 		}
+	}
 
-		findings := evaluateSchemaAuditScanFindings(partitionSummary, dynamicProperties)
-		items = append(items, SchemaAuditScanItem{
-			Database:                key.Database,
-			Table:                   key.Table,
-			PartitionCount:          partitionSummary.PartitionCount,
-			EmptyPartitionCount:     partitionSummary.EmptyPartitionCount,
-			EmptyPartitionRatio:     ratio(partitionSummary.EmptyPartitionCount, partitionSummary.PartitionCount),
-			DynamicPartitionEnabled: dynamicPartitionEnabled,
-			Score:                   computeSchemaAuditScore(findings),
-			FindingCount:            len(findings),
-			Findings:                summarizeSchemaAuditFindings(findings),
+	// summaryOnly lists the rows evaluated with summary rules alone.
+	summaryOnly := make([]int, len(scanRows))
+	for i := range summaryOnly {
+		summaryOnly[i] = i
+	}
+	var deepSummary *SchemaAuditDeepScanSummary
+	if opts.Deep {
+		started := time.Now()
+		plan := resolveSchemaAuditDeepScanPlan(opts, len(scanRows))
+		deepSummary = &SchemaAuditDeepScanSummary{
+			Concurrency:    plan.Concurrency,
+			TableTimeoutMs: plan.TableTimeout.Milliseconds(),
+			Skipped:        len(scanRows) - plan.Tables,
+		}
+		warn(schemaAuditDeepScanSkippedWarning(deepSummary.Skipped))
+		if plan.Tables < len(scanRows) {
+			summaryOnly = rankSchemaAuditDeepScanCandidates(scanRows, rules, opts, now)
+		}
+		deepRows := make([]schemaAuditScanRow, plan.Tables)
+		for k := range deepRows {
+			deepRows[k] = scanRows[summaryOnly[k]]
+		}
+		collectSchemaAuditDeepTables(ctx, db, isExternalCatalog(cfg.Catalog), deepRows, plan, func(k int, table schemaAuditDeepTable) {
+			if table.Err != nil {
+				deepSummary.Failed++
+			} else {
+				deepSummary.Evaluated++
+			}
+			evaluate(summaryOnly[k], &table)
 		})
+		deepSummary.ElapsedMs = time.Since(started).Milliseconds()
+		warn(schemaAuditDeepScanFailedWarning(deepSummary.Failed))
+		summaryOnly = summaryOnly[plan.Tables:]
+	}
+	for _, i := range summaryOnly {
+		evaluate(i, nil)
 	}
-	inventory.DatabaseCount = len(databaseSet)
-	inventory.EmptyPartitionRatio = ratio(inventory.EmptyPartitionCount, inventory.TotalPartitionCount)
 
 	sort.SliceStable(items, func(i, j int) bool {
 		if items[i].Score != items[j].Score {
@@ -143,6 +177,7 @@ func BuildSchemaAuditScan(
 	pagedItems := paginateSchemaAuditItems(items, page, pageSize)
 
 	return SchemaAuditScanResult{
+		Catalog:    strings.TrimSpace(cfg.Catalog),
 		Inventory:  inventory,
 		Items:      pagedItems,
 		Page:       page,
@@ -150,24 +185,128 @@ func BuildSchemaAuditScan(
 		TotalItems: len(items),
 		Truncated:  scanCollection.Truncated,
 		ScanLimit:  scanCollection.ScanLimit,
-		Warning:    schemaAuditScanWarning(scanCollection),
+		Warning:    strings.Join(warnings, " "),
+		Rules:      rules,
+		DeepScan:   deepSummary,
 	}, nil
 }
 
+func summarizeSchemaAuditInventory(rows []schemaAuditScanRow) SchemaAuditInventory {
+	databaseSet := make(map[string]struct{}, len(rows))
+	inventory := SchemaAuditInventory{
+		TableCount: len(rows),
+	}
+	for i := range rows {
+		databaseSet[rows[i].Key.Database] = struct{}{}
+		partitionSummary := rows[i].PartitionSummary
+		if partitionSummary.PartitionCount > 0 {
+			inventory.PartitionedTableCount++
+		}
+		inventory.TotalPartitionCount += partitionSummary.PartitionCount
+		inventory.EmptyPartitionCount += partitionSummary.EmptyPartitionCount
+		if isDynamicPartitionEnabled(rows[i].DynamicProperties) {
+			inventory.DynamicPartitionTableCount++
+		}
+	}
+	inventory.DatabaseCount = len(databaseSet)
+	inventory.EmptyPartitionRatio = ratio(inventory.EmptyPartitionCount, inventory.TotalPartitionCount)
+	return inventory
+}
+
+// evaluateSchemaAuditScanItem runs the rules for one scan row. deepTable is
+// nil for summary-only rows; a deepTable with Err also falls back to summary
+// rules and reports the error on the item.
+func evaluateSchemaAuditScanItem(
+	row schemaAuditScanRow,
+	deepTable *schemaAuditDeepTable,
+	rules SchemaAuditRuleConfig,
+	opts SchemaAuditScanOptions,
+	now time.Time,
+) SchemaAuditScanItem {
+	key := row.Key
+	partitionSummary := row.PartitionSummary
+	facts := SchemaAuditTableFacts{
+		Input:               SchemaAuditInputScanSummary,
+		PartitionCount:      partitionSummary.PartitionCount,
+		EmptyPartitionCount: partitionSummary.EmptyPartitionCount,
+		DynamicProperties:   row.DynamicProperties,
+	}
+	deep := false
+	deepError := ""
+	if deepTable != nil {
+		if deepTable.Err != nil {
+			deepError = deepTable.Err.Error()
+		} else {
+			facts = SchemaAuditTableFacts{
+				Input:             SchemaAuditInputTableDetail,
+				Partitions:        deepTable.Partitions,
+				DynamicProperties: deepTable.DynamicProperties,
+				CreateTableSQL:    deepTable.CreateTableSQL,
+			}
+			deep = true
+		}
+	}
+
+	findings := defaultSchemaAuditRules.Evaluate(facts, rules, opts.DisabledRules)
+	applySchemaAuditWaivers(findings, key.Database, key.Table, opts.Waivers, now)
+	suppressed := countSuppressedSchemaAuditFindings(findings)
+	return SchemaAuditScanItem{
+		Database:                key.Database,
+		Table:                   key.Table,
+		PartitionCount:          partitionSummary.PartitionCount,
+		EmptyPartitionCount:     partitionSummary.EmptyPartitionCount,
+		EmptyPartitionRatio:     ratio(partitionSummary.EmptyPartitionCount, partitionSummary.PartitionCount),
+		DynamicPartitionEnabled: isDynamicPartitionEnabled(row.DynamicProperties),
+		Score:                   computeSchemaAuditScore(findings),
+		FindingCount:            len(findings) - suppressed,
+		SuppressedCount:         suppressed,
+		Findings:                summarizeSchemaAuditFindings(findings),
+		Deep:                    deep,
+		DeepError:               deepError,
+	}
+}
+
+// rankSchemaAuditDeepScanCandidates orders row indexes by summary score,
+// highest first, so a capped deep scan spends its budget on the tables the
+// summary rules already flag. Ties keep the scan query's empty-partition order.
+func rankSchemaAuditDeepScanCandidates(
+	rows []schemaAuditScanRow,
+	rules SchemaAuditRuleConfig,
+	opts SchemaAuditScanOptions,
+	now time.Time,
+) []int {
+	scores := make([]int, len(rows))
+	order := make([]int, len(rows))
+	for i := range rows {
+		scores[i] = evaluateSchemaAuditScanItem(rows[i], nil, rules, opts, now).Score
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	return order
+}
+
 func BuildSchemaAuditTableDetail(
 	ctx context.Context,
 	cfg ConnConfig,
-	database string,
-	table string,
+	opts SchemaAuditTableDetailOptions,
 ) (SchemaAuditTableDetailResult, error) {
-	normalizedDatabase, err := validateSchemaAuditIdentifier(database, "database")
+	normalizedDatabase, err := validateSchemaAuditIdentifier(opts.Database, "database")
 	if err != nil {
 		return SchemaAuditTableDetailResult{}, err
 	}
-	normalizedTable, err := validateSchemaAuditIdentifier(table, "table")
+	normalizedTable, err := validateSchemaAuditIdentifier(opts.Table, "table")
 	if err != nil {
 		return SchemaAuditTableDetailResult{}, err
 	}
+	rules, err := normalizeSchemaAuditRuleConfig(opts.Rules)
+	if err != nil {
+		return SchemaAuditTableDetailResult{}, err
+	}
+	if err := defaultSchemaAuditRules.ValidateRuleIDs(opts.DisabledRules); err != nil {
+		return SchemaAuditTableDetailResult{}, err
+	}
 
 	cfg.Database = ""
 	db, err := openAndPing(ctx, cfg)
@@ -195,23 +334,32 @@ func BuildSchemaAuditTableDetail(
 		dynamicProperties[k] = v
 	}
 
+	external := isExternalCatalog(cfg.Catalog)
 	partitions, err := showSchemaAuditPartitions(ctx, db, normalizedDatabase, normalizedTable)
 	if err != nil {
-		return SchemaAuditTableDetailResult{}, err
-	}
-	indexes, err := showSchemaAuditIndexes(ctx, db, normalizedDatabase, normalizedTable)
-	if err != nil {
-		return SchemaAuditTableDetailResult{}, err
+		if !external {
+			return SchemaAuditTableDetailResult{}, err
+		}
+		// Unpartitioned external tables reject SHOW PARTITIONS.
+		partitions = []SchemaAuditPartition{}
+	}
+	indexes := []SchemaAuditIndex{}
+	if !external {
+		indexes, err = showSchemaAuditIndexes(ctx, db, normalizedDatabase, normalizedTable)
+		if err != nil {
+			return SchemaAuditTableDetailResult{}, err
+		}
 	}
 
-	bucketRuleConfig := defaultSchemaAuditBucketRuleConfig()
-	findings := evaluateSchemaAuditTableDetailFindings(
-		partitions,
-		dynamicProperties,
-		createTableSQL,
-		bucketRuleConfig,
-	)
+	findings := defaultSchemaAuditRules.Evaluate(SchemaAuditTableFacts{
+		Input:             SchemaAuditInputTableDetail,
+		Partitions:        partitions,
+		DynamicProperties: dynamicProperties,
+		CreateTableSQL:    createTableSQL,
+	}, rules, opts.DisabledRules)
+	applySchemaAuditWaivers(findings, normalizedDatabase, normalizedTable, opts.Waivers, time.Now())
 	return SchemaAuditTableDetailResult{
+		Catalog:           strings.TrimSpace(cfg.Catalog),
 		Database:          normalizedDatabase,
 		Table:             normalizedTable,
 		CreateTableSQL:    createTableSQL,
@@ -219,9 +367,17 @@ func BuildSchemaAuditTableDetail(
 		Partitions:        partitions,
 		Indexes:           indexes,
 		Findings:          findings,
+		Rules:             rules,
 	}, nil
 }
 
+// isExternalCatalog reports whether catalog names a Hive, Iceberg or other
+// non-internal catalog, where Doris-only metadata such as indexes is absent.
+func isExternalCatalog(catalog string) bool {
+	catalog = strings.TrimSpace(catalog)
+	return catalog != "" && !strings.EqualFold(catalog, "internal")
+}
+
 func normalizePagination(page int, pageSize int) (int, int) {
 	normalizedPage := page
 	if normalizedPage <= 0 {
@@ -796,6 +952,7 @@ func parseDynamicPartitionPropertiesFromCreateTable(createTableSQL string) map[s
 func evaluateSchemaAuditScanFindings(
 	summary schemaAuditPartitionSummary,
 	dynamicProperties map[string]string,
+	rules SchemaAuditRuleConfig,
 ) []SchemaAuditFinding {
 	if summary.PartitionCount == 0 {
 		return nil
@@ -807,9 +964,9 @@ func evaluateSchemaAuditScanFindings(
 	findings := make([]SchemaAuditFinding, 0, 2)
 	dynamicWindowSpan, hasDynamicWindowSpan := schemaAuditDynamicWindowSpan(dynamicProperties)
 
-	if emptyRatio >= schemaAuditEmptyRatioWarn {
+	if emptyRatio >= rules.EmptyRatioWarn {
 		severity := "warn"
-		if emptyRatio >= schemaAuditEmptyRatioCritical {
+		if emptyRatio >= rules.EmptyRatioCritical {
 			severity = "critical"
 		}
 		confidence := 0.95
@@ -828,15 +985,15 @@ func evaluateSchemaAuditScanFindings(
 				"futurePartitionUncertain": futureUncertain,
 				"potentialFutureWindow":    futureWindow,
 				"futureExclusionSource":    "scan_summary_no_exclusion",
-				"warnThreshold":            schemaAuditEmptyRatioWarn,
-				"criticalThreshold":        schemaAuditEmptyRatioCritical,
+				"warnThreshold":            rules.EmptyRatioWarn,
+				"criticalThreshold":        rules.EmptyRatioCritical,
 				"partitionTailKnown":       false,
 			},
 			Recommendation: "Reduce dynamic partition window and clean long-term empty partitions.",
 		})
 	}
 
-	if isDynamicPartitionEnabled(dynamicProperties) && emptyRatio >= schemaAuditEmptyRatioCritical {
+	if isDynamicPartitionEnabled(dynamicProperties) && emptyRatio >= rules.EmptyRatioCritical {
 		confidence := 0.9
 		if futureUncertain {
 			confidence = 0.65
@@ -852,8 +1009,8 @@ func evaluateSchemaAuditScanFindings(
 			"start":                    dynamicProperties["dynamic_partition.start"],
 			"end":                      dynamicProperties["dynamic_partition.end"],
 			"buckets":                  dynamicProperties["dynamic_partition.buckets"],
-			"windowSpanWarn":           schemaAuditDynamicWindowSpanWarn,
-			"windowSpanCritical":       schemaAuditDynamicWindowSpanCritical,
+			"windowSpanWarn":           rules.DynamicWindowSpanWarn,
+			"windowSpanCritical":       rules.DynamicWindowSpanCritical,
 		}
 		if hasDynamicWindowSpan {
 			evidence["windowSpan"] = dynamicWindowSpan
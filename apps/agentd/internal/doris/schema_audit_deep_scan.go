@@ -0,0 +1,171 @@
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	SchemaAuditDeepScanDefaultConcurrency = 4
+	SchemaAuditDeepScanMaxConcurrency     = 16
+	// SchemaAuditDeepScanMaxTables caps the tables a deep scan fetches; the
+	// rest of the candidates keep summary-only findings.
+	SchemaAuditDeepScanMaxTables       = 2000
+	SchemaAuditDeepScanMaxTableTimeout = 60 * time.Second
+
+	schemaAuditDeepScanTableTimeout = 10 * time.Second
+)
+
+// schemaAuditDeepTable holds the table-detail metadata fetched for one scan
+// row. Err is set when the fetch failed and the row falls back to summary
+// rules.
+type schemaAuditDeepTable struct {
+	Partitions        []SchemaAuditPartition
+	CreateTableSQL    string
+	DynamicProperties map[string]string
+	Err               error
+}
+
+type schemaAuditDeepScanPlan struct {
+	Concurrency  int
+	TableTimeout time.Duration
+	Tables       int
+}
+
+func resolveSchemaAuditDeepScanPlan(opts SchemaAuditScanOptions, rowCount int) schemaAuditDeepScanPlan {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = SchemaAuditDeepScanDefaultConcurrency
+	}
+	if concurrency > SchemaAuditDeepScanMaxConcurrency {
+		concurrency = SchemaAuditDeepScanMaxConcurrency
+	}
+	tables := rowCount
+	if tables > SchemaAuditDeepScanMaxTables {
+		tables = SchemaAuditDeepScanMaxTables
+	}
+	if concurrency > tables {
+		concurrency = tables
+	}
+	tableTimeout := opts.TableTimeout
+	if tableTimeout <= 0 {
+		tableTimeout = schemaAuditDeepScanTableTimeout
+	}
+	if tableTimeout > SchemaAuditDeepScanMaxTableTimeout {
+		tableTimeout = SchemaAuditDeepScanMaxTableTimeout
+	}
+	return schemaAuditDeepScanPlan{
+		Concurrency:  concurrency,
+		TableTimeout: tableTimeout,
+		Tables:       tables,
+	}
+}
+
+// collectSchemaAuditDeepTables runs SHOW CREATE TABLE and SHOW PARTITIONS for
+// the first plan.Tables rows with at most plan.Concurrency tables in flight,
+// handing each table to onTable as soon as it is fetched. onTable runs on the
+// calling goroutine, one table at a time. Callers pass rows highest summary
+// score first, so a capped scan still covers the likeliest offenders. A
+// failing table never fails the scan.
+func collectSchemaAuditDeepTables(
+	ctx context.Context,
+	db *sql.DB,
+	external bool,
+	rows []schemaAuditScanRow,
+	plan schemaAuditDeepScanPlan,
+	onTable func(i int, table schemaAuditDeepTable),
+) {
+	if plan.Tables == 0 {
+		return
+	}
+	db.SetMaxOpenConns(plan.Concurrency)
+	db.SetMaxIdleConns(plan.Concurrency)
+
+	type fetched struct {
+		index int
+		table schemaAuditDeepTable
+	}
+	next := make(chan int)
+	done := make(chan fetched)
+	var wg sync.WaitGroup
+	for w := 0; w < plan.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				done <- fetched{index: i, table: collectSchemaAuditDeepTable(ctx, db, external, rows[i], plan.TableTimeout)}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < plan.Tables; i++ {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+		close(done)
+	}()
+	for f := range done {
+		onTable(f.index, f.table)
+	}
+}
+
+func collectSchemaAuditDeepTable(
+	ctx context.Context,
+	db *sql.DB,
+	external bool,
+	row schemaAuditScanRow,
+	timeout time.Duration,
+) schemaAuditDeepTable {
+	if err := ctx.Err(); err != nil {
+		return schemaAuditDeepTable{Err: err}
+	}
+	tableCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	createTableSQL, err := showSchemaAuditCreateTableSQL(tableCtx, db, row.Key.Database, row.Key.Table)
+	if err != nil {
+		return schemaAuditDeepTable{Err: err}
+	}
+	partitions, err := showSchemaAuditPartitions(tableCtx, db, row.Key.Database, row.Key.Table)
+	if err != nil {
+		if !external {
+			return schemaAuditDeepTable{Err: err}
+		}
+		// Unpartitioned external tables reject SHOW PARTITIONS.
+		partitions = []SchemaAuditPartition{}
+	}
+
+	// The scan already read table_properties; CREATE TABLE fills in what the
+	// summary query could not see.
+	dynamicProperties := parseDynamicPartitionPropertiesFromCreateTable(createTableSQL)
+	for k, v := range row.DynamicProperties {
+		dynamicProperties[k] = v
+	}
+	return schemaAuditDeepTable{
+		Partitions:        partitions,
+		CreateTableSQL:    createTableSQL,
+		DynamicProperties: dynamicProperties,
+	}
+}
+
+func schemaAuditDeepScanSkippedWarning(skipped int) string {
+	if skipped <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Deep scan covers the %d candidate tables with the highest summary scores; %d more are ranked with summary rules only.",
+		SchemaAuditDeepScanMaxTables,
+		skipped,
+	)
+}
+
+func schemaAuditDeepScanFailedWarning(failed int) string {
+	if failed <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("Deep scan failed on %d tables; they are ranked with summary rules only.", failed)
+}
@@ -0,0 +1,119 @@
+package doris
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveSchemaAuditDeepScanPlan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts SchemaAuditScanOptions
+		rows int
+		want schemaAuditDeepScanPlan
+	}{
+		{
+			name: "defaults",
+			rows: 100,
+			want: schemaAuditDeepScanPlan{Concurrency: SchemaAuditDeepScanDefaultConcurrency, TableTimeout: schemaAuditDeepScanTableTimeout, Tables: 100},
+		},
+		{
+			name: "clamped to maximums",
+			opts: SchemaAuditScanOptions{Concurrency: 100, TableTimeout: time.Hour},
+			rows: SchemaAuditDeepScanMaxTables + 5,
+			want: schemaAuditDeepScanPlan{Concurrency: SchemaAuditDeepScanMaxConcurrency, TableTimeout: SchemaAuditDeepScanMaxTableTimeout, Tables: SchemaAuditDeepScanMaxTables},
+		},
+		{
+			name: "no more workers than tables",
+			opts: SchemaAuditScanOptions{Concurrency: 8, TableTimeout: 2 * time.Second},
+			rows: 3,
+			want: schemaAuditDeepScanPlan{Concurrency: 3, TableTimeout: 2 * time.Second, Tables: 3},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := resolveSchemaAuditDeepScanPlan(tc.opts, tc.rows); got != tc.want {
+				t.Fatalf("unexpected plan: got %+v want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollectSchemaAuditDeepTablesReportsPerTableErrors(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(ConnConfig{Host: "127.0.0.1", Port: 1, User: "u"})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+
+	rows := []schemaAuditScanRow{
+		{Key: schemaAuditTableKey{Database: "db1", Table: "t1"}},
+		{Key: schemaAuditTableKey{Database: "db1", Table: "t2"}},
+		{Key: schemaAuditTableKey{Database: "db2", Table: "t3"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	plan := resolveSchemaAuditDeepScanPlan(SchemaAuditScanOptions{Concurrency: 2}, len(rows))
+	seen := make(map[int]bool, len(rows))
+	collectSchemaAuditDeepTables(ctx, db, false, rows, plan, func(i int, table schemaAuditDeepTable) {
+		if seen[i] {
+			t.Fatalf("table %d reported twice", i)
+		}
+		seen[i] = true
+		if !errors.Is(table.Err, context.Canceled) {
+			t.Fatalf("table %d: expected context.Canceled, got %v", i, table.Err)
+		}
+	})
+	if len(seen) != len(rows) {
+		t.Fatalf("expected %d tables, got %d", len(rows), len(seen))
+	}
+}
+
+func TestRankSchemaAuditDeepScanCandidatesBySummaryScore(t *testing.T) {
+	t.Parallel()
+
+	row := func(table string, partitions int, empty int) schemaAuditScanRow {
+		return schemaAuditScanRow{
+			Key:              schemaAuditTableKey{Database: "db1", Table: table},
+			PartitionSummary: schemaAuditPartitionSummary{PartitionCount: partitions, EmptyPartitionCount: empty},
+		}
+	}
+	// Listed in information_schema order, not by risk.
+	rows := []schemaAuditScanRow{
+		row("a_clean", 10, 0),
+		row("b_clean", 10, 0),
+		row("c_mostly_empty", 10, 9),
+	}
+	got := rankSchemaAuditDeepScanCandidates(rows, DefaultSchemaAuditRuleConfig(), SchemaAuditScanOptions{}, time.Now())
+	want := []int{2, 0, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected candidate order: got %v want %v", got, want)
+		}
+	}
+}
+
+func TestSchemaAuditDeepScanWarnings(t *testing.T) {
+	t.Parallel()
+
+	if got := schemaAuditDeepScanSkippedWarning(0) + schemaAuditDeepScanFailedWarning(0); got != "" {
+		t.Fatalf("expected no warning for a complete deep scan, got %q", got)
+	}
+	if got := schemaAuditDeepScanSkippedWarning(7); !strings.Contains(got, "7 more are ranked") {
+		t.Fatalf("unexpected skipped warning: %q", got)
+	}
+	if got := schemaAuditDeepScanFailedWarning(2); !strings.Contains(got, "failed on 2 tables") {
+		t.Fatalf("unexpected failed warning: %q", got)
+	}
+}
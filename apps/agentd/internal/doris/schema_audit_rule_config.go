@@ -0,0 +1,209 @@
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaAuditProfileDefault is the profile used when a request names none.
+const SchemaAuditProfileDefault = "default"
+
+// ErrInvalidSchemaAuditRuleConfig marks rule configuration validation errors.
+var ErrInvalidSchemaAuditRuleConfig = errors.New("invalid schema audit rule config")
+
+// SchemaAuditRuleConfig holds the thresholds used by schema audit rules.
+// Zero fields fall back to the default profile.
+type SchemaAuditRuleConfig struct {
+	EmptyRatioWarn            float64 `json:"emptyRatioWarn,omitempty"`
+	EmptyRatioCritical        float64 `json:"emptyRatioCritical,omitempty"`
+	EmptyTailThreshold        int     `json:"emptyTailThreshold,omitempty"`
+	DynamicWindowSpanWarn     int     `json:"dynamicWindowSpanWarn,omitempty"`
+	DynamicWindowSpanCritical int     `json:"dynamicWindowSpanCritical,omitempty"`
+	TabletSizeMinBytes        uint64  `json:"tabletSizeMinBytes,omitempty"`
+	TabletSizeMaxBytes        uint64  `json:"tabletSizeMaxBytes,omitempty"`
+	AutoBucketMinBuckets      int     `json:"autoBucketMinBuckets,omitempty"`
+	AutoBucketMaxBuckets      int     `json:"autoBucketMaxBuckets,omitempty"`
+	// PartitionSizePerBucketGB pins the bucket estimate to one size per
+	// bucket; negative uses the adaptive classic/storage-compute range.
+	PartitionSizePerBucketGB int     `json:"partitionSizePerBucketGB,omitempty"`
+	BucketOutOfBoundsRatio   float64 `json:"bucketOutOfBoundsRatio,omitempty"`
+}
+
+var schemaAuditRuleProfiles = map[string]SchemaAuditRuleConfig{
+	SchemaAuditProfileDefault: {
+		EmptyRatioWarn:            0.3,
+		EmptyRatioCritical:        0.6,
+		EmptyTailThreshold:        7,
+		DynamicWindowSpanWarn:     32,
+		DynamicWindowSpanCritical: 64,
+		TabletSizeMinBytes:        1 * schemaAuditBucketSize1GB,
+		TabletSizeMaxBytes:        10 * schemaAuditBucketSize1GB,
+		AutoBucketMinBuckets:      schemaAuditDefaultAutoBucketMinBuckets,
+		AutoBucketMaxBuckets:      schemaAuditDefaultAutoBucketMaxBuckets,
+		PartitionSizePerBucketGB:  -1,
+		BucketOutOfBoundsRatio:    schemaAuditDefaultAutoBucketOutOfBoundsRatio,
+	},
+	// strict flags smaller layout drift, for clusters with tight storage budgets.
+	"strict": {
+		EmptyRatioWarn:            0.2,
+		EmptyRatioCritical:        0.4,
+		EmptyTailThreshold:        3,
+		DynamicWindowSpanWarn:     16,
+		DynamicWindowSpanCritical: 32,
+		TabletSizeMinBytes:        1 * schemaAuditBucketSize1GB,
+		TabletSizeMaxBytes:        5 * schemaAuditBucketSize1GB,
+		AutoBucketMinBuckets:      schemaAuditDefaultAutoBucketMinBuckets,
+		AutoBucketMaxBuckets:      schemaAuditDefaultAutoBucketMaxBuckets,
+		PartitionSizePerBucketGB:  -1,
+		BucketOutOfBoundsRatio:    0.3,
+	},
+	// analytics-cold-data tolerates pre-created and long-retention partitions
+	// that stay empty or small for most of their life.
+	"analytics-cold-data": {
+		EmptyRatioWarn:            0.5,
+		EmptyRatioCritical:        0.8,
+		EmptyTailThreshold:        30,
+		DynamicWindowSpanWarn:     128,
+		DynamicWindowSpanCritical: 400,
+		TabletSizeMinBytes:        512 * 1024 * 1024,
+		TabletSizeMaxBytes:        20 * schemaAuditBucketSize1GB,
+		AutoBucketMinBuckets:      schemaAuditDefaultAutoBucketMinBuckets,
+		AutoBucketMaxBuckets:      schemaAuditDefaultAutoBucketMaxBuckets,
+		PartitionSizePerBucketGB:  -1,
+		BucketOutOfBoundsRatio:    0.7,
+	},
+}
+
+// DefaultSchemaAuditRuleConfig returns the thresholds of the default profile.
+func DefaultSchemaAuditRuleConfig() SchemaAuditRuleConfig {
+	return schemaAuditRuleProfiles[SchemaAuditProfileDefault]
+}
+
+// SchemaAuditRuleProfiles returns a copy of the built-in rule profiles.
+func SchemaAuditRuleProfiles() map[string]SchemaAuditRuleConfig {
+	out := make(map[string]SchemaAuditRuleConfig, len(schemaAuditRuleProfiles))
+	for name, cfg := range schemaAuditRuleProfiles {
+		out[name] = cfg
+	}
+	return out
+}
+
+// SchemaAuditRuleProfileNames returns the sorted names of profiles.
+func SchemaAuditRuleProfileNames(profiles map[string]SchemaAuditRuleConfig) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithDefaults fills zero fields from the default profile.
+func (c SchemaAuditRuleConfig) WithDefaults() SchemaAuditRuleConfig {
+	def := DefaultSchemaAuditRuleConfig()
+	if c.EmptyRatioWarn == 0 {
+		c.EmptyRatioWarn = def.EmptyRatioWarn
+	}
+	if c.EmptyRatioCritical == 0 {
+		c.EmptyRatioCritical = def.EmptyRatioCritical
+	}
+	if c.EmptyTailThreshold == 0 {
+		c.EmptyTailThreshold = def.EmptyTailThreshold
+	}
+	if c.DynamicWindowSpanWarn == 0 {
+		c.DynamicWindowSpanWarn = def.DynamicWindowSpanWarn
+	}
+	if c.DynamicWindowSpanCritical == 0 {
+		c.DynamicWindowSpanCritical = def.DynamicWindowSpanCritical
+	}
+	if c.TabletSizeMinBytes == 0 {
+		c.TabletSizeMinBytes = def.TabletSizeMinBytes
+	}
+	if c.TabletSizeMaxBytes == 0 {
+		c.TabletSizeMaxBytes = def.TabletSizeMaxBytes
+	}
+	if c.AutoBucketMinBuckets == 0 {
+		c.AutoBucketMinBuckets = def.AutoBucketMinBuckets
+	}
+	if c.AutoBucketMaxBuckets == 0 {
+		c.AutoBucketMaxBuckets = def.AutoBucketMaxBuckets
+	}
+	if c.PartitionSizePerBucketGB == 0 {
+		c.PartitionSizePerBucketGB = def.PartitionSizePerBucketGB
+	}
+	if c.BucketOutOfBoundsRatio == 0 {
+		c.BucketOutOfBoundsRatio = def.BucketOutOfBoundsRatio
+	}
+	return c
+}
+
+// Validate reports the first inconsistent threshold. Call it on a config
+// returned by WithDefaults.
+func (c SchemaAuditRuleConfig) Validate() error {
+	switch {
+	case c.EmptyRatioWarn <= 0 || c.EmptyRatioWarn > 1:
+		return invalidSchemaAuditRuleConfig("emptyRatioWarn must be in (0, 1]")
+	case c.EmptyRatioCritical <= 0 || c.EmptyRatioCritical > 1:
+		return invalidSchemaAuditRuleConfig("emptyRatioCritical must be in (0, 1]")
+	case c.EmptyRatioCritical < c.EmptyRatioWarn:
+		return invalidSchemaAuditRuleConfig("emptyRatioCritical must be >= emptyRatioWarn")
+	case c.EmptyTailThreshold < 1:
+		return invalidSchemaAuditRuleConfig("emptyTailThreshold must be >= 1")
+	case c.DynamicWindowSpanWarn < 1:
+		return invalidSchemaAuditRuleConfig("dynamicWindowSpanWarn must be >= 1")
+	case c.DynamicWindowSpanCritical <= c.DynamicWindowSpanWarn:
+		return invalidSchemaAuditRuleConfig("dynamicWindowSpanCritical must be > dynamicWindowSpanWarn")
+	case c.TabletSizeMaxBytes <= c.TabletSizeMinBytes:
+		return invalidSchemaAuditRuleConfig("tabletSizeMaxBytes must be > tabletSizeMinBytes")
+	case c.AutoBucketMinBuckets < 1:
+		return invalidSchemaAuditRuleConfig("autoBucketMinBuckets must be >= 1")
+	case c.AutoBucketMaxBuckets < c.AutoBucketMinBuckets:
+		return invalidSchemaAuditRuleConfig("autoBucketMaxBuckets must be >= autoBucketMinBuckets")
+	case c.BucketOutOfBoundsRatio <= 0 || c.BucketOutOfBoundsRatio > 0.95:
+		return invalidSchemaAuditRuleConfig("bucketOutOfBoundsRatio must be in (0, 0.95]")
+	}
+	return nil
+}
+
+func invalidSchemaAuditRuleConfig(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSchemaAuditRuleConfig, detail)
+}
+
+// normalizeSchemaAuditRuleConfig fills defaults and validates.
+func normalizeSchemaAuditRuleConfig(c SchemaAuditRuleConfig) (SchemaAuditRuleConfig, error) {
+	c = c.WithDefaults()
+	if err := c.Validate(); err != nil {
+		return SchemaAuditRuleConfig{}, err
+	}
+	return c, nil
+}
+
+func (c SchemaAuditRuleConfig) bucketRuleConfig() schemaAuditBucketRuleConfig {
+	return schemaAuditBucketRuleConfig{
+		MinBuckets:               c.AutoBucketMinBuckets,
+		MaxBuckets:               c.AutoBucketMaxBuckets,
+		PartitionSizePerBucketGB: c.PartitionSizePerBucketGB,
+		OutOfBoundsRatio:         c.BucketOutOfBoundsRatio,
+		TabletSizeMinBytes:       c.TabletSizeMinBytes,
+		TabletSizeMaxBytes:       c.TabletSizeMaxBytes,
+	}
+}
+
+// LookupSchemaAuditRuleProfile returns the named profile; an empty name
+// selects the default profile.
+func LookupSchemaAuditRuleProfile(
+	profiles map[string]SchemaAuditRuleConfig,
+	name string,
+) (SchemaAuditRuleConfig, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = SchemaAuditProfileDefault
+	}
+	cfg, ok := profiles[name]
+	if !ok {
+		return SchemaAuditRuleConfig{}, errors.New("unknown schema audit profile: " + name)
+	}
+	return cfg, nil
+}
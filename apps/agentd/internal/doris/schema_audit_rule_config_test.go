@@ -0,0 +1,103 @@
+package doris
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchemaAuditRuleProfilesAreValid(t *testing.T) {
+	t.Parallel()
+
+	for name, rules := range SchemaAuditRuleProfiles() {
+		if err := rules.Validate(); err != nil {
+			t.Fatalf("profile %s is invalid: %v", name, err)
+		}
+		if rules.WithDefaults() != rules {
+			t.Fatalf("profile %s leaves fields to defaults: %+v", name, rules)
+		}
+	}
+}
+
+func TestSchemaAuditRuleConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rules   SchemaAuditRuleConfig
+		wantErr string
+	}{
+		{name: "zero value uses defaults", rules: SchemaAuditRuleConfig{}},
+		{name: "ratio above one", rules: SchemaAuditRuleConfig{EmptyRatioWarn: 1.5}, wantErr: "emptyRatioWarn must be in (0, 1]"},
+		{name: "critical below warn", rules: SchemaAuditRuleConfig{EmptyRatioWarn: 0.5, EmptyRatioCritical: 0.4}, wantErr: "emptyRatioCritical must be >= emptyRatioWarn"},
+		{name: "negative tail", rules: SchemaAuditRuleConfig{EmptyTailThreshold: -1}, wantErr: "emptyTailThreshold must be >= 1"},
+		{name: "window span order", rules: SchemaAuditRuleConfig{DynamicWindowSpanWarn: 64, DynamicWindowSpanCritical: 64}, wantErr: "dynamicWindowSpanCritical must be > dynamicWindowSpanWarn"},
+		{name: "tablet range order", rules: SchemaAuditRuleConfig{TabletSizeMinBytes: 20 * schemaAuditBucketSize1GB}, wantErr: "tabletSizeMaxBytes must be > tabletSizeMinBytes"},
+		{name: "bucket ratio", rules: SchemaAuditRuleConfig{BucketOutOfBoundsRatio: 1}, wantErr: "bucketOutOfBoundsRatio must be in (0, 0.95]"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := normalizeSchemaAuditRuleConfig(tc.rules)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if !errors.Is(err, ErrInvalidSchemaAuditRuleConfig) {
+				t.Fatalf("expected ErrInvalidSchemaAuditRuleConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLookupSchemaAuditRuleProfile(t *testing.T) {
+	t.Parallel()
+
+	profiles := SchemaAuditRuleProfiles()
+	rules, err := LookupSchemaAuditRuleProfile(profiles, "")
+	if err != nil || rules != DefaultSchemaAuditRuleConfig() {
+		t.Fatalf("expected default profile, got %+v err=%v", rules, err)
+	}
+	if _, err := LookupSchemaAuditRuleProfile(profiles, "missing"); err == nil {
+		t.Fatalf("expected unknown profile error")
+	}
+}
+
+func TestSchemaAuditRuleConfigChangesFindings(t *testing.T) {
+	t.Parallel()
+
+	summary := schemaAuditPartitionSummary{PartitionCount: 10, EmptyPartitionCount: 4}
+	if findings := evaluateSchemaAuditScanFindings(summary, nil, DefaultSchemaAuditRuleConfig()); !hasSchemaAuditRule(findings, "SA-E001") {
+		t.Fatalf("expected SA-E001 with default profile, got %+v", findings)
+	}
+	cold := SchemaAuditRuleProfiles()["analytics-cold-data"]
+	if findings := evaluateSchemaAuditScanFindings(summary, nil, cold); hasSchemaAuditRule(findings, "SA-E001") {
+		t.Fatalf("expected no SA-E001 with analytics-cold-data profile, got %+v", findings)
+	}
+
+	partitions := []SchemaAuditPartition{
+		{Name: "p1", Rows: 10, DataSizeBytes: 8 * schemaAuditBucketSize1GB, Buckets: 2},
+		{Name: "p2", Rows: 10, DataSizeBytes: 8 * schemaAuditBucketSize1GB, Buckets: 2},
+	}
+	if findings := evaluateSchemaAuditBucketBestPracticeFindings(partitions, defaultSchemaAuditBucketRuleConfig()); len(findings) != 0 {
+		t.Fatalf("expected 4GB tablets to pass default range, got %+v", findings)
+	}
+	strict := SchemaAuditRuleProfiles()["strict"].bucketRuleConfig()
+	strict.TabletSizeMaxBytes = 2 * schemaAuditBucketSize1GB
+	findings := evaluateSchemaAuditBucketBestPracticeFindings(partitions, strict)
+	finding, ok := schemaAuditFindingByRule(findings, "SA-B007")
+	if !ok {
+		t.Fatalf("expected SA-B007 with 1-2GB range, got %+v", findings)
+	}
+	if !strings.Contains(finding.Summary, "1-2GB") {
+		t.Fatalf("expected configured range in summary, got %q", finding.Summary)
+	}
+}
@@ -0,0 +1,415 @@
+package doris
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Inputs a schema audit rule can be evaluated on.
+const (
+	// SchemaAuditInputScanSummary is the per-table information_schema summary
+	// used by BuildSchemaAuditScan.
+	SchemaAuditInputScanSummary = "scan_summary"
+	// SchemaAuditInputTableDetail is SHOW PARTITIONS plus SHOW CREATE TABLE,
+	// as used by BuildSchemaAuditTableDetail.
+	SchemaAuditInputTableDetail = "table_detail"
+)
+
+const (
+	schemaAuditDocsDynamicPartition = "https://doris.apache.org/docs/table-design/data-partitioning/dynamic-partitioning"
+	schemaAuditDocsBucketing        = "https://doris.apache.org/docs/table-design/data-partitioning/data-bucketing"
+	schemaAuditDocsDataModel        = "https://doris.apache.org/docs/table-design/data-model/overview"
+
+	schemaAuditDefaultRuleWeight = 0.65
+	schemaAuditDefaultRuleImpact = 0.60
+)
+
+// SchemaAuditRuleMeta describes one rule ID.
+type SchemaAuditRuleMeta struct {
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	Category        string   `json:"category"`
+	DefaultSeverity string   `json:"defaultSeverity"`
+	Weight          float64  `json:"weight"`
+	Inputs          []string `json:"inputs"`
+	DocsURL         string   `json:"docsUrl,omitempty"`
+}
+
+// SchemaAuditTableFacts is the metadata of one table handed to rule sets.
+// Input tells which of the fields are populated.
+type SchemaAuditTableFacts struct {
+	Input               string
+	PartitionCount      int
+	EmptyPartitionCount int
+	Partitions          []SchemaAuditPartition
+	DynamicProperties   map[string]string
+	CreateTableSQL      string
+}
+
+// SchemaAuditRuleSet evaluates a group of rules that share intermediate
+// results, such as the bucket estimate behind SA-B001 and SA-B002.
+type SchemaAuditRuleSet interface {
+	Rules() []SchemaAuditRuleMeta
+	Evaluate(facts SchemaAuditTableFacts, cfg SchemaAuditRuleConfig) []SchemaAuditFinding
+	// Impact scales the score contribution of one of the set's findings by
+	// how much of the table its evidence covers, in (0, 1].
+	Impact(finding SchemaAuditFinding) float64
+}
+
+// SchemaAuditRuleRegistry holds rule sets and the metadata of their rules.
+type SchemaAuditRuleRegistry struct {
+	sets   []SchemaAuditRuleSet
+	rules  []SchemaAuditRuleMeta
+	owners []SchemaAuditRuleSet
+	byID   map[string]int
+}
+
+// NewSchemaAuditRuleRegistry registers sets in order. Rule IDs must be unique.
+func NewSchemaAuditRuleRegistry(sets ...SchemaAuditRuleSet) (*SchemaAuditRuleRegistry, error) {
+	registry := &SchemaAuditRuleRegistry{byID: make(map[string]int)}
+	for _, set := range sets {
+		for _, meta := range set.Rules() {
+			id := normalizeSchemaAuditRuleID(meta.ID)
+			if id == "" {
+				return nil, errors.New("schema audit rule id is required")
+			}
+			if _, ok := registry.byID[id]; ok {
+				return nil, errors.New("duplicate schema audit rule id: " + id)
+			}
+			if len(meta.Inputs) == 0 {
+				return nil, errors.New("schema audit rule " + id + " declares no inputs")
+			}
+			meta.ID = id
+			registry.byID[id] = len(registry.rules)
+			registry.rules = append(registry.rules, meta)
+			registry.owners = append(registry.owners, set)
+		}
+		registry.sets = append(registry.sets, set)
+	}
+	return registry, nil
+}
+
+var defaultSchemaAuditRules = mustSchemaAuditRuleRegistry(
+	schemaAuditEmptyPartitionRuleSet{},
+	schemaAuditBucketRuleSet{},
+)
+
+func mustSchemaAuditRuleRegistry(sets ...SchemaAuditRuleSet) *SchemaAuditRuleRegistry {
+	registry, err := NewSchemaAuditRuleRegistry(sets...)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// DefaultSchemaAuditRuleRegistry returns the built-in rules.
+func DefaultSchemaAuditRuleRegistry() *SchemaAuditRuleRegistry {
+	return defaultSchemaAuditRules
+}
+
+// Rules returns rule metadata in registration order.
+func (r *SchemaAuditRuleRegistry) Rules() []SchemaAuditRuleMeta {
+	out := make([]SchemaAuditRuleMeta, len(r.rules))
+	for i := range r.rules {
+		out[i] = r.rules[i]
+		out[i].Inputs = slices.Clone(r.rules[i].Inputs)
+	}
+	return out
+}
+
+// Rule looks up one rule by ID.
+func (r *SchemaAuditRuleRegistry) Rule(id string) (SchemaAuditRuleMeta, bool) {
+	i, ok := r.byID[normalizeSchemaAuditRuleID(id)]
+	if !ok {
+		return SchemaAuditRuleMeta{}, false
+	}
+	return r.rules[i], true
+}
+
+// Weight returns the scoring weight of a rule; unknown rules get a neutral
+// weight.
+func (r *SchemaAuditRuleRegistry) Weight(id string) float64 {
+	if meta, ok := r.Rule(id); ok {
+		return meta.Weight
+	}
+	return schemaAuditDefaultRuleWeight
+}
+
+// Impact returns the impact factor the owning rule set assigns to finding;
+// findings of unknown rules get a neutral impact.
+func (r *SchemaAuditRuleRegistry) Impact(finding SchemaAuditFinding) float64 {
+	i, ok := r.byID[normalizeSchemaAuditRuleID(finding.RuleID)]
+	if !ok {
+		return schemaAuditDefaultRuleImpact
+	}
+	return r.owners[i].Impact(finding)
+}
+
+// ValidateRuleIDs rejects IDs that are not registered.
+func (r *SchemaAuditRuleRegistry) ValidateRuleIDs(ids []string) error {
+	for _, id := range ids {
+		if _, ok := r.Rule(id); !ok {
+			return invalidSchemaAuditRuleConfig("unknown rule: " + strings.TrimSpace(id))
+		}
+	}
+	return nil
+}
+
+// Evaluate runs every set that has an enabled rule for facts.Input and
+// drops findings of disabled rules.
+func (r *SchemaAuditRuleRegistry) Evaluate(
+	facts SchemaAuditTableFacts,
+	cfg SchemaAuditRuleConfig,
+	disabled []string,
+) []SchemaAuditFinding {
+	disabledSet := make(map[string]struct{}, len(disabled))
+	for _, id := range disabled {
+		disabledSet[normalizeSchemaAuditRuleID(id)] = struct{}{}
+	}
+	isEnabled := func(meta SchemaAuditRuleMeta) bool {
+		_, off := disabledSet[normalizeSchemaAuditRuleID(meta.ID)]
+		return !off && slices.Contains(meta.Inputs, facts.Input)
+	}
+
+	findings := make([]SchemaAuditFinding, 0, 4)
+	for _, set := range r.sets {
+		if !slices.ContainsFunc(set.Rules(), isEnabled) {
+			continue
+		}
+		for _, finding := range set.Evaluate(facts, cfg) {
+			meta, ok := r.Rule(finding.RuleID)
+			if ok && !isEnabled(meta) {
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func normalizeSchemaAuditRuleID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+var (
+	schemaAuditBothInputs  = []string{SchemaAuditInputScanSummary, SchemaAuditInputTableDetail}
+	schemaAuditDetailInput = []string{SchemaAuditInputTableDetail}
+)
+
+type schemaAuditEmptyPartitionRuleSet struct{}
+
+func (schemaAuditEmptyPartitionRuleSet) Rules() []SchemaAuditRuleMeta {
+	return []SchemaAuditRuleMeta{
+		{
+			ID:              "SA-E001",
+			Title:           "High empty partition ratio",
+			Category:        "partition",
+			DefaultSeverity: "warn",
+			Weight:          0.95,
+			Inputs:          schemaAuditBothInputs,
+			DocsURL:         schemaAuditDocsDynamicPartition,
+		},
+		{
+			ID:              "SA-E002",
+			Title:           "Empty partitions at the latest tail",
+			Category:        "partition",
+			DefaultSeverity: "warn",
+			Weight:          0.80,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsDynamicPartition,
+		},
+		{
+			ID:              "SA-D004",
+			Title:           "Dynamic partition window creates mostly empty partitions",
+			Category:        "dynamic_partition",
+			DefaultSeverity: "warn",
+			Weight:          0.85,
+			Inputs:          schemaAuditBothInputs,
+			DocsURL:         schemaAuditDocsDynamicPartition,
+		},
+	}
+}
+
+func (schemaAuditEmptyPartitionRuleSet) Evaluate(
+	facts SchemaAuditTableFacts,
+	cfg SchemaAuditRuleConfig,
+) []SchemaAuditFinding {
+	if facts.Input == SchemaAuditInputTableDetail {
+		return evaluateSchemaAuditFindings(facts.Partitions, facts.DynamicProperties, cfg)
+	}
+	return evaluateSchemaAuditScanFindings(
+		schemaAuditPartitionSummary{
+			PartitionCount:      facts.PartitionCount,
+			EmptyPartitionCount: facts.EmptyPartitionCount,
+		},
+		facts.DynamicProperties,
+		cfg,
+	)
+}
+
+func (schemaAuditEmptyPartitionRuleSet) Impact(finding SchemaAuditFinding) float64 {
+	evidence := finding.Evidence
+	switch normalizeSchemaAuditRuleID(finding.RuleID) {
+	case "SA-E001":
+		if ratio, ok := schemaAuditEvidenceNumber(evidence, "emptyRatio"); ok {
+			return schemaAuditClampFloat(0.25+0.75*ratio, 0.25, 1)
+		}
+		return 0.70
+	case "SA-D004":
+		impact := 0.65
+		if ratio, ok := schemaAuditEvidenceNumber(evidence, "emptyRatio"); ok {
+			impact = schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
+		}
+		impact *= schemaAuditDynamicWindowImpact(evidence)
+		return schemaAuditClampFloat(impact, 0.35, 1)
+	case "SA-E002":
+		tailCount, okTail := schemaAuditEvidenceNumber(evidence, "emptyTailCount")
+		threshold, okThreshold := schemaAuditEvidenceNumber(evidence, "threshold")
+		if okTail && okThreshold && threshold > 0 {
+			ratio := tailCount / (2 * threshold)
+			return schemaAuditClampFloat(0.35+0.65*ratio, 0.35, 1)
+		}
+		return 0.65
+	}
+	return schemaAuditDefaultRuleImpact
+}
+
+type schemaAuditBucketRuleSet struct{}
+
+func (schemaAuditBucketRuleSet) Rules() []SchemaAuditRuleMeta {
+	return []SchemaAuditRuleMeta{
+		{
+			ID:              "SA-B001",
+			Title:           "Buckets below the size-based estimate",
+			Category:        "bucket",
+			DefaultSeverity: "warn",
+			Weight:          0.75,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+		{
+			ID:              "SA-B002",
+			Title:           "Buckets above the size-based estimate",
+			Category:        "bucket",
+			DefaultSeverity: "warn",
+			Weight:          0.75,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+		{
+			ID:              "SA-B003",
+			Title:           "AUTO bucket jumps between adjacent partitions",
+			Category:        "bucket",
+			DefaultSeverity: "warn",
+			Weight:          0.75,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+		{
+			ID:              "SA-B004",
+			Title:           "Bucket estimation skipped",
+			Category:        "bucket",
+			DefaultSeverity: "info",
+			Weight:          0.60,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+		{
+			ID:              "SA-B005",
+			Title:           "RANDOM distribution on a keyed table",
+			Category:        "distribution",
+			DefaultSeverity: "critical",
+			Weight:          1.0,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsDataModel,
+		},
+		{
+			ID:              "SA-B006",
+			Title:           "HASH distribution on non-key columns",
+			Category:        "distribution",
+			DefaultSeverity: "critical",
+			Weight:          1.0,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsDataModel,
+		},
+		{
+			ID:              "SA-B007",
+			Title:           "Average tablet size outside the recommended range",
+			Category:        "tablet",
+			DefaultSeverity: "info",
+			Weight:          0.55,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+		{
+			ID:              "SA-B009",
+			Title:           "Bucket changes only apply to new partitions",
+			Category:        "bucket",
+			DefaultSeverity: "info",
+			Weight:          0.25,
+			Inputs:          schemaAuditDetailInput,
+			DocsURL:         schemaAuditDocsBucketing,
+		},
+	}
+}
+
+func (schemaAuditBucketRuleSet) Evaluate(
+	facts SchemaAuditTableFacts,
+	cfg SchemaAuditRuleConfig,
+) []SchemaAuditFinding {
+	return evaluateSchemaAuditBucketFindings(facts.Partitions, facts.CreateTableSQL, cfg.bucketRuleConfig())
+}
+
+func (schemaAuditBucketRuleSet) Impact(finding SchemaAuditFinding) float64 {
+	evidence := finding.Evidence
+	switch normalizeSchemaAuditRuleID(finding.RuleID) {
+	case "SA-B001", "SA-B002":
+		anomalyCount, okAnomaly := schemaAuditEvidenceNumber(evidence, "anomalyCount")
+		validCount, okValid := schemaAuditEvidenceNumber(evidence, "validPartitionCount")
+		if okAnomaly && okValid && validCount > 0 {
+			ratio := anomalyCount / validCount
+			return schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
+		}
+		return 0.60
+	case "SA-B003":
+		jumpCount, okJump := schemaAuditEvidenceNumber(evidence, "jumpCount")
+		partitionCount, okPartition := schemaAuditEvidenceNumber(evidence, "partitionCount")
+		if okJump && okPartition && partitionCount > 1 {
+			ratio := jumpCount / (partitionCount - 1)
+			return schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
+		}
+		return 0.60
+	case "SA-B004":
+		partitionCount, okPartition := schemaAuditEvidenceNumber(evidence, "partitionCount")
+		missingBucketCount, okBucket := schemaAuditEvidenceNumber(evidence, "missingBucketCount")
+		missingSizeCount, okSize := schemaAuditEvidenceNumber(evidence, "missingSizeCount")
+		if okPartition && partitionCount > 0 && okBucket && okSize {
+			ratio := math.Max(missingBucketCount, missingSizeCount) / partitionCount
+			return schemaAuditClampFloat(0.35+0.65*ratio, 0.35, 1)
+		}
+		return 0.55
+	case "SA-B005", "SA-B006":
+		return 1.0
+	case "SA-B007":
+		avgSize, okAvg := schemaAuditEvidenceNumber(evidence, "averageTabletSizeBytes")
+		minSize, okMin := schemaAuditEvidenceNumber(evidence, "recommendedMinBytes")
+		maxSize, okMax := schemaAuditEvidenceNumber(evidence, "recommendedMaxBytes")
+		if okAvg && okMin && okMax && minSize > 0 && maxSize > minSize {
+			ratio := 1.0
+			switch {
+			case avgSize < minSize:
+				ratio = minSize / math.Max(avgSize, 1)
+			case avgSize > maxSize:
+				ratio = avgSize / maxSize
+			}
+			distance := math.Min(ratio-1, 2)
+			return schemaAuditClampFloat(0.35+0.325*distance, 0.35, 1)
+		}
+		return 0.50
+	case "SA-B009":
+		return 0.25
+	}
+	return schemaAuditDefaultRuleImpact
+}
@@ -0,0 +1,128 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+)
+
+type testSchemaAuditRuleSet struct {
+	rules    []SchemaAuditRuleMeta
+	findings []SchemaAuditFinding
+	impact   float64
+}
+
+func (s testSchemaAuditRuleSet) Rules() []SchemaAuditRuleMeta { return s.rules }
+
+func (s testSchemaAuditRuleSet) Evaluate(SchemaAuditTableFacts, SchemaAuditRuleConfig) []SchemaAuditFinding {
+	return s.findings
+}
+
+func (s testSchemaAuditRuleSet) Impact(SchemaAuditFinding) float64 { return s.impact }
+
+func TestDefaultSchemaAuditRuleRegistryWeights(t *testing.T) {
+	t.Parallel()
+
+	want := map[string]float64{
+		"SA-B005": 1.0,
+		"SA-B006": 1.0,
+		"SA-E001": 0.95,
+		"SA-D004": 0.85,
+		"SA-E002": 0.80,
+		"SA-B001": 0.75,
+		"SA-B002": 0.75,
+		"SA-B003": 0.75,
+		"SA-B004": 0.60,
+		"SA-B007": 0.55,
+		"SA-B009": 0.25,
+		"SA-X999": schemaAuditDefaultRuleWeight,
+	}
+	registry := DefaultSchemaAuditRuleRegistry()
+	for id, weight := range want {
+		if got := registry.Weight(id); got != weight {
+			t.Fatalf("weight of %s: got %v want %v", id, got, weight)
+		}
+	}
+	for _, meta := range registry.Rules() {
+		if meta.Title == "" || meta.Category == "" || meta.DefaultSeverity == "" || meta.DocsURL == "" {
+			t.Fatalf("rule %s has incomplete metadata: %+v", meta.ID, meta)
+		}
+	}
+}
+
+func TestNewSchemaAuditRuleRegistryRejectsDuplicateIDs(t *testing.T) {
+	t.Parallel()
+
+	set := testSchemaAuditRuleSet{rules: []SchemaAuditRuleMeta{{ID: "SA-E001", Inputs: schemaAuditBothInputs}}}
+	_, err := NewSchemaAuditRuleRegistry(schemaAuditEmptyPartitionRuleSet{}, set)
+	if err == nil || !strings.Contains(err.Error(), "duplicate schema audit rule id: SA-E001") {
+		t.Fatalf("expected duplicate id error, got %v", err)
+	}
+}
+
+func TestSchemaAuditRuleRegistryEvaluate(t *testing.T) {
+	t.Parallel()
+
+	set := testSchemaAuditRuleSet{
+		rules: []SchemaAuditRuleMeta{
+			{ID: "SA-T001", Weight: 0.5, Inputs: schemaAuditBothInputs},
+			{ID: "SA-T002", Weight: 0.5, Inputs: schemaAuditDetailInput},
+		},
+		findings: []SchemaAuditFinding{{RuleID: "SA-T001"}, {RuleID: "SA-T002"}},
+	}
+	registry, err := NewSchemaAuditRuleRegistry(set)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scan := registry.Evaluate(SchemaAuditTableFacts{Input: SchemaAuditInputScanSummary}, DefaultSchemaAuditRuleConfig(), nil)
+	if !hasSchemaAuditRule(scan, "SA-T001") || hasSchemaAuditRule(scan, "SA-T002") {
+		t.Fatalf("expected only scan-capable rules, got %+v", scan)
+	}
+	detail := registry.Evaluate(SchemaAuditTableFacts{Input: SchemaAuditInputTableDetail}, DefaultSchemaAuditRuleConfig(), []string{"sa-t001"})
+	if hasSchemaAuditRule(detail, "SA-T001") || !hasSchemaAuditRule(detail, "SA-T002") {
+		t.Fatalf("expected disabled rule to be dropped, got %+v", detail)
+	}
+	if err := registry.ValidateRuleIDs([]string{"SA-T001", "SA-E001"}); err == nil {
+		t.Fatalf("expected unknown rule error")
+	}
+}
+
+func TestDefaultSchemaAuditRuleRegistryDisablesBuiltInRules(t *testing.T) {
+	t.Parallel()
+
+	facts := SchemaAuditTableFacts{
+		Input:               SchemaAuditInputScanSummary,
+		PartitionCount:      10,
+		EmptyPartitionCount: 9,
+		DynamicProperties:   map[string]string{"dynamic_partition.enable": "true"},
+	}
+	findings := DefaultSchemaAuditRuleRegistry().Evaluate(facts, DefaultSchemaAuditRuleConfig(), nil)
+	if !hasSchemaAuditRule(findings, "SA-E001") || !hasSchemaAuditRule(findings, "SA-D004") {
+		t.Fatalf("expected SA-E001 and SA-D004, got %+v", findings)
+	}
+	findings = DefaultSchemaAuditRuleRegistry().Evaluate(facts, DefaultSchemaAuditRuleConfig(), []string{"SA-D004"})
+	if !hasSchemaAuditRule(findings, "SA-E001") || hasSchemaAuditRule(findings, "SA-D004") {
+		t.Fatalf("expected SA-D004 to be disabled, got %+v", findings)
+	}
+}
+
+func TestSchemaAuditRuleRegistryImpactComesFromOwningSet(t *testing.T) {
+	t.Parallel()
+
+	registry, err := NewSchemaAuditRuleRegistry(
+		schemaAuditEmptyPartitionRuleSet{},
+		testSchemaAuditRuleSet{rules: []SchemaAuditRuleMeta{{ID: "SA-T001", Inputs: schemaAuditBothInputs}}, impact: 0.42},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := registry.Impact(SchemaAuditFinding{RuleID: "sa-t001"}); got != 0.42 {
+		t.Fatalf("impact of registered rule: got %v want 0.42", got)
+	}
+	if got := registry.Impact(SchemaAuditFinding{RuleID: "SA-E001", Evidence: map[string]any{"emptyRatio": 1.0}}); got != 1 {
+		t.Fatalf("impact of SA-E001: got %v want 1", got)
+	}
+	if got := registry.Impact(SchemaAuditFinding{RuleID: "SA-X999"}); got != schemaAuditDefaultRuleImpact {
+		t.Fatalf("impact of unknown rule: got %v want %v", got, schemaAuditDefaultRuleImpact)
+	}
+}
@@ -10,12 +10,7 @@ import (
 )
 
 const (
-	schemaAuditEmptyRatioWarn            = 0.3
-	schemaAuditEmptyRatioCritical        = 0.6
-	schemaAuditEmptyTailThreshold        = 7
-	schemaAuditDynamicWindowSpanWarn     = 32
-	schemaAuditDynamicWindowSpanCritical = 64
-	schemaAuditDateTimeNanosLayout       = "2006-01-02 15:04:05.999999999"
+	schemaAuditDateTimeNanosLayout = "2006-01-02 15:04:05.999999999"
 
 	schemaAuditScoreMax                  = 100
 	schemaAuditScoreWarnSeverityFactor   = 0.70
@@ -26,7 +21,11 @@ const (
 	schemaAuditScoreMaxContribution      = 0.95
 )
 
-func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicProperties map[string]string) []SchemaAuditFinding {
+func evaluateSchemaAuditFindings(
+	partitions []SchemaAuditPartition,
+	dynamicProperties map[string]string,
+	rules SchemaAuditRuleConfig,
+) []SchemaAuditFinding {
 	findings := make([]SchemaAuditFinding, 0, 4)
 	totalPartitions := len(partitions)
 	if totalPartitions == 0 {
@@ -48,9 +47,9 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 	futureWindow, hasFutureWindow := schemaAuditDynamicFutureOffset(dynamicProperties)
 	futureUncertain := isDynamicPartitionEnabled(dynamicProperties) && hasFutureWindow && futureWindow > 0 && !futurePartitionClassified
 
-	if emptyRatio >= schemaAuditEmptyRatioWarn {
+	if emptyRatio >= rules.EmptyRatioWarn {
 		severity := "warn"
-		if emptyRatio >= schemaAuditEmptyRatioCritical {
+		if emptyRatio >= rules.EmptyRatioCritical {
 			severity = "critical"
 		}
 		confidence := 0.95
@@ -73,8 +72,8 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 				"futureExclusionSource":    exclusionEvidence["futureExclusionSource"],
 				"futurePartitionUncertain": futureUncertain,
 				"potentialFutureWindow":    exclusionEvidence["potentialFutureWindow"],
-				"warnThreshold":            schemaAuditEmptyRatioWarn,
-				"criticalThreshold":        schemaAuditEmptyRatioCritical,
+				"warnThreshold":            rules.EmptyRatioWarn,
+				"criticalThreshold":        rules.EmptyRatioCritical,
 			},
 			Recommendation: "Reduce dynamic partition window and clean long-term empty partitions.",
 		})
@@ -100,7 +99,7 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 		)
 	}
 	tailFutureUncertain := isDynamicPartitionEnabled(dynamicProperties) && hasFutureWindow && futureWindow > 0 && !tailFutureClassified
-	if effectiveEmptyTailCount >= schemaAuditEmptyTailThreshold {
+	if effectiveEmptyTailCount >= rules.EmptyTailThreshold {
 		confidence := schemaAuditTimelineConfidence(orderSource, tailFutureUncertain)
 		findings = append(findings, SchemaAuditFinding{
 			RuleID:     "SA-E002",
@@ -114,14 +113,14 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 				"orderSource":              orderSource,
 				"futureExclusionSource":    tailExclusionSource,
 				"futurePartitionUncertain": tailFutureUncertain,
-				"threshold":                schemaAuditEmptyTailThreshold,
+				"threshold":                rules.EmptyTailThreshold,
 				"latestPartitionName":      ordered[len(ordered)-1].Name,
 			},
 			Recommendation: "Check whether dynamic partition end/start are too wide for current write traffic.",
 		})
 	}
 
-	if isDynamicPartitionEnabled(dynamicProperties) && emptyRatio >= schemaAuditEmptyRatioCritical {
+	if isDynamicPartitionEnabled(dynamicProperties) && emptyRatio >= rules.EmptyRatioCritical {
 		confidence := 0.9
 		if futureUncertain {
 			confidence = 0.65
@@ -142,8 +141,8 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 			"start":                    dynamicProperties["dynamic_partition.start"],
 			"end":                      dynamicProperties["dynamic_partition.end"],
 			"buckets":                  dynamicProperties["dynamic_partition.buckets"],
-			"windowSpanWarn":           schemaAuditDynamicWindowSpanWarn,
-			"windowSpanCritical":       schemaAuditDynamicWindowSpanCritical,
+			"windowSpanWarn":           rules.DynamicWindowSpanWarn,
+			"windowSpanCritical":       rules.DynamicWindowSpanCritical,
 		}
 		if hasDynamicWindowSpan {
 			evidence["windowSpan"] = dynamicWindowSpan
@@ -161,24 +160,14 @@ func evaluateSchemaAuditFindings(partitions []SchemaAuditPartition, dynamicPrope
 	return findings
 }
 
-func evaluateSchemaAuditTableDetailFindings(
-	partitions []SchemaAuditPartition,
-	dynamicProperties map[string]string,
-	createTableSQL string,
-	bucketConfig schemaAuditBucketRuleConfig,
-) []SchemaAuditFinding {
-	findings := evaluateSchemaAuditFindings(partitions, dynamicProperties)
-	findings = append(findings, evaluateSchemaAuditBucketFindings(partitions, createTableSQL, bucketConfig)...)
-	return findings
-}
-
 func summarizeSchemaAuditFindings(findings []SchemaAuditFinding) []SchemaAuditFindingSummary {
 	out := make([]SchemaAuditFindingSummary, 0, len(findings))
 	for i := range findings {
 		out = append(out, SchemaAuditFindingSummary{
-			RuleID:   findings[i].RuleID,
-			Severity: findings[i].Severity,
-			Summary:  findings[i].Summary,
+			RuleID:     findings[i].RuleID,
+			Severity:   findings[i].Severity,
+			Summary:    findings[i].Summary,
+			Suppressed: findings[i].Suppression != nil,
 		})
 	}
 	return out
@@ -191,6 +180,9 @@ func computeSchemaAuditScore(findings []SchemaAuditFinding) int {
 
 	safeRatio := 1.0
 	for i := range findings {
+		if findings[i].Suppression != nil {
+			continue
+		}
 		contribution := schemaAuditScoreContribution(findings[i])
 		if contribution <= 0 {
 			continue
@@ -214,8 +206,8 @@ func schemaAuditScoreContribution(finding SchemaAuditFinding) float64 {
 		return 0
 	}
 
-	weight := schemaAuditRuleWeight(finding.RuleID)
-	impact := schemaAuditRuleImpact(finding)
+	weight := defaultSchemaAuditRules.Weight(finding.RuleID)
+	impact := defaultSchemaAuditRules.Impact(finding)
 	confidence := schemaAuditClampFloat(finding.Confidence, schemaAuditScoreMinConfidence, 1)
 	coverage := schemaAuditCoverageFactor(finding.Evidence)
 
@@ -236,102 +228,6 @@ func schemaAuditSeverityFactor(severity string) float64 {
 	}
 }
 
-func schemaAuditRuleWeight(ruleID string) float64 {
-	switch strings.ToUpper(strings.TrimSpace(ruleID)) {
-	case "SA-B005", "SA-B006":
-		return 1.0
-	case "SA-E001":
-		return 0.95
-	case "SA-D004":
-		return 0.85
-	case "SA-E002":
-		return 0.80
-	case "SA-B001", "SA-B002", "SA-B003":
-		return 0.75
-	case "SA-B004":
-		return 0.60
-	case "SA-B007":
-		return 0.55
-	case "SA-B009":
-		return 0.25
-	default:
-		return 0.65
-	}
-}
-
-func schemaAuditRuleImpact(finding SchemaAuditFinding) float64 {
-	evidence := finding.Evidence
-	switch strings.ToUpper(strings.TrimSpace(finding.RuleID)) {
-	case "SA-E001":
-		if ratio, ok := schemaAuditEvidenceNumber(evidence, "emptyRatio"); ok {
-			return schemaAuditClampFloat(0.25+0.75*ratio, 0.25, 1)
-		}
-		return 0.70
-	case "SA-D004":
-		impact := 0.65
-		if ratio, ok := schemaAuditEvidenceNumber(evidence, "emptyRatio"); ok {
-			impact = schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
-		}
-		impact *= schemaAuditDynamicWindowImpact(evidence)
-		return schemaAuditClampFloat(impact, 0.35, 1)
-	case "SA-E002":
-		tailCount, okTail := schemaAuditEvidenceNumber(evidence, "emptyTailCount")
-		threshold, okThreshold := schemaAuditEvidenceNumber(evidence, "threshold")
-		if okTail && okThreshold && threshold > 0 {
-			ratio := tailCount / (2 * threshold)
-			return schemaAuditClampFloat(0.35+0.65*ratio, 0.35, 1)
-		}
-		return 0.65
-	case "SA-B001", "SA-B002":
-		anomalyCount, okAnomaly := schemaAuditEvidenceNumber(evidence, "anomalyCount")
-		validCount, okValid := schemaAuditEvidenceNumber(evidence, "validPartitionCount")
-		if okAnomaly && okValid && validCount > 0 {
-			ratio := anomalyCount / validCount
-			return schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
-		}
-		return 0.60
-	case "SA-B003":
-		jumpCount, okJump := schemaAuditEvidenceNumber(evidence, "jumpCount")
-		partitionCount, okPartition := schemaAuditEvidenceNumber(evidence, "partitionCount")
-		if okJump && okPartition && partitionCount > 1 {
-			ratio := jumpCount / (partitionCount - 1)
-			return schemaAuditClampFloat(0.30+0.70*ratio, 0.30, 1)
-		}
-		return 0.60
-	case "SA-B004":
-		partitionCount, okPartition := schemaAuditEvidenceNumber(evidence, "partitionCount")
-		missingBucketCount, okBucket := schemaAuditEvidenceNumber(evidence, "missingBucketCount")
-		missingSizeCount, okSize := schemaAuditEvidenceNumber(evidence, "missingSizeCount")
-		if okPartition && partitionCount > 0 && okBucket && okSize {
-			ratio := math.Max(missingBucketCount, missingSizeCount) / partitionCount
-			return schemaAuditClampFloat(0.35+0.65*ratio, 0.35, 1)
-		}
-		return 0.55
-	case "SA-B005", "SA-B006":
-		return 1.0
-	case "SA-B007":
-		avgSize, okAvg := schemaAuditEvidenceNumber(evidence, "averageTabletSizeBytes")
-		minSize, okMin := schemaAuditEvidenceNumber(evidence, "recommendedMinBytes")
-		maxSize, okMax := schemaAuditEvidenceNumber(evidence, "recommendedMaxBytes")
-		if okAvg && okMin && okMax && minSize > 0 && maxSize > minSize {
-			ratio := 1.0
-			switch {
-			case avgSize < minSize:
-				ratio = minSize / math.Max(avgSize, 1)
-			case avgSize > maxSize:
-				ratio = avgSize / maxSize
-			}
-			distance := math.Min(ratio-1, 2)
-			return schemaAuditClampFloat(0.35+0.325*distance, 0.35, 1)
-		}
-		return 0.50
-	case "SA-B009":
-		return 0.25
-	default:
-		return 0.60
-	}
-}
-
 func schemaAuditDynamicWindowImpact(evidence map[string]any) float64 {
 	if len(evidence) == 0 {
 		return 1
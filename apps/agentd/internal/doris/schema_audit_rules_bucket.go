@@ -17,9 +17,6 @@ const (
 
 	schemaAuditBucketSize100MB = 100 * 1024 * 1024
 	schemaAuditBucketSize1GB   = 1024 * 1024 * 1024
-
-	schemaAuditBestPracticeTabletSizeMinBytes = 1 * 1024 * 1024 * 1024
-	schemaAuditBestPracticeTabletSizeMaxBytes = 10 * 1024 * 1024 * 1024
 )
 
 var (
@@ -33,6 +30,8 @@ type schemaAuditBucketRuleConfig struct {
 	MaxBuckets               int
 	PartitionSizePerBucketGB int
 	OutOfBoundsRatio         float64
+	TabletSizeMinBytes       uint64
+	TabletSizeMaxBytes       uint64
 }
 
 type schemaAuditBucketEstimate struct {
@@ -66,12 +65,7 @@ type schemaAuditCreateTableDescriptor struct {
 }
 
 func defaultSchemaAuditBucketRuleConfig() schemaAuditBucketRuleConfig {
-	return schemaAuditBucketRuleConfig{
-		MinBuckets:               schemaAuditDefaultAutoBucketMinBuckets,
-		MaxBuckets:               schemaAuditDefaultAutoBucketMaxBuckets,
-		PartitionSizePerBucketGB: -1,
-		OutOfBoundsRatio:         schemaAuditDefaultAutoBucketOutOfBoundsRatio,
-	}
+	return DefaultSchemaAuditRuleConfig().bucketRuleConfig()
 }
 
 func normalizeSchemaAuditBucketRuleConfig(
@@ -93,6 +87,16 @@ func normalizeSchemaAuditBucketRuleConfig(
 	if out.OutOfBoundsRatio > 0.95 {
 		out.OutOfBoundsRatio = 0.95
 	}
+	def := DefaultSchemaAuditRuleConfig()
+	if out.TabletSizeMinBytes == 0 {
+		out.TabletSizeMinBytes = def.TabletSizeMinBytes
+	}
+	if out.TabletSizeMaxBytes == 0 {
+		out.TabletSizeMaxBytes = def.TabletSizeMaxBytes
+	}
+	if out.TabletSizeMaxBytes <= out.TabletSizeMinBytes {
+		out.TabletSizeMaxBytes = out.TabletSizeMinBytes * 10
+	}
 	return out
 }
 
@@ -204,7 +208,7 @@ func evaluateSchemaAuditBucketFindings(
 
 	findings = append(
 		findings,
-		evaluateSchemaAuditBucketBestPracticeFindings(partitions)...,
+		evaluateSchemaAuditBucketBestPracticeFindings(partitions, normalizedConfig)...,
 	)
 	if shouldEmitBucketChangeExpectationFinding(findings) {
 		findings = append(findings, SchemaAuditFinding{
@@ -358,34 +362,35 @@ func evaluateSchemaAuditBucketDDLFindings(
 
 func evaluateSchemaAuditBucketBestPracticeFindings(
 	partitions []SchemaAuditPartition,
+	cfg schemaAuditBucketRuleConfig,
 ) []SchemaAuditFinding {
 	totalDataBytes, totalTabletCount, partitionWithBucketCount := summarizeSchemaAuditTabletLayout(partitions)
 	findings := make([]SchemaAuditFinding, 0, 2)
 
 	if totalTabletCount > 0 && totalDataBytes > 0 {
 		averageTabletSizeBytes := float64(totalDataBytes) / float64(totalTabletCount)
-		if averageTabletSizeBytes < schemaAuditBestPracticeTabletSizeMinBytes ||
-			averageTabletSizeBytes > schemaAuditBestPracticeTabletSizeMaxBytes {
+		minBytes := float64(cfg.TabletSizeMinBytes)
+		maxBytes := float64(cfg.TabletSizeMaxBytes)
+		if averageTabletSizeBytes < minBytes || averageTabletSizeBytes > maxBytes {
 			severity := "info"
-			if averageTabletSizeBytes < schemaAuditBestPracticeTabletSizeMinBytes/2 ||
-				averageTabletSizeBytes > schemaAuditBestPracticeTabletSizeMaxBytes*2 {
+			if averageTabletSizeBytes < minBytes/2 || averageTabletSizeBytes > maxBytes*2 {
 				severity = "warn"
 			}
 			findings = append(findings, SchemaAuditFinding{
 				RuleID:     "SA-B007",
 				Severity:   severity,
 				Confidence: 0.8,
-				Summary:    "Average tablet size is outside recommended 1-10GB range",
+				Summary:    "Average tablet size is outside recommended " + schemaAuditTabletRangeLabel(cfg) + " range",
 				Evidence: map[string]any{
 					"partitionCount":           len(partitions),
 					"partitionWithBucketCount": partitionWithBucketCount,
 					"totalTabletCount":         totalTabletCount,
 					"totalDataBytes":           totalDataBytes,
 					"averageTabletSizeBytes":   averageTabletSizeBytes,
-					"recommendedMinBytes":      schemaAuditBestPracticeTabletSizeMinBytes,
-					"recommendedMaxBytes":      schemaAuditBestPracticeTabletSizeMaxBytes,
+					"recommendedMinBytes":      cfg.TabletSizeMinBytes,
+					"recommendedMaxBytes":      cfg.TabletSizeMaxBytes,
 				},
-				Recommendation: "Tune bucket count so average tablet size converges to 1-10GB over active partitions.",
+				Recommendation: "Tune bucket count so average tablet size converges to " + schemaAuditTabletRangeLabel(cfg) + " over active partitions.",
 			})
 		}
 	}
@@ -393,6 +398,16 @@ func evaluateSchemaAuditBucketBestPracticeFindings(
 	return findings
 }
 
+// schemaAuditTabletRangeLabel renders the recommended tablet size range,
+// e.g. "1-10GB".
+func schemaAuditTabletRangeLabel(cfg schemaAuditBucketRuleConfig) string {
+	return schemaAuditGBLabel(cfg.TabletSizeMinBytes) + "-" + schemaAuditGBLabel(cfg.TabletSizeMaxBytes) + "GB"
+}
+
+func schemaAuditGBLabel(bytes uint64) string {
+	return strconv.FormatFloat(float64(bytes)/schemaAuditBucketSize1GB, 'f', -1, 64)
+}
+
 func summarizeSchemaAuditTabletLayout(
 	partitions []SchemaAuditPartition,
 ) (totalDataBytes uint64, totalTabletCount int, partitionWithBucketCount int) {
@@ -31,7 +31,7 @@ func TestEvaluateSchemaAuditFindingsExcludesFutureTailForE002(t *testing.T) {
 		"dynamic_partition.time_zone":         "Asia/Shanghai",
 		"dynamic_partition.start":             "-7",
 		"dynamic_partition.start_day_of_week": "1",
-	})
+	}, DefaultSchemaAuditRuleConfig())
 	if hasSchemaAuditRule(findings, "SA-E002") {
 		t.Fatalf("expected SA-E002 to be suppressed by future-tail exclusion, got %+v", findings)
 	}
@@ -60,7 +60,7 @@ func TestEvaluateSchemaAuditFindingsKeepsE002WhenNonFutureTailIsLong(t *testing.
 		"dynamic_partition.time_unit": "DAY",
 		"dynamic_partition.prefix":    "p",
 		"dynamic_partition.time_zone": "Asia/Shanghai",
-	})
+	}, DefaultSchemaAuditRuleConfig())
 	if !hasSchemaAuditRule(findings, "SA-E002") {
 		t.Fatalf("expected SA-E002 when non-future empty tail remains long, got %+v", findings)
 	}
@@ -112,7 +112,7 @@ func TestEvaluateSchemaAuditFindingsUsesRangeLowerOrderForTail(t *testing.T) {
 		},
 	}
 
-	findings := evaluateSchemaAuditFindings(partitions, nil)
+	findings := evaluateSchemaAuditFindings(partitions, nil, DefaultSchemaAuditRuleConfig())
 	if !hasSchemaAuditRule(findings, "SA-E002") {
 		t.Fatalf("expected SA-E002 when range lower ordering reveals long tail, got %+v", findings)
 	}
@@ -209,7 +209,7 @@ func TestEvaluateSchemaAuditFindingsLowersE002ConfidenceForPartialRangeOrder(t *
 		},
 	}
 
-	partialFindings := evaluateSchemaAuditFindings(partitions, nil)
+	partialFindings := evaluateSchemaAuditFindings(partitions, nil, DefaultSchemaAuditRuleConfig())
 	partialFinding, ok := schemaAuditFindingByRule(partialFindings, "SA-E002")
 	if !ok {
 		t.Fatalf("expected SA-E002 finding, got %+v", partialFindings)
@@ -263,6 +263,7 @@ func TestEvaluateSchemaAuditFindingsLowersE002ConfidenceForPartialRangeOrder(t *
 			},
 		},
 		nil,
+		DefaultSchemaAuditRuleConfig(),
 	)
 	fullRangeFinding, ok := schemaAuditFindingByRule(fullRangeFindings, "SA-E002")
 	if !ok {
@@ -300,7 +301,7 @@ func TestEvaluateSchemaAuditFindingsExcludesFutureEmptyForE001AndD004(t *testing
 		"dynamic_partition.time_unit": "DAY",
 		"dynamic_partition.prefix":    "p",
 		"dynamic_partition.time_zone": "Asia/Shanghai",
-	})
+	}, DefaultSchemaAuditRuleConfig())
 	if hasSchemaAuditRule(findings, "SA-E001") {
 		t.Fatalf("expected SA-E001 to be suppressed after precise future exclusion, got %+v", findings)
 	}
@@ -338,7 +339,7 @@ func TestEvaluateSchemaAuditFindingsStillWarnsForNonFutureEmpty(t *testing.T) {
 		"dynamic_partition.time_unit": "DAY",
 		"dynamic_partition.prefix":    "p",
 		"dynamic_partition.time_zone": "Asia/Shanghai",
-	})
+	}, DefaultSchemaAuditRuleConfig())
 	if !hasSchemaAuditRule(findings, "SA-E001") {
 		t.Fatalf("expected SA-E001 for non-future empty partitions, got %+v", findings)
 	}
@@ -363,7 +364,7 @@ func TestEvaluateSchemaAuditFindingsLowersConfidenceWhenFutureCannotBeClassified
 		"dynamic_partition.end":       "10",
 		"dynamic_partition.time_unit": "DAY",
 		"dynamic_partition.prefix":    "p",
-	})
+	}, DefaultSchemaAuditRuleConfig())
 	if !hasSchemaAuditRule(findings, "SA-E001") {
 		t.Fatalf("expected SA-E001, got %+v", findings)
 	}
@@ -1,8 +1,11 @@
 package doris
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func assertSchemaAuditQueryContains(t *testing.T, query string, fragments ...string) {
@@ -136,6 +139,7 @@ func TestEvaluateSchemaAuditScanFindingsMarksFutureUncertainty(t *testing.T) {
 			"dynamic_partition.start":  "-45",
 			"dynamic_partition.end":    "10",
 		},
+		DefaultSchemaAuditRuleConfig(),
 	)
 	if !hasSchemaAuditRule(findings, "SA-E001") {
 		t.Fatalf("expected SA-E001, got %+v", findings)
@@ -162,6 +166,7 @@ func TestEvaluateSchemaAuditScanFindingsStillDetectsNonFutureEmptyRisk(t *testin
 			"dynamic_partition.start":  "-45",
 			"dynamic_partition.end":    "10",
 		},
+		DefaultSchemaAuditRuleConfig(),
 	)
 	if !hasSchemaAuditRule(findings, "SA-E001") {
 		t.Fatalf("expected SA-E001, got %+v", findings)
@@ -170,3 +175,45 @@ func TestEvaluateSchemaAuditScanFindingsStillDetectsNonFutureEmptyRisk(t *testin
 		t.Fatalf("expected SA-D004, got %+v", findings)
 	}
 }
+
+func TestEvaluateSchemaAuditScanItemUsesDeepTable(t *testing.T) {
+	t.Parallel()
+
+	row := schemaAuditScanRow{
+		Key:              schemaAuditTableKey{Database: "db1", Table: "t1"},
+		PartitionSummary: schemaAuditPartitionSummary{PartitionCount: 10, EmptyPartitionCount: 8},
+	}
+	partitions := make([]SchemaAuditPartition, 0, 10)
+	for i := 0; i < 10; i++ {
+		partitions = append(partitions, SchemaAuditPartition{
+			Name:    "p" + strconv.Itoa(i),
+			Buckets: 1,
+			Empty:   i >= 2,
+		})
+	}
+	rules := DefaultSchemaAuditRuleConfig()
+	now := time.Now()
+	hasRule := func(item SchemaAuditScanItem, ruleID string) bool {
+		for _, finding := range item.Findings {
+			if finding.RuleID == ruleID {
+				return true
+			}
+		}
+		return false
+	}
+
+	summary := evaluateSchemaAuditScanItem(row, nil, rules, SchemaAuditScanOptions{}, now)
+	if summary.Deep || !hasRule(summary, "SA-E001") || hasRule(summary, "SA-E002") {
+		t.Fatalf("expected summary-only findings, got %+v", summary)
+	}
+
+	deep := evaluateSchemaAuditScanItem(row, &schemaAuditDeepTable{Partitions: partitions}, rules, SchemaAuditScanOptions{}, now)
+	if !deep.Deep || deep.DeepError != "" || !hasRule(deep, "SA-E002") {
+		t.Fatalf("expected table-detail findings, got %+v", deep)
+	}
+
+	failed := evaluateSchemaAuditScanItem(row, &schemaAuditDeepTable{Err: errors.New("timeout")}, rules, SchemaAuditScanOptions{}, now)
+	if failed.Deep || failed.DeepError != "timeout" || !hasRule(failed, "SA-E001") {
+		t.Fatalf("expected summary fallback with deep error, got %+v", failed)
+	}
+}
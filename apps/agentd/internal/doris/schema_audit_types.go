@@ -1,10 +1,40 @@
 package doris
 
+import "time"
+
 type SchemaAuditScanOptions struct {
 	Database  string
 	TableLike string
 	Page      int
 	PageSize  int
+	// Rules overrides rule thresholds; zero fields use the default profile.
+	Rules SchemaAuditRuleConfig
+	// DisabledRules lists rule IDs to skip.
+	DisabledRules []string
+	// Waivers suppress matching findings until they expire.
+	Waivers []SchemaAuditWaiver
+	// Deep fetches SHOW PARTITIONS and SHOW CREATE TABLE for each candidate
+	// so the ranking uses table-detail rules as well.
+	Deep bool
+	// Concurrency bounds the tables a deep scan fetches at once.
+	Concurrency int
+	// TableTimeout bounds the metadata queries of one table in a deep scan;
+	// the caller's context bounds the whole scan.
+	TableTimeout time.Duration
+	// OnInventory, OnItem and OnWarning report progress while the scan runs.
+	// OnItem sees tables in evaluation order, not rank order. Calls never
+	// overlap.
+	OnInventory func(SchemaAuditInventory)
+	OnItem      func(SchemaAuditScanItem)
+	OnWarning   func(string)
+}
+
+type SchemaAuditTableDetailOptions struct {
+	Database      string
+	Table         string
+	Rules         SchemaAuditRuleConfig
+	DisabledRules []string
+	Waivers       []SchemaAuditWaiver
 }
 
 type SchemaAuditInventory struct {
@@ -18,9 +48,10 @@ type SchemaAuditInventory struct {
 }
 
 type SchemaAuditFindingSummary struct {
-	RuleID   string `json:"ruleId"`
-	Severity string `json:"severity"`
-	Summary  string `json:"summary"`
+	RuleID     string `json:"ruleId"`
+	Severity   string `json:"severity"`
+	Summary    string `json:"summary"`
+	Suppressed bool   `json:"suppressed,omitempty"`
 }
 
 type SchemaAuditScanItem struct {
@@ -32,10 +63,25 @@ type SchemaAuditScanItem struct {
 	DynamicPartitionEnabled bool                        `json:"dynamicPartitionEnabled"`
 	Score                   int                         `json:"score"`
 	FindingCount            int                         `json:"findingCount"`
+	SuppressedCount         int                         `json:"suppressedCount,omitempty"`
 	Findings                []SchemaAuditFindingSummary `json:"findings"`
+	// Deep is set when table-detail rules were evaluated for the table.
+	Deep bool `json:"deep,omitempty"`
+	// DeepError explains why a deep scan fell back to summary rules.
+	DeepError string `json:"deepError,omitempty"`
+}
+
+type SchemaAuditDeepScanSummary struct {
+	Concurrency    int   `json:"concurrency"`
+	TableTimeoutMs int64 `json:"tableTimeoutMs"`
+	Evaluated      int   `json:"evaluated"`
+	Failed         int   `json:"failed"`
+	Skipped        int   `json:"skipped"`
+	ElapsedMs      int64 `json:"elapsedMs"`
 }
 
 type SchemaAuditScanResult struct {
+	Catalog    string                `json:"catalog,omitempty"`
 	Inventory  SchemaAuditInventory  `json:"inventory"`
 	Items      []SchemaAuditScanItem `json:"items"`
 	Page       int                   `json:"page"`
@@ -44,6 +90,9 @@ type SchemaAuditScanResult struct {
 	Truncated  bool                  `json:"truncated"`
 	ScanLimit  int                   `json:"scanLimit"`
 	Warning    string                `json:"warning,omitempty"`
+	Rules      SchemaAuditRuleConfig `json:"rules"`
+	// DeepScan is set for deep scans.
+	DeepScan *SchemaAuditDeepScanSummary `json:"deepScan,omitempty"`
 }
 
 type SchemaAuditFinding struct {
@@ -53,6 +102,9 @@ type SchemaAuditFinding struct {
 	Summary        string         `json:"summary"`
 	Evidence       map[string]any `json:"evidence"`
 	Recommendation string         `json:"recommendation,omitempty"`
+	// Suppression is set when an active waiver covers the finding; such
+	// findings do not count towards the score.
+	Suppression *SchemaAuditSuppression `json:"suppression,omitempty"`
 }
 
 type SchemaAuditPartition struct {
@@ -71,6 +123,7 @@ type SchemaAuditIndex struct {
 }
 
 type SchemaAuditTableDetailResult struct {
+	Catalog           string                 `json:"catalog,omitempty"`
 	Database          string                 `json:"database"`
 	Table             string                 `json:"table"`
 	CreateTableSQL    string                 `json:"createTableSql"`
@@ -78,4 +131,5 @@ type SchemaAuditTableDetailResult struct {
 	Partitions        []SchemaAuditPartition `json:"partitions"`
 	Indexes           []SchemaAuditIndex     `json:"indexes"`
 	Findings          []SchemaAuditFinding   `json:"findings"`
+	Rules             SchemaAuditRuleConfig  `json:"rules"`
 }
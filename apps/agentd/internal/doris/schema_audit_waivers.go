@@ -0,0 +1,130 @@
+package doris
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+// SchemaAuditWaiver suppresses one rule on tables matching the database and
+// table glob patterns ("*" and "?") until ExpiresAt.
+type SchemaAuditWaiver struct {
+	ID        string    `json:"id"`
+	RuleID    string    `json:"ruleId"`
+	Database  string    `json:"database"`
+	Table     string    `json:"table"`
+	Reason    string    `json:"reason"`
+	Author    string    `json:"author"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SchemaAuditSuppression records the waiver that suppressed a finding.
+type SchemaAuditSuppression struct {
+	WaiverID  string    `json:"waiverId"`
+	Reason    string    `json:"reason"`
+	Author    string    `json:"author"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Normalize trims the fields, upper-cases the rule ID and defaults empty
+// patterns to "*".
+func (w SchemaAuditWaiver) Normalize() SchemaAuditWaiver {
+	w.ID = strings.TrimSpace(w.ID)
+	w.RuleID = normalizeSchemaAuditRuleID(w.RuleID)
+	w.Database = strings.TrimSpace(w.Database)
+	if w.Database == "" {
+		w.Database = "*"
+	}
+	w.Table = strings.TrimSpace(w.Table)
+	if w.Table == "" {
+		w.Table = "*"
+	}
+	w.Reason = strings.TrimSpace(w.Reason)
+	w.Author = strings.TrimSpace(w.Author)
+	return w
+}
+
+// Validate checks a normalized waiver.
+func (w SchemaAuditWaiver) Validate() error {
+	if w.RuleID == "" {
+		return errors.New("ruleId is required")
+	}
+	if _, ok := defaultSchemaAuditRules.Rule(w.RuleID); !ok {
+		return errors.New("unknown schema audit rule: " + w.RuleID)
+	}
+	if _, err := path.Match(w.Database, ""); err != nil {
+		return errors.New("database pattern is invalid")
+	}
+	if _, err := path.Match(w.Table, ""); err != nil {
+		return errors.New("table pattern is invalid")
+	}
+	if w.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if w.Author == "" {
+		return errors.New("author is required")
+	}
+	if w.ExpiresAt.IsZero() {
+		return errors.New("expiresAt is required")
+	}
+	return nil
+}
+
+// Active reports whether the waiver still applies at now.
+func (w SchemaAuditWaiver) Active(now time.Time) bool {
+	return now.Before(w.ExpiresAt)
+}
+
+func (w SchemaAuditWaiver) matches(ruleID string, database string, table string) bool {
+	if normalizeSchemaAuditRuleID(w.RuleID) != normalizeSchemaAuditRuleID(ruleID) {
+		return false
+	}
+	databaseMatch, _ := path.Match(w.Database, database)
+	tableMatch, _ := path.Match(w.Table, table)
+	return databaseMatch && tableMatch
+}
+
+// applySchemaAuditWaivers marks findings covered by an active waiver. When
+// several waivers match, the one expiring last wins.
+func applySchemaAuditWaivers(
+	findings []SchemaAuditFinding,
+	database string,
+	table string,
+	waivers []SchemaAuditWaiver,
+	now time.Time,
+) {
+	for i := range findings {
+		var best *SchemaAuditWaiver
+		for j := range waivers {
+			waiver := &waivers[j]
+			if !waiver.Active(now) || !waiver.matches(findings[i].RuleID, database, table) {
+				continue
+			}
+			if best == nil || waiver.ExpiresAt.After(best.ExpiresAt) {
+				best = waiver
+			}
+		}
+		if best == nil {
+			findings[i].Suppression = nil
+			continue
+		}
+		findings[i].Suppression = &SchemaAuditSuppression{
+			WaiverID:  best.ID,
+			Reason:    best.Reason,
+			Author:    best.Author,
+			ExpiresAt: best.ExpiresAt,
+		}
+	}
+}
+
+func countSuppressedSchemaAuditFindings(findings []SchemaAuditFinding) int {
+	n := 0
+	for i := range findings {
+		if findings[i].Suppression != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package doris
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchemaAuditWaiverValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := SchemaAuditWaiver{
+		RuleID:    "sa-e001",
+		Database:  "migration_*",
+		Reason:    "pre-created partitions for backfill",
+		Author:    "dba",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}.Normalize()
+	if valid.RuleID != "SA-E001" || valid.Table != "*" {
+		t.Fatalf("unexpected normalized waiver: %+v", valid)
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*SchemaAuditWaiver)
+		wantErr string
+	}{
+		{name: "unknown rule", mutate: func(w *SchemaAuditWaiver) { w.RuleID = "SA-X001" }, wantErr: "unknown schema audit rule: SA-X001"},
+		{name: "bad pattern", mutate: func(w *SchemaAuditWaiver) { w.Table = "[" }, wantErr: "table pattern is invalid"},
+		{name: "missing reason", mutate: func(w *SchemaAuditWaiver) { w.Reason = "" }, wantErr: "reason is required"},
+		{name: "missing author", mutate: func(w *SchemaAuditWaiver) { w.Author = "" }, wantErr: "author is required"},
+		{name: "missing expiry", mutate: func(w *SchemaAuditWaiver) { w.ExpiresAt = time.Time{} }, wantErr: "expiresAt is required"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			waiver := valid
+			tc.mutate(&waiver)
+			if err := waiver.Validate(); err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApplySchemaAuditWaivers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	waivers := []SchemaAuditWaiver{
+		{ID: "expired", RuleID: "SA-E001", Database: "*", Table: "*", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "short", RuleID: "SA-E001", Database: "mig_*", Table: "*", ExpiresAt: now.Add(time.Hour)},
+		{ID: "long", RuleID: "SA-E001", Database: "mig_*", Table: "orders", ExpiresAt: now.Add(48 * time.Hour)},
+		{ID: "other-rule", RuleID: "SA-D004", Database: "*", Table: "*", ExpiresAt: now.Add(time.Hour)},
+	}
+	newFindings := func() []SchemaAuditFinding {
+		return []SchemaAuditFinding{
+			{RuleID: "SA-E001", Severity: "critical", Confidence: 0.95, Evidence: map[string]any{"emptyRatio": 0.9}},
+			{RuleID: "SA-E002", Severity: "warn", Confidence: 0.95},
+		}
+	}
+
+	findings := newFindings()
+	applySchemaAuditWaivers(findings, "mig_2026", "orders", waivers, now)
+	if findings[0].Suppression == nil || findings[0].Suppression.WaiverID != "long" {
+		t.Fatalf("expected the longest-lived waiver, got %+v", findings[0].Suppression)
+	}
+	if findings[1].Suppression != nil {
+		t.Fatalf("expected SA-E002 to stay active, got %+v", findings[1].Suppression)
+	}
+	if got, want := computeSchemaAuditScore(findings), computeSchemaAuditScore(findings[1:]); got != want {
+		t.Fatalf("expected suppressed finding to be excluded from score, got %d want %d", got, want)
+	}
+	if countSuppressedSchemaAuditFindings(findings) != 1 {
+		t.Fatalf("expected one suppressed finding")
+	}
+	if summary := summarizeSchemaAuditFindings(findings); !summary[0].Suppressed || summary[1].Suppressed {
+		t.Fatalf("unexpected summary suppression flags: %+v", summary)
+	}
+
+	findings = newFindings()
+	applySchemaAuditWaivers(findings, "sales", "orders", waivers, now)
+	if findings[0].Suppression != nil {
+		t.Fatalf("expected no waiver outside the database pattern, got %+v", findings[0].Suppression)
+	}
+
+	findings = newFindings()
+	applySchemaAuditWaivers(findings, "mig_2026", "orders", waivers, now.Add(72*time.Hour))
+	if findings[0].Suppression != nil {
+		t.Fatalf("expected expired waivers to resurface the finding, got %+v", findings[0].Suppression)
+	}
+}
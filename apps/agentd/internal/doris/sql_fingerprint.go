@@ -0,0 +1,26 @@
+package doris
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const sqlFingerprintBytes = 8
+
+var sqlTemplateNormalizer = &sqlRedactor{mode: SQLRedactionPlaceholder, compact: true}
+
+// NormalizeSQLTemplate rewrites SQL into its template: literals become "?",
+// literal IN-lists collapse to "IN (?)", whitespace is collapsed and comments
+// other than optimizer hints are dropped. Templates are only comparable with
+// other templates produced by this function.
+func NormalizeSQLTemplate(sqlText string) string {
+	return strings.TrimRight(sqlTemplateNormalizer.redact(sqlText), "; ")
+}
+
+// FingerprintSQL returns the template of sqlText and a short stable hash of it.
+func FingerprintSQL(sqlText string) (template string, fingerprint string) {
+	template = NormalizeSQLTemplate(sqlText)
+	sum := sha256.Sum256([]byte(template))
+	return template, hex.EncodeToString(sum[:sqlFingerprintBytes])
+}
@@ -0,0 +1,279 @@
+package doris
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Statement kinds reported by SplitSQLStatements.
+const (
+	SQLStatementSelect  = "select"
+	SQLStatementInsert  = "insert"
+	SQLStatementUpdate  = "update"
+	SQLStatementDelete  = "delete"
+	SQLStatementExplain = "explain"
+	SQLStatementUse     = "use"
+	SQLStatementSwitch  = "switch"
+	SQLStatementSet     = "set"
+	SQLStatementOther   = "other"
+)
+
+// SQLStatement is one statement of a script. Text has leading and trailing
+// comments and the terminating ';' removed.
+type SQLStatement struct {
+	Kind    string `json:"kind"`
+	Keyword string `json:"keyword,omitempty"`
+	Text    string `json:"text"`
+
+	tokens []sqlToken
+}
+
+// GuardedSQL is user SQL that passed GuardSQL: the single statement to run
+// plus the context leading "SWITCH catalog;" and "USE [catalog.]db;"
+// statements selected.
+type GuardedSQL struct {
+	Catalog   string
+	Database  string
+	Statement SQLStatement
+}
+
+// SplitSQLStatements splits sqlText on ';' outside strings, quoted
+// identifiers and comments and classifies each statement. Empty statements
+// are dropped.
+func SplitSQLStatements(sqlText string) ([]SQLStatement, error) {
+	tokens, err := lexSQL(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	var statements []SQLStatement
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !tokens[i].isSymbol(';') {
+			continue
+		}
+		if stmt, ok := newSQLStatement(sqlText, tokens[start:i]); ok {
+			statements = append(statements, stmt)
+		}
+		start = i + 1
+	}
+	return statements, nil
+}
+
+func newSQLStatement(sqlText string, tokens []sqlToken) (SQLStatement, bool) {
+	first := nextSQLTokenIndex(tokens, 0)
+	if first >= len(tokens) {
+		return SQLStatement{}, false
+	}
+	last := len(tokens) - 1
+	for tokens[last].isTrivia() {
+		last--
+	}
+	tokens = tokens[first : last+1]
+	kind, keyword := classifySQLTokens(tokens)
+	return SQLStatement{
+		Kind:    kind,
+		Keyword: keyword,
+		Text:    sqlText[tokens[0].Start:tokens[len(tokens)-1].End],
+		tokens:  tokens,
+	}, true
+}
+
+func classifySQLTokens(tokens []sqlToken) (kind string, keyword string) {
+	i := nextSQLTokenIndex(tokens, 0)
+	parenthesized := false
+	for i < len(tokens) && tokens[i].isSymbol('(') {
+		parenthesized = true
+		i = nextSQLTokenIndex(tokens, i+1)
+	}
+	if i >= len(tokens) || tokens[i].Kind != sqlTokenWord {
+		return SQLStatementOther, ""
+	}
+	keyword = strings.ToUpper(tokens[i].Text)
+	switch keyword {
+	case "SELECT", "WITH", "VALUES":
+		return SQLStatementSelect, keyword
+	}
+	if parenthesized {
+		return SQLStatementOther, keyword
+	}
+	switch keyword {
+	case "INSERT":
+		return SQLStatementInsert, keyword
+	case "UPDATE":
+		return SQLStatementUpdate, keyword
+	case "DELETE":
+		return SQLStatementDelete, keyword
+	case "EXPLAIN":
+		return SQLStatementExplain, keyword
+	case "USE":
+		return SQLStatementUse, keyword
+	case "SWITCH":
+		return SQLStatementSwitch, keyword
+	case "SET":
+		return SQLStatementSet, keyword
+	}
+	return SQLStatementOther, keyword
+}
+
+// classifySQL returns the statement kind of a single statement.
+func classifySQL(sqlText string) string {
+	tokens, err := lexSQL(sqlText)
+	if err != nil {
+		return SQLStatementOther
+	}
+	kind, _ := classifySQLTokens(tokens)
+	return kind
+}
+
+// GuardSQL accepts optional leading SWITCH/USE statements followed by exactly
+// one query or DML statement, or an EXPLAIN of one. Nothing but EXPLAIN is
+// ever executed, so DML is only planned.
+func GuardSQL(sqlText string) (GuardedSQL, error) {
+	statements, err := SplitSQLStatements(sqlText)
+	if err != nil {
+		return GuardedSQL{}, err
+	}
+	if len(statements) == 0 {
+		return GuardedSQL{}, errors.New("sql is required")
+	}
+
+	var out GuardedSQL
+	i := 0
+	for ; i < len(statements); i++ {
+		stmt := statements[i]
+		if stmt.Kind != SQLStatementUse && stmt.Kind != SQLStatementSwitch {
+			break
+		}
+		catalog, database, err := parseSQLContextStatement(stmt)
+		if err != nil {
+			return GuardedSQL{}, err
+		}
+		if stmt.Kind == SQLStatementSwitch {
+			out.Catalog, out.Database = catalog, ""
+			continue
+		}
+		if catalog != "" {
+			out.Catalog = catalog
+		}
+		out.Database = database
+	}
+	if i == len(statements) {
+		return GuardedSQL{}, errors.New("sql is required after " + statements[i-1].Keyword)
+	}
+	if rest := len(statements) - i; rest > 1 {
+		return GuardedSQL{}, errors.New("sql must contain a single statement, found " + strconv.Itoa(rest))
+	}
+	out.Statement = statements[i]
+	if err := checkExplainableSQLStatement(out.Statement); err != nil {
+		return GuardedSQL{}, err
+	}
+	return out, nil
+}
+
+func checkExplainableSQLStatement(stmt SQLStatement) error {
+	kind := stmt.Kind
+	if kind == SQLStatementExplain {
+		target := explainTargetTokens(stmt.tokens)
+		kind, _ = classifySQLTokens(target)
+		if !isExplainableSQLKind(kind) {
+			return errors.New("EXPLAIN target is not an explainable statement")
+		}
+		return nil
+	}
+	switch {
+	case kind == SQLStatementSet:
+		return errors.New("SET statements are not allowed; pass sessionVariables instead")
+	case isExplainableSQLKind(kind):
+		return nil
+	case stmt.Keyword == "":
+		return errors.New("sql must start with a statement keyword")
+	default:
+		return errors.New("statement is not explainable: " + stmt.Keyword)
+	}
+}
+
+func isExplainableSQLKind(kind string) bool {
+	switch kind {
+	case SQLStatementSelect, SQLStatementInsert, SQLStatementUpdate, SQLStatementDelete:
+		return true
+	}
+	return false
+}
+
+// explainTargetTokens skips EXPLAIN and its plan type, level and PROCESS
+// modifiers.
+func explainTargetTokens(tokens []sqlToken) []sqlToken {
+	i := nextSQLTokenIndex(tokens, 0)
+	if i < len(tokens) && tokens[i].isWord("EXPLAIN") {
+		i = nextSQLTokenIndex(tokens, i+1)
+	}
+	for i < len(tokens) && tokens[i].Kind == sqlTokenWord {
+		word := strings.ToUpper(tokens[i].Text)
+		_, planType := explainPlanTypeTokens[word]
+		_, level := explainLevelTokens[word]
+		if !planType && !level && word != "PROCESS" {
+			break
+		}
+		i = nextSQLTokenIndex(tokens, i+1)
+	}
+	return tokens[i:]
+}
+
+// parseSQLContextStatement reads "USE [catalog.]db" or "SWITCH catalog".
+func parseSQLContextStatement(stmt SQLStatement) (catalog string, database string, err error) {
+	what := "database"
+	if stmt.Kind == SQLStatementSwitch {
+		what = "catalog"
+	}
+	tokens := stmt.tokens
+	var names []string
+	i := nextSQLTokenIndex(tokens, 1)
+	for {
+		if i >= len(tokens) {
+			break
+		}
+		name, ok := sqlIdentifierText(tokens[i])
+		if !ok {
+			return "", "", errors.New(stmt.Keyword + " statement has an invalid " + what + " name")
+		}
+		names = append(names, name)
+		i++
+		if i >= len(tokens) {
+			break
+		}
+		if !tokens[i].isSymbol('.') {
+			if tokens[i].isTrivia() {
+				return "", "", errors.New(stmt.Keyword + " statement must end with ';'")
+			}
+			return "", "", errors.New(stmt.Keyword + " statement has an invalid " + what + " name")
+		}
+		i++
+		if i >= len(tokens) {
+			return "", "", errors.New(stmt.Keyword + " statement requires a " + what + " name")
+		}
+	}
+	switch {
+	case len(names) == 0:
+		return "", "", errors.New(stmt.Keyword + " statement requires a " + what + " name")
+	case stmt.Kind == SQLStatementSwitch && len(names) == 1:
+		return names[0], "", nil
+	case stmt.Kind == SQLStatementUse && len(names) == 1:
+		return "", names[0], nil
+	case stmt.Kind == SQLStatementUse && len(names) == 2:
+		return names[0], names[1], nil
+	}
+	return "", "", errors.New(stmt.Keyword + " statement has an invalid " + what + " name")
+}
+
+// sqlIdentifierText returns the name of a bare word or backtick identifier.
+func sqlIdentifierText(t sqlToken) (string, bool) {
+	switch t.Kind {
+	case sqlTokenWord:
+		return t.Text, true
+	case sqlTokenQuotedIdent:
+		name := strings.TrimSpace(strings.ReplaceAll(t.Text[1:len(t.Text)-1], "``", "`"))
+		return name, name != ""
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	t.Parallel()
+
+	stmts, err := SplitSQLStatements("-- lead\nUSE `a;b`; select ';' /* ; */ from t # x ;\n;\n  (SELECT 1);;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct{ kind, text string }{
+		{SQLStatementUse, "USE `a;b`"},
+		{SQLStatementSelect, "select ';' /* ; */ from t"},
+		{SQLStatementSelect, "(SELECT 1)"},
+	}
+	if len(stmts) != len(want) {
+		t.Fatalf("unexpected statements: %+v", stmts)
+	}
+	for i := range want {
+		if stmts[i].Kind != want[i].kind || stmts[i].Text != want[i].text {
+			t.Fatalf("statement %d: got %s %q", i, stmts[i].Kind, stmts[i].Text)
+		}
+	}
+
+	if _, err := SplitSQLStatements("select 'x"); err == nil {
+		t.Fatalf("expected unterminated string error")
+	}
+}
+
+func TestGuardSQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		in          string
+		wantCatalog string
+		wantDB      string
+		wantSQL     string
+		wantErr     string
+	}{
+		{name: "no use", in: "select 1", wantSQL: "select 1"},
+		{name: "use select", in: "use tpch; select 1", wantDB: "tpch", wantSQL: "select 1"},
+		{name: "use explain", in: "USE tpch; EXPLAIN select 1", wantDB: "tpch", wantSQL: "EXPLAIN select 1"},
+		{name: "quoted db", in: "use `db-prod`; select 1;", wantDB: "db-prod", wantSQL: "select 1"},
+		{name: "leading comment", in: "/* report */ -- daily\nuse tpch; select 1", wantDB: "tpch", wantSQL: "select 1"},
+		{name: "use catalog db", in: "use hive.tpch; select 1", wantCatalog: "hive", wantDB: "tpch", wantSQL: "select 1"},
+		{
+			name:        "use quoted catalog db",
+			in:          "USE `iceberg-prod`.`sales`; select 1",
+			wantCatalog: "iceberg-prod",
+			wantDB:      "sales",
+			wantSQL:     "select 1",
+		},
+		{name: "switch catalog", in: "switch hive; select 1", wantCatalog: "hive", wantSQL: "select 1"},
+		{name: "switch then use", in: "SWITCH hive; USE tpch; select 1", wantCatalog: "hive", wantDB: "tpch", wantSQL: "select 1"},
+		{name: "odd casing dml", in: "iNsErT into t select 1", wantSQL: "iNsErT into t select 1"},
+		{name: "explain wrapped dml", in: "explain verbose delete from t where k = 1", wantSQL: "explain verbose delete from t where k = 1"},
+		{name: "keyword prefix not match", in: "useful select 1", wantErr: "statement is not explainable: USEFUL"},
+		{name: "missing db", in: "use ; select 1", wantErr: "USE statement requires a database name"},
+		{name: "missing semicolon", in: "use tpch select 1", wantErr: "USE statement must end with ';'"},
+		{name: "no sql after use", in: "use tpch;  ", wantErr: "sql is required after USE"},
+		{name: "invalid unquoted name", in: "use tpch-1; select 1", wantErr: "USE statement has an invalid database name"},
+		{name: "use missing db after catalog", in: "use hive.; select 1", wantErr: "USE statement requires a database name"},
+		{name: "switch missing semicolon", in: "switch hive select 1", wantErr: "SWITCH statement must end with ';'"},
+		{name: "switch dotted name", in: "switch hive.tpch; select 1", wantErr: "SWITCH statement has an invalid catalog name"},
+		{name: "only comments", in: "-- nothing\n/* here */", wantErr: "sql is required"},
+		{name: "multiple statements", in: "select 1; drop table t", wantErr: "sql must contain a single statement, found 2"},
+		{name: "ddl", in: "DROP TABLE t", wantErr: "statement is not explainable: DROP"},
+		{name: "set", in: "set enable_profile = true", wantErr: "SET statements are not allowed"},
+		{name: "explain ddl", in: "EXPLAIN drop table t", wantErr: "EXPLAIN target is not an explainable statement"},
+		{name: "unterminated comment", in: "select 1 /* x", wantErr: "sql has an unterminated comment"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := GuardSQL(tc.in)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v (result=%+v)", tc.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Catalog != tc.wantCatalog || got.Database != tc.wantDB || got.Statement.Text != tc.wantSQL {
+				t.Fatalf("unexpected result: %q.%q %q", got.Catalog, got.Database, got.Statement.Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package doris
+
+import (
+	"errors"
+	"strings"
+)
+
+type sqlTokenKind int
+
+const (
+	sqlTokenSpace sqlTokenKind = iota
+	sqlTokenComment
+	sqlTokenString
+	sqlTokenQuotedIdent
+	sqlTokenNumber
+	sqlTokenWord
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	Kind  sqlTokenKind
+	Text  string
+	Start int
+	End   int
+}
+
+func (t sqlToken) isTrivia() bool {
+	return t.Kind == sqlTokenSpace || t.Kind == sqlTokenComment
+}
+
+func (t sqlToken) isWord(upper string) bool {
+	return t.Kind == sqlTokenWord && strings.EqualFold(t.Text, upper)
+}
+
+func (t sqlToken) isSymbol(symbol byte) bool {
+	return t.Kind == sqlTokenSymbol && len(t.Text) == 1 && t.Text[0] == symbol
+}
+
+var (
+	errSQLUnterminatedString  = errors.New("sql has an unterminated string literal")
+	errSQLUnterminatedIdent   = errors.New("sql has an unterminated quoted identifier")
+	errSQLUnterminatedComment = errors.New("sql has an unterminated comment")
+)
+
+// lexSQL splits MySQL-dialect SQL into tokens. It understands single and double
+// quoted strings (backslash and doubled-quote escapes), backtick identifiers,
+// "--", "#" and "/* */" comments. On an unterminated construct it still returns
+// every token, with the unterminated one running to the end of the input.
+func lexSQL(s string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0, len(s)/4+1)
+	var lexErr error
+	i := 0
+	for i < len(s) {
+		start := i
+		c := s[i]
+		kind := sqlTokenSymbol
+		switch {
+		case strings.IndexByte(asciiWhitespace, c) >= 0:
+			kind = sqlTokenSpace
+			for i < len(s) && strings.IndexByte(asciiWhitespace, s[i]) >= 0 {
+				i++
+			}
+		case c == '#' || (c == '-' && i+1 < len(s) && s[i+1] == '-'):
+			kind = sqlTokenComment
+			nl := strings.IndexByte(s[i:], '\n')
+			if nl < 0 {
+				i = len(s)
+			} else {
+				i += nl
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			kind = sqlTokenComment
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				i = len(s)
+				if lexErr == nil {
+					lexErr = errSQLUnterminatedComment
+				}
+			} else {
+				i += 2 + end + 2
+			}
+		case c == '\'' || c == '"':
+			kind = sqlTokenString
+			var ok bool
+			i, ok = scanSQLQuoted(s, i, c, true)
+			if !ok && lexErr == nil {
+				lexErr = errSQLUnterminatedString
+			}
+		case c == '`':
+			kind = sqlTokenQuotedIdent
+			var ok bool
+			i, ok = scanSQLQuoted(s, i, c, false)
+			if !ok && lexErr == nil {
+				lexErr = errSQLUnterminatedIdent
+			}
+		case isSQLDigit(c) || (c == '.' && i+1 < len(s) && isSQLDigit(s[i+1])):
+			kind = sqlTokenNumber
+			end := scanSQLNumber(s, i)
+			// A number immediately followed by word characters is an identifier such as 1d_table.
+			if end < len(s) && isSQLWordPart(s[end]) {
+				kind = sqlTokenWord
+				for end < len(s) && isSQLWordPart(s[end]) {
+					end++
+				}
+			}
+			i = end
+		case isSQLWordStart(c):
+			kind = sqlTokenWord
+			for i < len(s) && isSQLWordPart(s[i]) {
+				i++
+			}
+		default:
+			i++
+		}
+		tokens = append(tokens, sqlToken{Kind: kind, Text: s[start:i], Start: start, End: i})
+	}
+	return tokens, lexErr
+}
+
+func scanSQLQuoted(s string, i int, quote byte, backslashEscapes bool) (int, bool) {
+	i++
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			if backslashEscapes {
+				i += 2
+				continue
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1, true
+		}
+		i++
+	}
+	return len(s), false
+}
+
+func scanSQLNumber(s string, i int) int {
+	if s[i] == '0' && i+1 < len(s) && (s[i+1] == 'x' || s[i+1] == 'X') {
+		i += 2
+		for i < len(s) && isSQLHexDigit(s[i]) {
+			i++
+		}
+		return i
+	}
+	for i < len(s) && isSQLDigit(s[i]) {
+		i++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && isSQLDigit(s[i]) {
+			i++
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isSQLDigit(s[j]) {
+			i = j
+			for i < len(s) && isSQLDigit(s[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLHexDigit(c byte) bool {
+	return isSQLDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isSQLWordStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$' || c >= 0x80
+}
+
+func isSQLWordPart(c byte) bool {
+	return isSQLWordStart(c) || isSQLDigit(c)
+}
+
+// nextSQLTokenIndex returns the index of the next non-trivia token at or after i, or len(tokens).
+func nextSQLTokenIndex(tokens []sqlToken, i int) int {
+	for i < len(tokens) && tokens[i].isTrivia() {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,144 @@
+package doris
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	SQLRedactionNone        = ""
+	SQLRedactionPlaceholder = "placeholder"
+	SQLRedactionHash        = "hash"
+
+	sqlRedactionHashBytes = 6
+)
+
+// NormalizeSQLRedactionMode validates a redaction mode name. Empty means no redaction.
+func NormalizeSQLRedactionMode(mode string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(mode))
+	switch normalized {
+	case "", "none":
+		return SQLRedactionNone, nil
+	case SQLRedactionPlaceholder, SQLRedactionHash:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported redaction mode: %s (want placeholder or hash)", normalized)
+	}
+}
+
+// sqlRedactor replaces string and numeric literals in SQL text.
+//
+// Placeholder mode writes "?" for every literal and collapses literal IN-lists to
+// "IN (?)". Hash mode writes a salted HMAC of each literal instead, so equal
+// values still correlate across rows without revealing them.
+type sqlRedactor struct {
+	mode string
+	salt []byte
+	// compact collapses whitespace and drops comments other than optimizer hints.
+	compact bool
+}
+
+func newSQLRedactor(mode string, salt string) (*sqlRedactor, error) {
+	normalized, err := NormalizeSQLRedactionMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if normalized == SQLRedactionNone {
+		return nil, nil
+	}
+	r := &sqlRedactor{mode: normalized, salt: []byte(salt)}
+	if normalized == SQLRedactionHash && len(r.salt) == 0 {
+		// Without a caller-provided salt, hashes only correlate within one export.
+		r.salt = make([]byte, 16)
+		if _, err := rand.Read(r.salt); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *sqlRedactor) redact(sqlText string) string {
+	tokens, _ := lexSQL(sqlText)
+	var b strings.Builder
+	b.Grow(len(sqlText))
+	pendingSpace := false
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if r.compact {
+			if tok.isTrivia() && !strings.HasPrefix(tok.Text, "/*+") {
+				pendingSpace = b.Len() > 0
+				continue
+			}
+			if pendingSpace {
+				b.WriteByte(' ')
+				pendingSpace = false
+			}
+		}
+		if r.mode == SQLRedactionPlaceholder && tok.isWord("IN") {
+			if end, ok := literalInListEnd(tokens, i+1); ok {
+				b.WriteString(tok.Text)
+				b.WriteString(" (?)")
+				i = end
+				continue
+			}
+		}
+		switch tok.Kind {
+		case sqlTokenString:
+			b.WriteString(r.replacement(tok.Text, true))
+		case sqlTokenNumber:
+			b.WriteString(r.replacement(tok.Text, false))
+		default:
+			b.WriteString(tok.Text)
+		}
+	}
+	return b.String()
+}
+
+func (r *sqlRedactor) replacement(literal string, quoted bool) string {
+	if r.mode == SQLRedactionPlaceholder {
+		return "?"
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(literal))
+	digest := hex.EncodeToString(mac.Sum(nil)[:sqlRedactionHashBytes])
+	if quoted {
+		return "'h:" + digest + "'"
+	}
+	return "h:" + digest
+}
+
+// literalInListEnd reports whether tokens starting at i form "(lit, lit, ...)" and
+// returns the index of the closing parenthesis.
+func literalInListEnd(tokens []sqlToken, i int) (int, bool) {
+	i = nextSQLTokenIndex(tokens, i)
+	if i >= len(tokens) || !tokens[i].isSymbol('(') {
+		return 0, false
+	}
+	expectLiteral := true
+	for i = nextSQLTokenIndex(tokens, i+1); i < len(tokens); i = nextSQLTokenIndex(tokens, i+1) {
+		tok := tokens[i]
+		if expectLiteral {
+			if tok.isSymbol('-') || tok.isSymbol('+') {
+				continue
+			}
+			if tok.Kind != sqlTokenString && tok.Kind != sqlTokenNumber && !tok.isWord("NULL") {
+				return 0, false
+			}
+			expectLiteral = false
+			continue
+		}
+		switch {
+		case tok.isSymbol(','):
+			expectLiteral = true
+		case tok.isSymbol(')'):
+			return i, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package doris
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexSQLTokenKinds(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := lexSQL("SELECT `a`, 'x''y', 1.5e3 FROM t1 -- c\n/* b */ WHERE 1d_col = \"q\\\"\"")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, tok := range tokens {
+		if tok.Kind == sqlTokenSpace {
+			continue
+		}
+		got = append(got, tok.Text)
+	}
+	want := []string{"SELECT", "`a`", ",", "'x''y'", ",", "1.5e3", "FROM", "t1", "-- c", "/* b */", "WHERE", "1d_col", "=", "\"q\\\"\""}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected tokens:\nwant: %q\ngot:  %q", want, got)
+	}
+	if tokens[len(tokens)-1].Kind != sqlTokenString {
+		t.Fatalf("expected string token, got %v", tokens[len(tokens)-1].Kind)
+	}
+}
+
+func TestLexSQLUnterminated(t *testing.T) {
+	t.Parallel()
+
+	tokens, err := lexSQL("select 'abc")
+	if err != errSQLUnterminatedString {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := tokens[len(tokens)-1]; last.Kind != sqlTokenString || last.Text != "'abc" {
+		t.Fatalf("unexpected last token: %+v", last)
+	}
+}
+
+func TestSQLRedactorPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	r, err := newSQLRedactor("placeholder", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "SELECT * FROM t WHERE name = 'alice' AND age > 30",
+			want: "SELECT * FROM t WHERE name = ? AND age > ?",
+		},
+		{
+			in:   "select * from t where id in (1, 2, -3,'x') and c is not null",
+			want: "select * from t where id in (?) and c is not null",
+		},
+		{
+			in:   "select * from t where id IN (select id from u where k = 'v')",
+			want: "select * from t where id IN (select id from u where k = ?)",
+		},
+		{
+			in:   "select `col1`, t2.c3 from db1.t2 where phone = \"555-0100\"",
+			want: "select `col1`, t2.c3 from db1.t2 where phone = ?",
+		},
+		{
+			in:   "insert into t values ('it''s', 0x1F, .5)",
+			want: "insert into t values (?, ?, ?)",
+		},
+		{
+			in:   "select * from t where note = 'truncated by audit plugin",
+			want: "select * from t where note = ?",
+		},
+	}
+	for _, tt := range tests {
+		if got := r.redact(tt.in); got != tt.want {
+			t.Fatalf("redact(%q):\nwant: %q\ngot:  %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestSQLRedactorHashCorrelates(t *testing.T) {
+	t.Parallel()
+
+	r1, err := newSQLRedactor("hash", "salt-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2, _ := newSQLRedactor("hash", "salt-b")
+
+	a := r1.redact("select * from t where email = 'a@example.com' and id in (7, 8)")
+	b := r1.redact("select * from t where email = 'a@example.com' and id in (7, 9)")
+	if strings.Contains(a, "example.com") {
+		t.Fatalf("literal leaked: %q", a)
+	}
+	emailA := a[strings.Index(a, "'h:"):][:len("'h:")+12+1]
+	if !strings.Contains(b, emailA) {
+		t.Fatalf("expected equal literals to hash equally: %q vs %q", a, b)
+	}
+	if a == b {
+		t.Fatalf("expected different literals to hash differently: %q", a)
+	}
+	if r2.redact("select 'a@example.com'") == r1.redact("select 'a@example.com'") {
+		t.Fatalf("expected salt to change the hash")
+	}
+}
+
+func TestNormalizeSQLRedactionMode(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{"": "", "none": "", " Hash ": "hash", "placeholder": "placeholder"} {
+		got, err := NormalizeSQLRedactionMode(in)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeSQLRedactionMode(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := NormalizeSQLRedactionMode("mask"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
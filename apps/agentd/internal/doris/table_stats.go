@@ -0,0 +1,325 @@
+package doris
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TableStatsMaxTables = 50
+
+	tableStatsStaleChangeRatio = 0.2
+	tableStatsStaleAge         = 7 * 24 * time.Hour
+)
+
+// Staleness reasons reported on TableStatsEntry.StaleReasons.
+const (
+	TableStatsNeverAnalyzed = "never_analyzed"
+	TableStatsRowsChanged   = "rows_changed"
+	TableStatsOld           = "old"
+	TableStatsNewPartition  = "new_partition"
+	TableStatsAnalyzeFailed = "analyze_failed"
+)
+
+var tableStatsTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.000",
+	time.RFC3339,
+}
+
+// TableStatsRef names a table. Empty Catalog and Database fall back to the
+// connection's.
+type TableStatsRef struct {
+	Catalog  string `json:"catalog,omitempty"`
+	Database string `json:"database,omitempty"`
+	Table    string `json:"table"`
+}
+
+type TableColumnStats struct {
+	Column       string `json:"column"`
+	RowCount     *int64 `json:"rowCount,omitempty"`
+	NDV          *int64 `json:"ndv,omitempty"`
+	NullCount    *int64 `json:"nullCount,omitempty"`
+	Min          string `json:"min,omitempty"`
+	Max          string `json:"max,omitempty"`
+	LastAnalyzed string `json:"lastAnalyzed,omitempty"`
+}
+
+type TableAnalyzeJob struct {
+	JobID   string `json:"jobId"`
+	State   string `json:"state"`
+	Message string `json:"message,omitempty"`
+	EndTime string `json:"endTime,omitempty"`
+}
+
+type TableStatsEntry struct {
+	TableStatsRef
+	RowCount     *int64             `json:"rowCount,omitempty"`
+	UpdatedRows  *int64             `json:"updatedRows,omitempty"`
+	LastAnalyzed string             `json:"lastAnalyzed,omitempty"`
+	AnalyzeJob   *TableAnalyzeJob   `json:"analyzeJob,omitempty"`
+	Columns      []TableColumnStats `json:"columns"`
+	Stale        bool               `json:"stale"`
+	StaleReasons []string           `json:"staleReasons"`
+	// NodeKeys are the explain scan nodes reading this table, when the refs
+	// came from a plan.
+	NodeKeys []string `json:"nodeKeys,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type TableStatsResult struct {
+	Tables []TableStatsEntry `json:"tables"`
+}
+
+// ExplainScanTableRefs returns the distinct tables scanned by g and, per
+// table, the keys of the scan nodes that read it.
+func ExplainScanTableRefs(g *ExplainPlanGraph) ([]TableStatsRef, map[TableStatsRef][]string) {
+	refs := make([]TableStatsRef, 0, 4)
+	nodeKeys := make(map[TableStatsRef][]string)
+	if g == nil {
+		return refs, nodeKeys
+	}
+	for i := range g.Nodes {
+		ref, ok := parseExplainTableRef(g.Nodes[i].Table)
+		if !ok {
+			continue
+		}
+		if _, seen := nodeKeys[ref]; !seen {
+			refs = append(refs, ref)
+		}
+		nodeKeys[ref] = append(nodeKeys[ref], g.Nodes[i].Key)
+	}
+	return refs, nodeKeys
+}
+
+// parseExplainTableRef reads "[catalog.]db.table(alias)" as printed after
+// "TABLE:" in scan nodes. Old versions prefix the database with a cluster.
+func parseExplainTableRef(raw string) (TableStatsRef, bool) {
+	name := strings.TrimSpace(raw)
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	name = strings.TrimPrefix(name, "default_cluster:")
+	if name == "" || strings.ContainsAny(name, "`; ") {
+		return TableStatsRef{}, false
+	}
+	parts := strings.Split(name, ".")
+	switch len(parts) {
+	case 1:
+		return TableStatsRef{Table: parts[0]}, true
+	case 2:
+		return TableStatsRef{Database: parts[0], Table: parts[1]}, true
+	case 3:
+		return TableStatsRef{Catalog: parts[0], Database: parts[1], Table: parts[2]}, true
+	}
+	return TableStatsRef{}, false
+}
+
+// FetchTableStats reads table stats, column stats and the latest analyze job
+// for each ref. A table that fails is reported on its entry.
+func FetchTableStats(ctx context.Context, cfg ConnConfig, refs []TableStatsRef) (TableStatsResult, error) {
+	if len(refs) == 0 {
+		return TableStatsResult{}, errors.New("tables is required")
+	}
+	if len(refs) > TableStatsMaxTables {
+		return TableStatsResult{}, fmt.Errorf("too many tables: %d (max=%d)", len(refs), TableStatsMaxTables)
+	}
+	normalized := make([]TableStatsRef, len(refs))
+	for i, ref := range refs {
+		ref = TableStatsRef{
+			Catalog:  strings.TrimSpace(ref.Catalog),
+			Database: strings.TrimSpace(ref.Database),
+			Table:    strings.TrimSpace(ref.Table),
+		}
+		if ref.Database == "" {
+			ref.Database = strings.TrimSpace(cfg.Database)
+		}
+		if ref.Table == "" || ref.Database == "" {
+			return TableStatsResult{}, fmt.Errorf("tables[%d] needs a database and table", i)
+		}
+		if strings.ContainsAny(ref.Catalog+ref.Database+ref.Table, "`;\r\n\t") {
+			return TableStatsResult{}, fmt.Errorf("tables[%d] is invalid", i)
+		}
+		normalized[i] = ref
+	}
+
+	cfg.Database = ""
+	db, err := openAndPing(ctx, cfg)
+	if err != nil {
+		return TableStatsResult{}, err
+	}
+	defer db.Close()
+
+	now := time.Now()
+	result := TableStatsResult{Tables: make([]TableStatsEntry, 0, len(normalized))}
+	for _, ref := range normalized {
+		if err := ctx.Err(); err != nil {
+			return TableStatsResult{}, err
+		}
+		result.Tables = append(result.Tables, fetchTableStatsEntry(ctx, db, ref, now))
+	}
+	return result, nil
+}
+
+func fetchTableStatsEntry(ctx context.Context, db *sql.DB, ref TableStatsRef, now time.Time) TableStatsEntry {
+	name := quoteSchemaAuditIdentifier(ref.Database) + "." + quoteSchemaAuditIdentifier(ref.Table)
+	if ref.Catalog != "" {
+		name = quoteSchemaAuditIdentifier(ref.Catalog) + "." + name
+	}
+	tableRows, _, err := queryRowsAsStringMaps(ctx, db, "SHOW TABLE STATS "+name)
+	if err != nil {
+		return TableStatsEntry{TableStatsRef: ref, Columns: []TableColumnStats{}, StaleReasons: []string{}, Error: err.Error()}
+	}
+	columnRows, _, err := queryRowsAsStringMaps(ctx, db, "SHOW COLUMN STATS "+name)
+	if err != nil {
+		return TableStatsEntry{TableStatsRef: ref, Columns: []TableColumnStats{}, StaleReasons: []string{}, Error: err.Error()}
+	}
+	// Analyze job history is informational; older versions lack SHOW ANALYZE.
+	jobRows, _, err := queryRowsAsStringMaps(ctx, db, "SHOW ANALYZE "+name)
+	if err != nil {
+		jobRows = nil
+	}
+	return buildTableStatsEntry(ref, tableRows, columnRows, jobRows, now)
+}
+
+func buildTableStatsEntry(
+	ref TableStatsRef,
+	tableRows []map[string]string,
+	columnRows []map[string]string,
+	jobRows []map[string]string,
+	now time.Time,
+) TableStatsEntry {
+	entry := TableStatsEntry{TableStatsRef: ref, Columns: []TableColumnStats{}, StaleReasons: []string{}}
+	newPartition := false
+	if len(tableRows) > 0 {
+		row := tableRows[0]
+		entry.RowCount = parseTableStatsCount(firstNonEmptyValue(row, "row_count"))
+		entry.UpdatedRows = parseTableStatsCount(firstNonEmptyValue(row, "updated_rows"))
+		entry.LastAnalyzed = firstNonEmptyValue(row, "last_analyze_time", "updated_time")
+		newPartition = strings.EqualFold(firstNonEmptyValue(row, "new_partition"), "true")
+	}
+
+	var lastColumnAnalyze time.Time
+	var analyzedRows *int64
+	for _, row := range columnRows {
+		// Rows for rollups and materialized views repeat the base columns.
+		if index := firstNonEmptyValue(row, "index_name"); index != "" && !strings.EqualFold(index, ref.Table) {
+			continue
+		}
+		col := TableColumnStats{
+			Column:       firstNonEmptyValue(row, "column_name"),
+			RowCount:     parseTableStatsCount(firstNonEmptyValue(row, "count")),
+			NDV:          parseTableStatsCount(firstNonEmptyValue(row, "ndv")),
+			NullCount:    parseTableStatsCount(firstNonEmptyValue(row, "num_null")),
+			Min:          firstNonEmptyValue(row, "min"),
+			Max:          firstNonEmptyValue(row, "max"),
+			LastAnalyzed: firstNonEmptyValue(row, "updated_time", "last_analyze_time"),
+		}
+		if col.Column == "" {
+			continue
+		}
+		if t, ok := parseTableStatsTime(col.LastAnalyzed); ok && t.After(lastColumnAnalyze) {
+			lastColumnAnalyze = t
+		}
+		if analyzedRows == nil && col.RowCount != nil {
+			analyzedRows = col.RowCount
+		}
+		entry.Columns = append(entry.Columns, col)
+	}
+	sort.SliceStable(entry.Columns, func(i, j int) bool { return entry.Columns[i].Column < entry.Columns[j].Column })
+	if entry.LastAnalyzed == "" && !lastColumnAnalyze.IsZero() {
+		entry.LastAnalyzed = lastColumnAnalyze.Format(tableStatsTimeLayouts[0])
+	}
+	entry.AnalyzeJob = latestTableAnalyzeJob(jobRows)
+
+	if len(entry.Columns) == 0 {
+		entry.StaleReasons = append(entry.StaleReasons, TableStatsNeverAnalyzed)
+	} else {
+		if tableStatsRowsChanged(entry.RowCount, entry.UpdatedRows, analyzedRows) {
+			entry.StaleReasons = append(entry.StaleReasons, TableStatsRowsChanged)
+		}
+		last, ok := parseTableStatsTime(entry.LastAnalyzed)
+		if !ok {
+			last, ok = lastColumnAnalyze, !lastColumnAnalyze.IsZero()
+		}
+		if ok && now.Sub(last) > tableStatsStaleAge {
+			entry.StaleReasons = append(entry.StaleReasons, TableStatsOld)
+		}
+	}
+	if newPartition {
+		entry.StaleReasons = append(entry.StaleReasons, TableStatsNewPartition)
+	}
+	if entry.AnalyzeJob != nil && strings.EqualFold(entry.AnalyzeJob.State, "FAILED") {
+		entry.StaleReasons = append(entry.StaleReasons, TableStatsAnalyzeFailed)
+	}
+	entry.Stale = len(entry.StaleReasons) > 0
+	return entry
+}
+
+// tableStatsRowsChanged compares the current row count with the rows changed
+// since the last analyze, or with the count the column stats were built on.
+func tableStatsRowsChanged(rowCount, updatedRows, analyzedRows *int64) bool {
+	if rowCount == nil {
+		return false
+	}
+	base := math.Max(float64(*rowCount), 1)
+	if updatedRows != nil && float64(*updatedRows)/base >= tableStatsStaleChangeRatio {
+		return true
+	}
+	if analyzedRows != nil {
+		drift := math.Abs(float64(*rowCount - *analyzedRows))
+		return drift/base >= tableStatsStaleChangeRatio
+	}
+	return false
+}
+
+func latestTableAnalyzeJob(rows []map[string]string) *TableAnalyzeJob {
+	var latest *TableAnalyzeJob
+	var latestID int64 = -1
+	for _, row := range rows {
+		id := firstNonEmptyValue(row, "job_id")
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || n <= latestID {
+			continue
+		}
+		latestID = n
+		latest = &TableAnalyzeJob{
+			JobID:   id,
+			State:   strings.ToUpper(firstNonEmptyValue(row, "state")),
+			Message: firstNonEmptyValue(row, "message"),
+			EndTime: firstNonEmptyValue(row, "end_time", "last_exec_time_in_ms"),
+		}
+	}
+	return latest
+}
+
+// parseTableStatsCount reads counts that Doris may print as "6001215.0".
+func parseTableStatsCount(raw string) *int64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "N/A") {
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	n := int64(v)
+	return &n
+}
+
+func parseTableStatsTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range tableStatsTimeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,87 @@
+package doris
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExplainScanTableRefs(t *testing.T) {
+	t.Parallel()
+
+	g := mustParseExplain(t, `PLAN FRAGMENT 0
+  3:VHASH JOIN
+  |  join op: INNER JOIN(BROADCAST)
+  |
+  |----1:VOlapScanNode
+  |       TABLE: db.dim(d)
+  |
+  0:VOlapScanNode
+     TABLE: default_cluster:db.fact(f)
+
+PLAN FRAGMENT 1
+  2:VOlapScanNode
+     TABLE: db.dim(dim)
+  4:VHIVE_SCAN_NODE
+     TABLE: hive.ods.events
+`)
+	refs, nodeKeys := ExplainScanTableRefs(g)
+	want := []TableStatsRef{
+		{Database: "db", Table: "dim"},
+		{Database: "db", Table: "fact"},
+		{Catalog: "hive", Database: "ods", Table: "events"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("unexpected refs: %+v", refs)
+	}
+	if keys := nodeKeys[want[0]]; len(keys) != 2 {
+		t.Fatalf("unexpected dim node keys: %v", keys)
+	}
+}
+
+func TestBuildTableStatsEntry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.Local)
+	ref := TableStatsRef{Database: "db", Table: "fact"}
+	tableRows := []map[string]string{{
+		"updated_rows":      "300",
+		"row_count":         "1000",
+		"last_analyze_time": "2024-06-01 08:00:00",
+		"new_partition":     "false",
+	}}
+	columnRows := []map[string]string{
+		{"column_name": "k", "index_name": "fact", "count": "1000.0", "ndv": "998.0", "num_null": "0.0", "min": "1", "max": "1000", "updated_time": "2024-06-01 08:00:00"},
+		{"column_name": "k", "index_name": "mv_fact", "count": "10.0", "ndv": "10.0", "num_null": "0.0"},
+		{"column_name": "a", "index_name": "fact", "count": "1000.0", "ndv": "N/A", "num_null": "12.0", "updated_time": "2024-06-01 08:00:00"},
+	}
+	jobRows := []map[string]string{
+		{"job_id": "7", "state": "FINISHED"},
+		{"job_id": "9", "state": "failed", "message": "timeout"},
+	}
+
+	entry := buildTableStatsEntry(ref, tableRows, columnRows, jobRows, now)
+	if entry.RowCount == nil || *entry.RowCount != 1000 || entry.LastAnalyzed != "2024-06-01 08:00:00" {
+		t.Fatalf("unexpected table stats: %+v", entry)
+	}
+	if len(entry.Columns) != 2 || entry.Columns[0].Column != "a" || entry.Columns[0].NDV != nil || *entry.Columns[0].NullCount != 12 {
+		t.Fatalf("unexpected columns: %+v", entry.Columns)
+	}
+	if entry.AnalyzeJob == nil || entry.AnalyzeJob.JobID != "9" || entry.AnalyzeJob.State != "FAILED" {
+		t.Fatalf("unexpected analyze job: %+v", entry.AnalyzeJob)
+	}
+	wantReasons := []string{TableStatsRowsChanged, TableStatsOld, TableStatsAnalyzeFailed}
+	if !entry.Stale || !reflect.DeepEqual(entry.StaleReasons, wantReasons) {
+		t.Fatalf("unexpected staleness: %v %v", entry.Stale, entry.StaleReasons)
+	}
+
+	fresh := buildTableStatsEntry(ref, []map[string]string{{"row_count": "1000", "updated_rows": "0"}}, columnRows[:1], nil, time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local))
+	if fresh.Stale || len(fresh.StaleReasons) != 0 {
+		t.Fatalf("expected fresh stats: %v", fresh.StaleReasons)
+	}
+
+	never := buildTableStatsEntry(ref, nil, nil, nil, now)
+	if !reflect.DeepEqual(never.StaleReasons, []string{TableStatsNeverAnalyzed}) {
+		t.Fatalf("unexpected reasons: %v", never.StaleReasons)
+	}
+}
@@ -15,8 +15,18 @@ import (
 func main() {
 	var listenAddr string
 	var exportTimeout time.Duration
+	var config api.ServerConfig
+	var auditLogDirs string
+	var schemaAuditProfiles string
 	flag.StringVar(&listenAddr, "listen", "127.0.0.1:12306", "HTTP listen address")
 	flag.DurationVar(&exportTimeout, "export-timeout", 60*time.Second, "Doris audit log export timeout")
+	flag.IntVar(&config.ExportJobs.Concurrency, "job-concurrency", 2, "Max concurrently running audit export jobs")
+	flag.DurationVar(&config.ExportJobs.Timeout, "job-timeout", 30*time.Minute, "Audit export job timeout")
+	flag.DurationVar(&config.ExportJobs.TTL, "job-ttl", time.Hour, "How long finished export job results are kept")
+	flag.StringVar(&config.ExportJobs.Dir, "job-dir", "", "Directory for spooled export job results (default: OS temp dir)")
+	flag.StringVar(&auditLogDirs, "audit-log-dir", "", "Comma-separated FE log directories readable in fe.audit.log file mode (default: disabled)")
+	flag.StringVar(&schemaAuditProfiles, "schema-audit-profiles", "", "JSON file with extra named schema audit rule profiles")
+	flag.StringVar(&config.SchemaAuditWaiversFile, "schema-audit-waivers", "", "JSON file where schema audit waivers are kept (default: in memory)")
 	flag.Parse()
 	if exportTimeout <= 0 {
 		exportTimeout = 60 * time.Second
@@ -35,7 +45,29 @@ func main() {
 		os.Exit(2)
 	}
 
-	handler := api.NewServer(nil, exportTimeout)
+	for _, dir := range strings.Split(auditLogDirs, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			config.AuditLogDirs = append(config.AuditLogDirs, dir)
+		}
+	}
+
+	if schemaAuditProfiles != "" {
+		config.SchemaAuditProfiles, err = api.LoadSchemaAuditProfiles(schemaAuditProfiles)
+		if err != nil {
+			log.Printf("invalid --schema-audit-profiles %q: %v", schemaAuditProfiles, err)
+			os.Exit(2)
+		}
+	}
+
+	if config.SchemaAuditWaiversFile != "" {
+		config.SchemaAuditWaivers, err = api.LoadSchemaAuditWaivers(config.SchemaAuditWaiversFile)
+		if err != nil {
+			log.Printf("invalid --schema-audit-waivers %q: %v", config.SchemaAuditWaiversFile, err)
+			os.Exit(2)
+		}
+	}
+
+	handler := api.NewServerWithConfig(nil, exportTimeout, config)
 	httpServer := &http.Server{
 		Addr:              listenAddr,
 		Handler:           handler,
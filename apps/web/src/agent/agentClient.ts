@@ -3,16 +3,29 @@ export type DorisConnectionInfo = {
   port: number;
   user: string;
   database?: string;
+  catalog?: string;
 };
 
 export type DorisConnectionInput = DorisConnectionInfo & {
   password: string;
 };
 
+export type ExplainMode =
+  | "tree"
+  | "plan"
+  | "verbose"
+  | "graph"
+  | "shape"
+  | "memo"
+  | "physical"
+  | "optimized"
+  | "distributed";
+
 export type SchemaAuditFindingSummary = {
   ruleId: string;
   severity: string;
   summary: string;
+  suppressed?: boolean;
 };
 
 export type SchemaAuditScanItem = {
@@ -24,7 +37,10 @@ export type SchemaAuditScanItem = {
   dynamicPartitionEnabled: boolean;
   score: number;
   findingCount: number;
+  suppressedCount?: number;
   findings: SchemaAuditFindingSummary[];
+  deep?: boolean;
+  deepError?: string;
 };
 
 export type SchemaAuditInventory = {
@@ -37,6 +53,30 @@ export type SchemaAuditInventory = {
   dynamicPartitionTableCount: number;
 };
 
+export type SchemaAuditRuleConfig = {
+  emptyRatioWarn?: number;
+  emptyRatioCritical?: number;
+  emptyTailThreshold?: number;
+  dynamicWindowSpanWarn?: number;
+  dynamicWindowSpanCritical?: number;
+  tabletSizeMinBytes?: number;
+  tabletSizeMaxBytes?: number;
+  autoBucketMinBuckets?: number;
+  autoBucketMaxBuckets?: number;
+  partitionSizePerBucketGB?: number;
+  bucketOutOfBoundsRatio?: number;
+};
+
+export type SchemaAuditRuleMeta = {
+  id: string;
+  title: string;
+  category: string;
+  defaultSeverity: string;
+  weight: number;
+  inputs: Array<"scan_summary" | "table_detail">;
+  docsUrl?: string;
+};
+
 export type SchemaAuditScanResult = {
   inventory: SchemaAuditInventory;
   items: SchemaAuditScanItem[];
@@ -46,6 +86,44 @@ export type SchemaAuditScanResult = {
   truncated: boolean;
   scanLimit: number;
   warning?: string;
+  deepScan?: SchemaAuditDeepScanSummary;
+};
+
+export type SchemaAuditScanParams = {
+  connection: DorisConnectionInput;
+  database?: string;
+  tableLike?: string;
+  page?: number;
+  pageSize?: number;
+  profile?: string;
+  ruleConfig?: SchemaAuditRuleConfig;
+  disabledRules?: string[];
+  deep?: boolean;
+  concurrency?: number;
+  tableTimeoutSeconds?: number;
+};
+
+export type SchemaAuditScanEvent =
+  | { type: "inventory"; inventory: SchemaAuditInventory; total: number }
+  | { type: "item"; item: SchemaAuditScanItem; evaluated: number; total: number }
+  | { type: "warning"; warning: string }
+  | { type: "summary"; result: SchemaAuditScanResult }
+  | { type: "error"; error: string };
+
+export type SchemaAuditDeepScanSummary = {
+  concurrency: number;
+  tableTimeoutMs: number;
+  evaluated: number;
+  failed: number;
+  skipped: number;
+  elapsedMs: number;
+};
+
+export type SchemaAuditSuppression = {
+  waiverId: string;
+  reason: string;
+  author: string;
+  expiresAt: string;
 };
 
 export type SchemaAuditFinding = {
@@ -55,6 +133,7 @@ export type SchemaAuditFinding = {
   summary: string;
   evidence: Record<string, unknown>;
   recommendation?: string;
+  suppression?: SchemaAuditSuppression;
 };
 
 export type SchemaAuditPartition = {
@@ -171,6 +250,42 @@ function parseDatabasesData(data: unknown): { databases: string[] } {
   return { databases: values };
 }
 
+export type AuditLogExportPage = {
+  blob: Blob;
+  rowCount: number | null;
+  hasMore: boolean;
+  // Empty when the export is not paged (lookbackSeconds mode) or has no rows.
+  nextCursor: string;
+};
+
+function parseAuditLogExportPage(blob: Blob, headers: Headers): AuditLogExportPage {
+  const rowCount = headers.get("X-Audit-Log-Row-Count");
+  const parsed = rowCount === null ? null : Number(rowCount);
+  return {
+    blob,
+    rowCount: parsed !== null && Number.isFinite(parsed) ? parsed : null,
+    hasMore: headers.get("X-Audit-Log-Has-More") === "true",
+    nextCursor: headers.get("X-Audit-Log-Next-Cursor") ?? "",
+  };
+}
+
+export type DorisCatalog = {
+  name: string;
+  type: string;
+};
+
+function parseCatalogsData(data: unknown): { catalogs: DorisCatalog[] } {
+  const obj = asObject(data);
+  const values = obj?.catalogs;
+  if (
+    !Array.isArray(values) ||
+    values.some((v) => typeof asObject(v)?.name !== "string" || typeof asObject(v)?.type !== "string")
+  ) {
+    throw new Error(toInvalidDataMessage("expected { catalogs: { name, type }[] }", data));
+  }
+  return { catalogs: values as DorisCatalog[] };
+}
+
 function parseExplainData(data: unknown): { rawText: string } {
   const obj = asObject(data);
   if (!obj || typeof obj.rawText !== "string") {
@@ -206,6 +321,7 @@ function parseSchemaAuditFindingSummary(value: unknown, data: unknown): SchemaAu
     ruleId: obj.ruleId,
     severity: obj.severity,
     summary: obj.summary,
+    suppressed: obj.suppressed === true ? true : undefined,
   };
 }
 
@@ -244,7 +360,10 @@ function parseSchemaAuditScanItem(value: unknown, data: unknown): SchemaAuditSca
     dynamicPartitionEnabled: obj.dynamicPartitionEnabled,
     score,
     findingCount,
+    suppressedCount: toFiniteNumber(obj.suppressedCount) ?? undefined,
     findings,
+    deep: obj.deep === true ? true : undefined,
+    deepError: typeof obj.deepError === "string" ? obj.deepError : undefined,
   };
 }
 
@@ -315,6 +434,75 @@ function parseSchemaAuditScanData(data: unknown): SchemaAuditScanResult {
     truncated: obj.truncated === true,
     scanLimit,
     warning: typeof obj.warning === "string" ? obj.warning : undefined,
+    deepScan: parseSchemaAuditDeepScanSummary(obj.deepScan),
+  };
+}
+
+function parseSchemaAuditScanEvent(line: string): SchemaAuditScanEvent {
+  let data: unknown;
+  try {
+    data = JSON.parse(line) as unknown;
+  } catch {
+    throw new Error(toInvalidDataMessage("invalid schema audit stream line", line));
+  }
+  const obj = asObject(data);
+  switch (obj?.type) {
+    case "inventory":
+      return {
+        type: "inventory",
+        inventory: parseSchemaAuditInventory(obj.inventory, data),
+        total: toFiniteNumber(obj.total) ?? 0,
+      };
+    case "item":
+      return {
+        type: "item",
+        item: parseSchemaAuditScanItem(obj.item, data),
+        evaluated: toFiniteNumber(obj.evaluated) ?? 0,
+        total: toFiniteNumber(obj.total) ?? 0,
+      };
+    case "warning":
+      if (typeof obj.warning === "string") return { type: "warning", warning: obj.warning };
+      break;
+    case "summary":
+      return { type: "summary", result: parseSchemaAuditScanData(obj.result) };
+    case "error":
+      if (typeof obj.error === "string") return { type: "error", error: obj.error };
+      break;
+  }
+  throw new Error(toInvalidDataMessage("invalid schema audit stream event", data));
+}
+
+function parseSchemaAuditDeepScanSummary(value: unknown): SchemaAuditDeepScanSummary | undefined {
+  const obj = asObject(value);
+  if (!obj) {
+    return undefined;
+  }
+  return {
+    concurrency: toFiniteNumber(obj.concurrency) ?? 0,
+    tableTimeoutMs: toFiniteNumber(obj.tableTimeoutMs) ?? 0,
+    evaluated: toFiniteNumber(obj.evaluated) ?? 0,
+    failed: toFiniteNumber(obj.failed) ?? 0,
+    skipped: toFiniteNumber(obj.skipped) ?? 0,
+    elapsedMs: toFiniteNumber(obj.elapsedMs) ?? 0,
+  };
+}
+
+function parseSchemaAuditSuppression(value: unknown): SchemaAuditSuppression | undefined {
+  const obj = asObject(value);
+  if (
+    !obj ||
+    typeof obj.waiverId !== "string" ||
+    typeof obj.reason !== "string" ||
+    typeof obj.author !== "string" ||
+    typeof obj.expiresAt !== "string"
+  ) {
+    return undefined;
+  }
+  return {
+    waiverId: obj.waiverId,
+    reason: obj.reason,
+    author: obj.author,
+    expiresAt: obj.expiresAt,
   };
 }
 
@@ -342,6 +530,7 @@ function parseSchemaAuditFinding(value: unknown, data: unknown): SchemaAuditFind
     summary: obj.summary,
     evidence,
     recommendation: typeof obj.recommendation === "string" ? obj.recommendation : undefined,
+    suppression: parseSchemaAuditSuppression(obj.suppression),
   };
 }
 
@@ -469,11 +658,15 @@ export class AgentClient {
     }
   }
 
-  private async postBlob(path: string, payload: unknown, signal?: AbortSignal): Promise<Blob> {
+  private async postBlob(
+    path: string,
+    payload: unknown,
+    signal?: AbortSignal
+  ): Promise<{ blob: Blob; headers: Headers }> {
     const res = await this.post(path, payload, signal);
     if (!res.ok) throw await toResponseError(res);
     try {
-      return await res.blob();
+      return { blob: await res.blob(), headers: res.headers };
     } catch {
       throw new Error("Export interrupted. Please retry.");
     }
@@ -513,19 +706,41 @@ export class AgentClient {
     return res.databases;
   }
 
-  async exportAuditLogOutfileTsv(
+  async listDorisCatalogs(
+    params: { connection: DorisConnectionInput },
+    signal?: AbortSignal
+  ): Promise<DorisCatalog[]> {
+    const res = await this.postJson("/api/v1/doris/catalogs", params, parseCatalogsData, signal);
+    return res.catalogs;
+  }
+
+  async exportAuditLog(
     params: {
       connection: DorisConnectionInput;
-      lookbackSeconds: number;
+      lookbackSeconds?: number;
       limit: number;
+      from?: string;
+      to?: string;
+      cursor?: string;
+      format?: "tsv" | "ndjson" | "arrow" | "parquet";
+      columns?: string[];
+      normalize?: boolean;
+      redact?: "placeholder" | "hash";
+      redactSalt?: string;
     },
     signal?: AbortSignal
-  ): Promise<Blob> {
-    return this.postBlob("/api/v1/doris/audit-log/export", params, signal);
+  ): Promise<AuditLogExportPage> {
+    const { blob, headers } = await this.postBlob("/api/v1/doris/audit-log/export", params, signal);
+    return parseAuditLogExportPage(blob, headers);
   }
 
   async explain(
-    params: { connection: DorisConnectionInput; sql: string; mode?: "tree" | "plan" },
+    params: {
+      connection: DorisConnectionInput;
+      sql: string;
+      mode?: ExplainMode;
+      sessionVariables?: Record<string, string>;
+    },
     signal?: AbortSignal
   ): Promise<string> {
     const res = await this.postJson("/api/v1/doris/explain", params, parseExplainData, signal);
@@ -533,13 +748,7 @@ export class AgentClient {
   }
 
   async schemaAuditScan(
-    params: {
-      connection: DorisConnectionInput;
-      database?: string;
-      tableLike?: string;
-      page?: number;
-      pageSize?: number;
-    },
+    params: SchemaAuditScanParams,
     signal?: AbortSignal
   ): Promise<SchemaAuditScanResult> {
     return this.postJson(
@@ -550,11 +759,49 @@ export class AgentClient {
     );
   }
 
+  // Streams scan progress to onEvent and resolves with the final summary.
+  // Aborting signal cancels the scan on the agent.
+  async schemaAuditScanStream(
+    params: SchemaAuditScanParams,
+    onEvent: (event: SchemaAuditScanEvent) => void,
+    signal?: AbortSignal
+  ): Promise<SchemaAuditScanResult> {
+    const res = await this.post("/api/v1/doris/schema-audit/scan/stream", params, signal);
+    if (!res.ok) throw await toResponseError(res);
+    if (!res.body) throw new Error("Request interrupted. Please retry.");
+    const reader = res.body.getReader();
+    const decoder = new TextDecoder();
+    let buffered = "";
+    for (;;) {
+      let chunk: ReadableStreamReadResult<Uint8Array>;
+      try {
+        chunk = await reader.read();
+      } catch {
+        throw new Error("Request interrupted. Please retry.");
+      }
+      buffered += decoder.decode(chunk.value, { stream: !chunk.done });
+      const lines = buffered.split("\n");
+      buffered = chunk.done ? "" : (lines.pop() ?? "");
+      for (const line of lines) {
+        if (!line.trim()) continue;
+        const event = parseSchemaAuditScanEvent(line);
+        if (event.type === "error") throw new Error(event.error);
+        if (event.type === "summary") return event.result;
+        onEvent(event);
+      }
+      if (chunk.done) break;
+    }
+    throw new Error("Request interrupted. Please retry.");
+  }
+
   async schemaAuditTableDetail(
     params: {
       connection: DorisConnectionInput;
       database: string;
       table: string;
+      profile?: string;
+      ruleConfig?: SchemaAuditRuleConfig;
+      disabledRules?: string[];
     },
     signal?: AbortSignal
   ): Promise<SchemaAuditTableDetailResult> {
@@ -80,7 +80,7 @@ export default function DorisAuditLogImportModal(
     abortRef.current?.abort();
     abortRef.current = new AbortController();
     try {
-      const blob = await agent.exportAuditLogOutfileTsv(
+      const { blob } = await agent.exportAuditLog(
         {
           connection,
           lookbackSeconds: lb,